@@ -25,6 +25,14 @@ type BlockchainAPI interface {
 	TransactionsTotal() uint64
 	BlocksTotal() uint64
 	Balances(keys []string) []int64
+	AssetBalances(keys []string, asset []byte) []int64
+	AccountProof(keyBase64 string) *books.AccountProof
+	TransactionStatus(signature []byte) books.Receipt
+	TransactionProof(signature []byte) *block.TransactionProof
+	FeesEarned(keyBase64 string) int64
+	BlockFees(from, to uint64) []books.BlockFees
+	Supply() (total int64, pendingFees int64)
+	Mempool() (size int, capacity int, transactions []block.Transaction)
 	TransactionsFrom(keyBase64 string, offset, limit uint64) (*block.Transactions, uint64)
 	TransactionsTo(keyBase64 string, offset, limit uint64) (*block.Transactions, uint64)
 	AccountTransactions(keyBase64 string, offset, limit uint64) (*block.Transactions, uint64)
@@ -33,6 +41,9 @@ type BlockchainAPI interface {
 	BlockByHeight(height uint64) *block.Block
 	BlockByHash(hashBase64 string) *block.Block
 	BlockHeight() uint64
+	FinalizedHeight() uint64
+	Validators() []books.Validator
+	ValidatorNode(validator ed25519.PublicKey) *replication.NodeData
 	TPS() int64
 	BlockTime() int64
 	RandomKeys(uint64) []ed25519.PublicKey
@@ -41,11 +52,12 @@ type BlockchainAPI interface {
 
 // API struct stores all data source objects for blockchain api methods
 type API struct {
-	bm    *books.Accounts
-	db    DataBase
-	mint  *mint.Mint
-	sync  *replication.Sync
-	stats *Stats
+	bm      *books.Accounts
+	db      DataBase
+	mint    *mint.Mint
+	sync    *replication.Sync
+	mempool *books.Mempool
+	stats   *Stats
 }
 
 // Stats struct stores global statistics
@@ -101,6 +113,86 @@ func (api *API) Balances(keys []string) []int64 {
 	return balances
 }
 
+// AssetBalances takes array of account public keys in base64 format and returns
+// their balances in the user issued asset
+func (api *API) AssetBalances(keys []string, asset []byte) []int64 {
+	balances := make([]int64, len(keys))
+	for i := 0; i < len(keys); i++ {
+		key, _ := base64.StdEncoding.DecodeString(keys[i])
+		balances[i] = api.bm.AssetBalance(key, asset)
+	}
+	return balances
+}
+
+// AccountProof returns balance of 'keyBase64' account with Merkle proof of its inclusion into the state root
+func (api *API) AccountProof(keyBase64 string) *books.AccountProof {
+	key, err := base64.StdEncoding.DecodeString(keyBase64)
+	if err != nil {
+		return nil
+	}
+	return api.bm.AccountProof(key)
+}
+
+// TransactionStatus returns receipt of the transaction with given signature
+func (api *API) TransactionStatus(signature []byte) books.Receipt {
+	return api.bm.TransactionReceipt(signature)
+}
+
+// TransactionProof returns Merkle proof of inclusion of the transaction with
+// given signature into its block, nil if the transaction is not found
+func (api *API) TransactionProof(signature []byte) *block.TransactionProof {
+	return api.db.GetTransactionProof(signature)
+}
+
+// FeesEarned returns fees credited to 'keyBase64' node as producer
+func (api *API) FeesEarned(keyBase64 string) int64 {
+	key, _ := base64.StdEncoding.DecodeString(keyBase64)
+	return api.bm.FeesEarned(key)
+}
+
+// BlockFees returns fees collected from blocks with height between from and to inclusive
+func (api *API) BlockFees(from, to uint64) []books.BlockFees {
+	return api.bm.BlockFeesRange(from, to)
+}
+
+// Supply returns total supply of native tokens and fees which are not collected yet
+func (api *API) Supply() (int64, int64) {
+	return api.bm.TotalSupply(), api.bm.PendingFees()
+}
+
+// FinalizedHeight returns number of the highest block voted final by signers
+func (api *API) FinalizedHeight() uint64 {
+	return api.bm.FinalizedHeight()
+}
+
+// Validators returns validator set sorted by decreasing stake
+func (api *API) Validators() []books.Validator {
+	return api.bm.Validators()
+}
+
+// ValidatorNode returns node associated with the staking identity, nil if there is none
+func (api *API) ValidatorNode(validator ed25519.PublicKey) *replication.NodeData {
+	if api.sync == nil {
+		return nil
+	}
+	return api.sync.NodeOfValidator(validator)
+}
+
+// SetMempool sets mempool of the producer, api reports empty mempool without it
+func (api *API) SetMempool(mempool *books.Mempool) {
+	api.mempool = mempool
+}
+
+// Mempool returns number of waiting transactions, mempool capacity and its
+// contents in the order they will be processed
+func (api *API) Mempool() (int, int, []block.Transaction) {
+	if api.mempool == nil {
+		return 0, 0, nil
+	}
+	trans := api.mempool.Transactions()
+	return len(trans), api.mempool.Capacity(), trans
+}
+
 // TransactionsFrom returns transactions from 'keyBase64' account
 func (api *API) TransactionsFrom(keyBase64 string, offset, limit uint64) (*block.Transactions, uint64) {
 	key, _ := base64.StdEncoding.DecodeString(keyBase64)
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"encoding/base64"
 	"strconv"
 
 	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/books"
 	"github.com/Ansiblock/Ansiblock/replication"
 	"golang.org/x/crypto/ed25519"
 )
@@ -13,11 +15,23 @@ type BlockchainApiMock struct {
 	TransactionsTotalVal uint64
 	BlocksToTalVal       uint64
 	BalanceValues        []int64
+	Proof                *books.AccountProof
+	Receipt              books.Receipt
+	TxProof              *block.TransactionProof
+	FeesEarnedVal        int64
+	BlockFeesList        []books.BlockFees
+	SupplyVal            int64
+	PendingFeesVal       int64
+	MempoolList          []block.Transaction
+	MempoolCapacity      int
 	AccTransactions      *block.Transactions
 	BlockTransactions    *block.Transactions
 	BlocksList           []block.Block
 	Block                *block.Block
 	BlockHeightVal       uint64
+	FinalizedHeightVal   uint64
+	ValidatorsList       []books.Validator
+	ValidatorNodes       map[string]*replication.NodeData
 	TPSVal               int64
 	BlockTimeVal         int64
 	QueryParams          map[string]string
@@ -45,6 +59,57 @@ func (apiMock *BlockchainApiMock) Balances(keys []string) []int64 {
 	return apiMock.BalanceValues
 }
 
+func (apiMock *BlockchainApiMock) AssetBalances(keys []string, asset []byte) []int64 {
+	apiMock.QueryParams["asset"] = base64.StdEncoding.EncodeToString(asset)
+	return apiMock.BalanceValues
+}
+
+func (apiMock *BlockchainApiMock) AccountProof(keyBase64 string) *books.AccountProof {
+	apiMock.QueryParams["accountKey"] = keyBase64
+	return apiMock.Proof
+}
+
+func (apiMock *BlockchainApiMock) TransactionStatus(signature []byte) books.Receipt {
+	apiMock.QueryParams["signature"] = base64.StdEncoding.EncodeToString(signature)
+	return apiMock.Receipt
+}
+
+func (apiMock *BlockchainApiMock) TransactionProof(signature []byte) *block.TransactionProof {
+	apiMock.QueryParams["signature"] = base64.StdEncoding.EncodeToString(signature)
+	return apiMock.TxProof
+}
+
+func (apiMock *BlockchainApiMock) FeesEarned(keyBase64 string) int64 {
+	apiMock.QueryParams["nodeKey"] = keyBase64
+	return apiMock.FeesEarnedVal
+}
+
+func (apiMock *BlockchainApiMock) BlockFees(from, to uint64) []books.BlockFees {
+	apiMock.QueryParams["from"] = strconv.FormatUint(from, 10)
+	apiMock.QueryParams["to"] = strconv.FormatUint(to, 10)
+	return apiMock.BlockFeesList
+}
+
+func (apiMock *BlockchainApiMock) Supply() (int64, int64) {
+	return apiMock.SupplyVal, apiMock.PendingFeesVal
+}
+
+func (apiMock *BlockchainApiMock) FinalizedHeight() uint64 {
+	return apiMock.FinalizedHeightVal
+}
+
+func (apiMock *BlockchainApiMock) Validators() []books.Validator {
+	return apiMock.ValidatorsList
+}
+
+func (apiMock *BlockchainApiMock) ValidatorNode(validator ed25519.PublicKey) *replication.NodeData {
+	return apiMock.ValidatorNodes[string(validator)]
+}
+
+func (apiMock *BlockchainApiMock) Mempool() (int, int, []block.Transaction) {
+	return len(apiMock.MempoolList), apiMock.MempoolCapacity, apiMock.MempoolList
+}
+
 func (apiMock *BlockchainApiMock) TransactionsFrom(keyBase64 string, offset, limit uint64) (*block.Transactions, uint64) {
 	apiMock.QueryParams["from"] = keyBase64
 	apiMock.QueryParams["offset"] = strconv.Itoa(int(offset))
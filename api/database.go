@@ -30,6 +30,12 @@ const (
 
 	// check number of blocks in database every time counter reaches checkinterval
 	checkInterval = 100
+
+	// blockColumns are selected by block queries in the order they are scanned
+	blockColumns = "Height, Count, Val, numTrans, PrevHash, TxRoot, Producer, Signature, StateRoot"
+
+	// transactionColumns are selected by transaction queries in the order they are scanned
+	transactionColumns = "id, Height, [From], [To], Token, Fee, ValidVDFValue, Signature, Nonce, Version, Type, Memo, Outputs, Asset, Data"
 )
 
 // DB is database object, created by NewDBConnection method
@@ -51,6 +57,8 @@ type DataBase interface {
 	GetTransactionsFrom(from []byte, offset, limit uint64) (*block.Transactions, uint64)
 	GetTransactionsTo(to []byte, offset, limit uint64) (*block.Transactions, uint64)
 	GetAccountTransactions(account []byte, offset, limit uint64) (*block.Transactions, uint64)
+	GetTransactionProof(signature []byte) *block.TransactionProof
+	DeleteBlocksAfterHeight(height uint64) error
 }
 
 // NewDBConnection creates a connection to database
@@ -64,6 +72,7 @@ func NewDBConnection(dbFileName string) *DB {
 	checkErr(err)
 	db := DB{conn: connection, mutex: &sync.RWMutex{}}
 	db.createTablesIfNotExist()
+	db.migrateTables()
 	db.createIndexesIfNotExist()
 	return &db
 }
@@ -71,25 +80,71 @@ func NewDBConnection(dbFileName string) *DB {
 // helper function to create tables
 func (db *DB) createTablesIfNotExist() {
 	statement, err := db.conn.Prepare("CREATE TABLE IF NOT EXISTS blocks " +
-		"(Height INTEGER PRIMARY KEY, Count INTEGER, Val BLOB, numTrans INTEGER)")
+		"(Height INTEGER PRIMARY KEY, Count INTEGER, Val BLOB, numTrans INTEGER, PrevHash BLOB, TxRoot BLOB, Producer BLOB, Signature BLOB, StateRoot BLOB)")
 	checkErr(err)
 	_, err = statement.Exec()
 	checkErr(err)
 
 	stmt := "CREATE TABLE IF NOT EXISTS transactions (id INTEGER PRIMARY KEY, Height INTEGER, [From] BLOB, [To] BLOB, " +
-		"Token INTEGER, Fee INTEGER, ValidVDFValue BLOB, Signature BLOB, FOREIGN KEY (Height) REFERENCES blocks(Height))"
+		"Token INTEGER, Fee INTEGER, ValidVDFValue BLOB, Signature BLOB, Nonce INTEGER, Version INTEGER, Type INTEGER, Memo BLOB, Outputs BLOB, Asset BLOB, Data BLOB, FOREIGN KEY (Height) REFERENCES blocks(Height))"
 	statement, err = db.conn.Prepare(stmt)
 	checkErr(err)
 	_, err = statement.Exec()
 	checkErr(err)
 }
 
+// addedColumn is a column added to the table after the table was released
+type addedColumn struct {
+	table      string
+	name       string
+	definition string
+}
+
+// addedColumns are added to tables of databases created by older versions.
+// They are in the order of CREATE TABLE statements, so columns of migrated
+// and new tables are in the same order.
+var addedColumns = []addedColumn{
+	{"blocks", "PrevHash", "BLOB"},
+	{"blocks", "TxRoot", "BLOB"},
+	{"blocks", "Producer", "BLOB"},
+	{"blocks", "Signature", "BLOB"},
+	{"blocks", "StateRoot", "BLOB"},
+	{"transactions", "Nonce", "INTEGER NOT NULL DEFAULT 0"},
+	{"transactions", "Version", "INTEGER NOT NULL DEFAULT 0"},
+	{"transactions", "Type", "INTEGER NOT NULL DEFAULT 0"},
+	{"transactions", "Memo", "BLOB"},
+	{"transactions", "Outputs", "BLOB"},
+	{"transactions", "Asset", "BLOB"},
+	{"transactions", "Data", "BLOB"},
+}
+
+// migrateTables adds columns which are missing in existing tables
+func (db *DB) migrateTables() {
+	for _, column := range addedColumns {
+		if db.hasColumn(column.table, column.name) {
+			continue
+		}
+		log.Info(fmt.Sprintf("DB: add column %v to table %v", column.name, column.table))
+		_, err := db.conn.Exec(fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v %v", column.table, column.name, column.definition))
+		checkErr(err)
+	}
+}
+
+// hasColumn checks whether the table has column with given name
+func (db *DB) hasColumn(table, name string) bool {
+	var count int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, name).Scan(&count)
+	checkErr(err)
+	return count > 0
+}
+
 // helper function to create indexes
 func (db *DB) createIndexesIfNotExist() {
 	statements := []string{"CREATE INDEX IF NOT EXISTS blocks_vdf_index ON blocks (Val)",
 		"CREATE INDEX IF NOT EXISTS transactions_height_index ON transactions (Height)",
 		"CREATE INDEX IF NOT EXISTS transactions_from_index ON transactions ([From])",
-		"CREATE INDEX IF NOT EXISTS transactions_to_index ON transactions ([To])"}
+		"CREATE INDEX IF NOT EXISTS transactions_to_index ON transactions ([To])",
+		"CREATE INDEX IF NOT EXISTS transactions_signature_index ON transactions (Signature)"}
 
 	for _, stmt := range statements {
 		statement, err := db.conn.Prepare(stmt)
@@ -110,20 +165,22 @@ func checkErr(err error) {
 func (db *DB) SaveBlock(blk block.Block) error {
 	db.mutex.Lock()
 	defer db.mutex.Unlock()
-	statement, err := db.conn.Prepare("INSERT INTO blocks (Height, Count, Val, numTrans) VALUES(?, ?, ?, ?)")
+	statement, err := db.conn.Prepare("INSERT INTO blocks (Height, Count, Val, numTrans, PrevHash, TxRoot, Producer, Signature, StateRoot) " +
+		"VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	checkErr(err)
 	defer statement.Close()
-	_, err = statement.Exec(blk.Number, blk.Count, blk.Val, blk.Transactions.Count())
+	_, err = statement.Exec(blk.Number, blk.Count, blk.Val, blk.Transactions.Count(),
+		[]byte(blk.PrevHash), []byte(blk.TxRoot), []byte(blk.Producer), []byte(blk.Signature), blk.StateRoot)
 	checkErr(err)
 	transactions := blk.Transactions
 	tx, err := db.conn.Begin()
 	checkErr(err)
 	stmt, err := tx.Prepare("INSERT INTO transactions (Height, [From], [To], Token, Fee, " +
-		"ValidVDFValue, Signature) VALUES(?, ?, ?, ?, ?, ?, ?)")
+		"ValidVDFValue, Signature, Nonce, Version, Type, Memo, Outputs, Asset, Data) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	checkErr(err)
 	defer stmt.Close()
 	for _, t := range transactions.Ts {
-		_, err = stmt.Exec(blk.Number, t.From, t.To, t.Token, t.Fee, t.ValidVDFValue, t.Signature)
+		_, err = stmt.Exec(blk.Number, t.From, t.To, t.Token, t.Fee, t.ValidVDFValue, t.Signature, t.Nonce, t.Version, t.Type, t.Memo, block.SerializeOutputs(t.Outputs), t.Asset, t.Serialize())
 		checkErr(err)
 	}
 	// commit transaction
@@ -148,6 +205,26 @@ func (db *DB) checkAndDeleteOldData() {
 	}
 }
 
+// DeleteBlocksAfterHeight deletes blocks above the given height and their
+// transactions, it is used when the chain is rolled back
+func (db *DB) DeleteBlocksAfterHeight(height uint64) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM transactions WHERE Height > ?", height); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM blocks WHERE Height > ?", height); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
 // trims database size to "limit" number of blocks
 func (db *DB) deleteOldData(limit uint64) {
 	// Get heights of old transactions
@@ -190,10 +267,13 @@ func (db *DB) getBlock(query string, param interface{}) *block.Block {
 	defer db.mutex.RUnlock()
 	var blk block.Block
 	var numTrans int64
-	err := db.conn.QueryRow(query, param).Scan(&blk.Number, &blk.Count, &blk.Val, &numTrans)
+	var producer []byte
+	err := db.conn.QueryRow(query, param).Scan(&blk.Number, &blk.Count, &blk.Val, &numTrans,
+		&blk.PrevHash, &blk.TxRoot, &producer, &blk.Signature, &blk.StateRoot)
 	if err == sql.ErrNoRows {
 		return nil
 	}
+	blk.Producer = producer
 	blk.Transactions = &block.Transactions{Ts: make([]block.Transaction, numTrans)}
 	return &blk
 }
@@ -201,14 +281,14 @@ func (db *DB) getBlock(query string, param interface{}) *block.Block {
 // GetBlockByHash gets block from database with VDF value
 // returns pointer to block or nil if not found
 func (db *DB) GetBlockByHash(hash []byte) *block.Block {
-	query := "SELECT * FROM blocks WHERE Val = ?"
+	query := "SELECT " + blockColumns + " FROM blocks WHERE Val = ?"
 	return db.getBlock(query, hash)
 }
 
 // GetBlockByHeight gets block from database searching by height
 // returns pointer to block or nil if not found
 func (db *DB) GetBlockByHeight(height uint64) *block.Block {
-	query := "SELECT * FROM blocks WHERE Height = ?"
+	query := "SELECT " + blockColumns + " FROM blocks WHERE Height = ?"
 	return db.getBlock(query, height)
 }
 
@@ -231,9 +311,11 @@ func (db *DB) getBlocks(query string, offset, limit uint64) ([]block.Block, uint
 	var blkArray []block.Block
 	var blk block.Block
 	var numTrans uint64
+	var producer []byte
 	for rows.Next() {
-		err := rows.Scan(&blk.Number, &blk.Count, &blk.Val, &numTrans)
+		err := rows.Scan(&blk.Number, &blk.Count, &blk.Val, &numTrans, &blk.PrevHash, &blk.TxRoot, &producer, &blk.Signature, &blk.StateRoot)
 		checkErr(err)
+		blk.Producer = producer
 		blk.Transactions = &block.Transactions{Ts: make([]block.Transaction, numTrans)}
 		blkArray = append(blkArray, blk)
 	}
@@ -248,14 +330,14 @@ func (db *DB) getBlocks(query string, offset, limit uint64) ([]block.Block, uint
 // GetBlocksAfterHeight gets unseen new blocks from database by height,
 // including block with given height
 func (db *DB) GetBlocksAfterHeight(offset, limit uint64) ([]block.Block, uint64) {
-	query := "SELECT * FROM blocks WHERE Height >= ? ORDER BY Height ASC LIMIT ?"
+	query := "SELECT " + blockColumns + " FROM blocks WHERE Height >= ? ORDER BY Height ASC LIMIT ?"
 	return db.getBlocks(query, offset, limit)
 }
 
 // GetBlocksBeforeHeight gets old blocks from database by height,
 // including block with given height
 func (db *DB) GetBlocksBeforeHeight(offset, limit uint64) ([]block.Block, uint64) {
-	query := "SELECT * FROM blocks WHERE Height <= ? ORDER BY Height DESC LIMIT ?"
+	query := "SELECT " + blockColumns + " FROM blocks WHERE Height <= ? ORDER BY Height DESC LIMIT ?"
 	return db.getBlocks(query, offset, limit)
 }
 
@@ -271,8 +353,12 @@ func (db *DB) getTransactions(query string, params interface{}, offset, limit ui
 	var tr block.Transaction
 	var id uint64
 	var height int
+	var outputs []byte
+	var data []byte
 	for rows.Next() {
-		err := rows.Scan(&id, &height, &tr.From, &tr.To, &tr.Token, &tr.Fee, &tr.ValidVDFValue, &tr.Signature)
+		err := rows.Scan(&id, &height, &tr.From, &tr.To, &tr.Token, &tr.Fee, &tr.ValidVDFValue, &tr.Signature, &tr.Nonce, &tr.Version, &tr.Type, &tr.Memo, &outputs, &tr.Asset, &data)
+		checkErr(err)
+		tr.Outputs, err = block.DeserializeOutputs(outputs)
 		checkErr(err)
 		transactions.Ts = append(transactions.Ts, tr)
 	}
@@ -284,27 +370,72 @@ func (db *DB) getTransactions(query string, params interface{}, offset, limit ui
 // GetTxFromBlockByHeight returns transactions associated with block given by height
 // including transaction with given offset
 func (db *DB) GetTxFromBlockByHeight(height uint64, offset, limit uint64) (*block.Transactions, uint64) {
-	query := "SELECT * FROM transactions WHERE Height = ? AND id <= ? ORDER BY id DESC LIMIT ?"
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE Height = ? AND id <= ? ORDER BY id DESC LIMIT ?"
 	return db.getTransactions(query, height, offset, limit)
 }
 
 // GetTransactionsFrom gets transactions from DB sent from given address
 // including transaction with given offset
 func (db *DB) GetTransactionsFrom(from []byte, offset, limit uint64) (*block.Transactions, uint64) {
-	query := "SELECT * FROM transactions WHERE [From] = ? AND id <= ? ORDER BY id DESC LIMIT ?"
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE [From] = ? AND id <= ? ORDER BY id DESC LIMIT ?"
 	return db.getTransactions(query, from, offset, limit)
 }
 
 // GetTransactionsTo gets transactions from DB received by given address
 // including transaction with given offset
 func (db *DB) GetTransactionsTo(to []byte, offset, limit uint64) (*block.Transactions, uint64) {
-	query := "SELECT * FROM transactions WHERE [To] = ? AND id <= ? ORDER BY id DESC LIMIT ?"
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE [To] = ? AND id <= ? ORDER BY id DESC LIMIT ?"
 	return db.getTransactions(query, to, offset, limit)
 }
 
 // GetAccountTransactions gets abstract of given account from database
 // including transaction with given offset
 func (db *DB) GetAccountTransactions(account []byte, offset, limit uint64) (*block.Transactions, uint64) {
-	query := "SELECT * FROM transactions WHERE ? IN ([From], [To]) AND id <= ? ORDER BY id DESC LIMIT ?"
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE ? IN ([From], [To]) AND id <= ? ORDER BY id DESC LIMIT ?"
 	return db.getTransactions(query, account, offset, limit)
 }
+
+// blockTransactionsOf returns height and all transactions of the block which
+// contains transaction with given signature, in the order they are in the block
+func (db *DB) blockTransactionsOf(signature []byte) (uint64, *block.Transactions, bool) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+	var height uint64
+	err := db.conn.QueryRow("SELECT Height FROM transactions WHERE Signature = ?", signature).Scan(&height)
+	if err == sql.ErrNoRows {
+		return 0, nil, false
+	}
+	checkErr(err)
+	rows, err := db.conn.Query("SELECT Data FROM transactions WHERE Height = ? ORDER BY id ASC", height)
+	checkErr(err)
+	defer rows.Close()
+	transactions := new(block.Transactions)
+	var data []byte
+	for rows.Next() {
+		err := rows.Scan(&data)
+		checkErr(err)
+		var tr block.Transaction
+		if err = tr.DeserializeFromSlice(data); err != nil {
+			return 0, nil, false
+		}
+		transactions.Ts = append(transactions.Ts, tr)
+	}
+	err = rows.Err()
+	checkErr(err)
+	return height, transactions, true
+}
+
+// GetTransactionProof returns proof of inclusion of the transaction with given
+// signature into its block, nil if the transaction is not found
+func (db *DB) GetTransactionProof(signature []byte) *block.TransactionProof {
+	height, transactions, ok := db.blockTransactionsOf(signature)
+	if !ok {
+		return nil
+	}
+	blk := db.GetBlockByHeight(height)
+	if blk == nil {
+		return nil
+	}
+	blk.Transactions = transactions
+	return blk.TransactionProof(signature)
+}
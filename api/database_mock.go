@@ -10,6 +10,7 @@ type DBMock struct {
 	Trans  *block.Transactions
 	Block  *block.Block
 	Blocks []*block.Block
+	Proof  *block.TransactionProof
 	From   []byte
 	To     []byte
 }
@@ -18,6 +19,17 @@ func (db *DBMock) SaveBlock(blk block.Block) error {
 	return nil
 }
 
+func (db *DBMock) DeleteBlocksAfterHeight(height uint64) error {
+	blocks := make([]*block.Block, 0, len(db.Blocks))
+	for _, bl := range db.Blocks {
+		if bl.Number <= height {
+			blocks = append(blocks, bl)
+		}
+	}
+	db.Blocks = blocks
+	return nil
+}
+
 func (db *DBMock) GetBlockByHash(hash []byte) *block.Block {
 	return db.Block
 }
@@ -71,3 +83,7 @@ func (db *DBMock) GetTransactionsTo(to []byte, offset, limit uint64) (*block.Tra
 func (db *DBMock) GetAccountTransactions(account []byte, offset, limit uint64) (*block.Transactions, uint64) {
 	return db.Trans, 0
 }
+
+func (db *DBMock) GetTransactionProof(signature []byte) *block.TransactionProof {
+	return db.Proof
+}
@@ -1,9 +1,12 @@
 package api
 
 import (
+	"bytes"
 	"crypto/rand"
+	"database/sql"
 	"os"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/Ansiblock/Ansiblock/block"
@@ -20,7 +23,9 @@ func createBlock() block.Block {
 	tr2 := block.NewTransaction(&keypair2, keypair1.Public, 10, 11, vdf0)
 	trans := &block.Transactions{Ts: []block.Transaction{tr1, tr2}}
 	vdf1 := block.VDF(vdf0)
-	return block.Block{Count: 1, Val: vdf1, Transactions: trans}
+	bl := block.Block{Count: 1, Val: vdf1, Transactions: trans}
+	bl.Seal(&keypair1, &block.Block{Val: vdf0})
+	return bl
 }
 
 func TestNewDBConnection(t *testing.T) {
@@ -70,6 +75,23 @@ func TestGetBlockByHeight(t *testing.T) {
 	}
 }
 
+func TestBlockStateRoot(t *testing.T) {
+	os.Remove(DBFilename)
+	db := NewDBConnection(DBFilename)
+	producer := block.NewKeyPair()
+	b := createBlock()
+	b.StateRoot = block.TransactionsRoot(b.Transactions)
+	b.Seal(&producer, &block.Block{Val: block.VDF([]byte("state root"))})
+	db.SaveBlock(b)
+	blk := db.GetBlockByHeight(b.Number)
+	if blk == nil || !bytes.Equal(blk.StateRoot, b.StateRoot) || !bytes.Equal(blk.Hash(), b.Hash()) {
+		t.Fatalf("stored block should keep its state root and hash")
+	}
+	if vote := blk.Vote(); !vote.Verify() {
+		t.Errorf("signature of stored block should match its hash")
+	}
+}
+
 func TestGetBlocksStartingAtHeight(t *testing.T) {
 	os.Remove(DBFilename)
 	db := NewDBConnection(DBFilename)
@@ -146,3 +168,123 @@ func TestGetAccountTransactions(t *testing.T) {
 		}
 	}
 }
+
+func TestTransactionMemo(t *testing.T) {
+	os.Remove(DBFilename)
+	db := NewDBConnection(DBFilename)
+	keypair1 := block.NewKeyPair()
+	keypair2 := block.NewKeyPair()
+	vdf := block.VDF([]byte("memo"))
+	tr := block.NewTransactionWithMemo(&keypair1, keypair2.Public, 3, 1, vdf, []byte("invoice-42"))
+	b := block.Block{Count: 1, Val: block.VDF(vdf), Transactions: &block.Transactions{Ts: []block.Transaction{tr}}}
+	db.SaveBlock(b)
+	transPtr, _ := db.GetTxFromBlockByHeight(0, MaxOffset, 100)
+	if len(transPtr.Ts) != 1 || !transPtr.Ts[0].Equals(tr) || !transPtr.Ts[0].VerifySignature() {
+		t.Error("Error getting transaction with memo", transPtr.Ts)
+	}
+}
+
+func TestTransactionOutputs(t *testing.T) {
+	os.Remove(DBFilename)
+	db := NewDBConnection(DBFilename)
+	keypair1 := block.NewKeyPair()
+	keypair2 := block.NewKeyPair()
+	vdf := block.VDF([]byte("batch"))
+	outputs := []block.Output{block.Output{To: keypair1.Public, Token: 1}, block.Output{To: keypair2.Public, Token: 2}}
+	tr := block.NewBatchTransaction(&keypair1, outputs, 0, vdf)
+	b := block.Block{Count: 1, Val: block.VDF(vdf), Transactions: &block.Transactions{Ts: []block.Transaction{tr}}}
+	db.SaveBlock(b)
+	transPtr, _ := db.GetTxFromBlockByHeight(0, MaxOffset, 100)
+	if len(transPtr.Ts) != 1 || !transPtr.Ts[0].Equals(tr) || !transPtr.Ts[0].VerifySignature() {
+		t.Error("Error getting batch transaction", transPtr.Ts)
+	}
+}
+
+func TestDeleteBlocksAfterHeight(t *testing.T) {
+	os.Remove(DBFilename)
+	db := NewDBConnection(DBFilename)
+	for i := 0; i < 5; i++ {
+		b := createBlock()
+		b.Number = uint64(i)
+		db.SaveBlock(b)
+	}
+	if err := db.DeleteBlocksAfterHeight(2); err != nil {
+		t.Fatalf("Error deleting blocks: %v", err)
+	}
+	if db.GetBlockByHeight(2) == nil || db.GetBlockByHeight(3) != nil {
+		t.Error("Blocks above the height should be deleted")
+	}
+	if trans, _ := db.GetTxFromBlockByHeight(3, MaxOffset, maxTransactions); len(trans.Ts) != 0 {
+		t.Error("Transactions of deleted blocks should be deleted")
+	}
+}
+
+func TestGetTransactionProof(t *testing.T) {
+	os.Remove(DBFilename)
+	db := NewDBConnection(DBFilename)
+	producer := block.NewKeyPair()
+	var blocks []block.Block
+	for i := 0; i < 3; i++ {
+		b := createBlock()
+		b.Number = uint64(i)
+		b.Seal(&producer, nil)
+		db.SaveBlock(b)
+		blocks = append(blocks, b)
+	}
+	signature := blocks[1].Transactions.Ts[1].Signature
+	proof := db.GetTransactionProof(signature)
+	if proof == nil || !proof.Verify() || proof.Block.Number != 1 || proof.Index != 1 ||
+		!reflect.DeepEqual(proof.Block.Producer, producer.Public) {
+		t.Errorf("Error getting transaction proof %v", proof)
+	}
+	if db.GetTransactionProof([]byte("unknown")) != nil {
+		t.Error("Proof of unknown transaction should be nil")
+	}
+}
+
+// openOldDB returns database with tables created by the first released schema
+func openOldDB(t *testing.T) *DB {
+	os.Remove(DBFilename)
+	connection, err := sql.Open("sqlite3", DBFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statements := []string{"CREATE TABLE blocks (Height INTEGER PRIMARY KEY, Count INTEGER, Val BLOB, numTrans INTEGER)",
+		"CREATE TABLE transactions (id INTEGER PRIMARY KEY, Height INTEGER, [From] BLOB, [To] BLOB, " +
+			"Token INTEGER, Fee INTEGER, ValidVDFValue BLOB, Signature BLOB, FOREIGN KEY (Height) REFERENCES blocks(Height))"}
+	for _, stmt := range statements {
+		if _, err = connection.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &DB{conn: connection, mutex: &sync.RWMutex{}}
+}
+
+func TestMigrateTables(t *testing.T) {
+	db := openOldDB(t)
+	if _, err := db.conn.Exec("INSERT INTO blocks (Height, Count, Val, numTrans) VALUES(?, ?, ?, ?)", 7, 3, []byte("old"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.conn.Exec("INSERT INTO transactions (Height, [From], [To], Token, Fee, ValidVDFValue, Signature) VALUES(?, ?, ?, ?, ?, ?, ?)",
+		7, []byte("from"), []byte("to"), 10, 1, []byte("vdf"), []byte("old signature")); err != nil {
+		t.Fatal(err)
+	}
+	db.createTablesIfNotExist()
+	db.migrateTables()
+	db.migrateTables()
+	for _, column := range addedColumns {
+		if !db.hasColumn(column.table, column.name) {
+			t.Errorf("column %v should be added to table %v", column.name, column.table)
+		}
+	}
+	if blk := db.GetBlockByHeight(7); blk == nil || blk.Count != 3 || !bytes.Equal(blk.Val, []byte("old")) || blk.PrevHash != nil {
+		t.Errorf("blocks stored before migration should be read, got %v", blk)
+	}
+	trans, _ := db.GetTxFromBlockByHeight(7, 100, 10)
+	if len(trans.Ts) != 1 || trans.Ts[0].Token != 10 || !bytes.Equal(trans.Ts[0].Signature, []byte("old signature")) {
+		t.Errorf("transactions stored before migration should be read, got %v", trans.Ts)
+	}
+	if db.GetTransactionProof([]byte("old signature")) != nil {
+		t.Errorf("transactions stored without data should not be proved")
+	}
+}
@@ -85,6 +85,152 @@ func TestAccounts(t *testing.T) {
 	}
 }
 
+func TestAccountsWithAsset(t *testing.T) {
+	apiMock := NewBlockchainAPIMock()
+	apiMock.BalanceValues = []int64{5}
+	blockchainAPI = apiMock
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.POST("/api/accounts", accounts)
+	asset := base64.StdEncoding.EncodeToString(make([]byte, block.AssetIDSize))
+	keysJSON, _ := json.Marshal([]string{"key"})
+	request, _ := http.NewRequest(http.MethodPost, "/api/accounts?asset="+asset, bytes.NewBuffer(keysJSON))
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	var accountsList []AccountModel
+	json.Unmarshal(response.Body.Bytes(), &accountsList)
+	if response.Code != http.StatusOK || len(accountsList) != 1 || accountsList[0].Asset != asset ||
+		accountsList[0].Balance != 5 || apiMock.QueryParams["asset"] != asset {
+		t.Errorf("/api/accounts returned wrong asset balances: %v", response.Body.String())
+	}
+
+	request, _ = http.NewRequest(http.MethodPost, "/api/accounts?asset=abc", bytes.NewBuffer(keysJSON))
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	if response.Code != http.StatusBadRequest {
+		t.Errorf("/api/accounts should reject invalid asset, got %v", response.Code)
+	}
+}
+
+func TestFees(t *testing.T) {
+	apiMock := NewBlockchainAPIMock()
+	apiMock.FeesEarnedVal = 42
+	apiMock.BlockFeesList = []books.BlockFees{books.BlockFees{Height: 3, Collector: []byte("producer"), Fee: 7}}
+	apiMock.SupplyVal = 1000
+	apiMock.PendingFeesVal = 5
+	blockchainAPI = apiMock
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/api/fees", fees)
+	router.GET("/api/fees/blocks", blockFees)
+	router.GET("/api/supply", supply)
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/fees?nodeKey=cHJvZHVjZXI=", nil)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	var feesResp FeesModel
+	json.Unmarshal(response.Body.Bytes(), &feesResp)
+	if response.Code != http.StatusOK || feesResp.Earned != 42 || apiMock.QueryParams["nodeKey"] != "cHJvZHVjZXI=" {
+		t.Errorf("/api/fees returned wrong data: %v", response.Body.String())
+	}
+
+	request, _ = http.NewRequest(http.MethodGet, "/api/fees/blocks?from=1&to=5", nil)
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	var blockFeesResp []BlockFeesModel
+	json.Unmarshal(response.Body.Bytes(), &blockFeesResp)
+	if response.Code != http.StatusOK || len(blockFeesResp) != 1 || blockFeesResp[0].Fee != 7 ||
+		apiMock.QueryParams["from"] != "1" || apiMock.QueryParams["to"] != "5" {
+		t.Errorf("/api/fees/blocks returned wrong data: %v", response.Body.String())
+	}
+
+	request, _ = http.NewRequest(http.MethodGet, "/api/fees/blocks?from=5&to=1", nil)
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	if response.Code != http.StatusBadRequest {
+		t.Errorf("/api/fees/blocks should reject empty range, got %v", response.Code)
+	}
+
+	request, _ = http.NewRequest(http.MethodGet, "/api/supply", nil)
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	var supplyResp SupplyModel
+	json.Unmarshal(response.Body.Bytes(), &supplyResp)
+	if response.Code != http.StatusOK || supplyResp.Total != 1000 || supplyResp.PendingFees != 5 {
+		t.Errorf("/api/supply returned wrong data: %v", response.Body.String())
+	}
+}
+
+func TestMempool(t *testing.T) {
+	apiMock := NewBlockchainAPIMock()
+	trans := block.CreateRealTransactions(3)
+	apiMock.MempoolList = trans.Ts
+	apiMock.MempoolCapacity = 10
+	blockchainAPI = apiMock
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/api/mempool", mempool)
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/mempool", nil)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	var mempoolResp MempoolModel
+	json.Unmarshal(response.Body.Bytes(), &mempoolResp)
+	if response.Code != http.StatusOK || mempoolResp.Size != 3 || mempoolResp.Capacity != 10 ||
+		len(mempoolResp.Transactions) != 3 ||
+		mempoolResp.Transactions[1].Signature != base64.StdEncoding.EncodeToString(trans.Ts[1].Signature) {
+		t.Errorf("/api/mempool returned wrong data: %v", response.Body.String())
+	}
+}
+
+func TestFinality(t *testing.T) {
+	apiMock := NewBlockchainAPIMock()
+	apiMock.BlockHeightVal = 12
+	apiMock.FinalizedHeightVal = 10
+	blockchainAPI = apiMock
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/api/finality", finality)
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/finality", nil)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	var finalityResp FinalityModel
+	json.Unmarshal(response.Body.Bytes(), &finalityResp)
+	if response.Code != http.StatusOK || finalityResp.BlockHeight != 12 || finalityResp.FinalizedHeight != 10 {
+		t.Errorf("/api/finality returned wrong data: %v", response.Body.String())
+	}
+}
+
+func TestValidators(t *testing.T) {
+	apiMock := NewBlockchainAPIMock()
+	online := block.NewKeyPair()
+	offline := block.NewKeyPair()
+	apiMock.ValidatorsList = []books.Validator{books.Validator{PublicKey: online.Public, Stake: 70},
+		books.Validator{PublicKey: offline.Public, Stake: 30}}
+	apiMock.ValidatorNodes = map[string]*replication.NodeData{string(online.Public): &replication.NodeData{Self: []byte("node"), NodeName: "signer-1"}}
+	blockchainAPI = apiMock
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/api/validators", validators)
+
+	request, _ := http.NewRequest(http.MethodGet, "/api/validators", nil)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	var validatorsResp []ValidatorModel
+	json.Unmarshal(response.Body.Bytes(), &validatorsResp)
+	if response.Code != http.StatusOK || len(validatorsResp) != 2 || validatorsResp[0].Stake != 70 ||
+		validatorsResp[0].PublicKey != base64.StdEncoding.EncodeToString(online.Public) || validatorsResp[0].Node != "signer-1" ||
+		validatorsResp[1].Node != "" || validatorsResp[1].NodeKey != "" {
+		t.Errorf("/api/validators returned wrong data: %v", response.Body.String())
+	}
+}
+
 func TestRestAPIBlocks(t *testing.T) {
 	apiMock := NewBlockchainAPIMock()
 	numBlocks := 98
@@ -416,3 +562,121 @@ func TestFindTransactionsTo(t *testing.T) {
 		}
 	}
 }
+
+func TestAccountProof(t *testing.T) {
+	apiMock := NewBlockchainAPIMock()
+	am, keyPairs := books.RandomAccounts(10)
+	apiMock.Proof = am.AccountProof(keyPairs[2].Public)
+	blockchainAPI = apiMock
+	key := base64.StdEncoding.EncodeToString(keyPairs[2].Public)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/api/accounts/proof", accountProof)
+	request, err := http.NewRequest(http.MethodGet, "/api/accounts/proof?accountKey="+key, nil)
+	if err != nil {
+		t.Fatalf("Couldn’t create request: %v\n", err)
+	}
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	if response.Code != http.StatusOK {
+		t.Errorf("/api/accounts/proof failed with error code %v.", response.Code)
+	}
+
+	var resProof AccountProofModel
+	json.Unmarshal(response.Body.Bytes(), &resProof)
+	if apiMock.QueryParams["accountKey"] != key || resProof.Balance != apiMock.Proof.Balance || !resProof.Found ||
+		len(resProof.Proof) != len(apiMock.Proof.Proof) || resProof.Root != base64.StdEncoding.EncodeToString(am.StateRoot()) {
+		t.Errorf("/api/accounts/proof returned wrong data: %v.", response.Body.String())
+	}
+
+	apiMock.Proof = am.AccountProof(block.NewKeyPair().Public)
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	if response.Code != http.StatusNotFound {
+		t.Errorf("/api/accounts/proof for unknown account should fail, code %v.", response.Code)
+	}
+}
+
+func TestTransactionStatus(t *testing.T) {
+	apiMock := NewBlockchainAPIMock()
+	apiMock.Receipt = books.Receipt{Status: books.StatusConfirmed, Height: 17}
+	blockchainAPI = apiMock
+	kp := block.NewKeyPair()
+	tran := block.NewTransaction(&kp, kp.Public, 10, 0, block.VDF([]byte{1}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/api/transaction/:signature", transactionStatus)
+	request, err := http.NewRequest(http.MethodGet, "/api/transaction/"+base64.URLEncoding.EncodeToString(tran.Signature), nil)
+	if err != nil {
+		t.Fatalf("Couldn’t create request: %v\n", err)
+	}
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	if response.Code != http.StatusOK {
+		t.Errorf("/api/transaction failed with error code %v.", response.Code)
+	}
+	var status TransactionStatusModel
+	json.Unmarshal(response.Body.Bytes(), &status)
+	signature := base64.StdEncoding.EncodeToString(tran.Signature)
+	if status.Status != "confirmed" || status.Height != 17 || status.Signature != signature || apiMock.QueryParams["signature"] != signature {
+		t.Errorf("/api/transaction returned wrong data: %v.", response.Body.String())
+	}
+
+	apiMock.Receipt = books.Receipt{}
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	if response.Code != http.StatusNotFound {
+		t.Errorf("/api/transaction for unknown transaction should fail, code %v.", response.Code)
+	}
+
+	request, _ = http.NewRequest(http.MethodGet, "/api/transaction/%25%25", nil)
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	if response.Code != http.StatusBadRequest {
+		t.Errorf("/api/transaction with invalid signature should fail, code %v.", response.Code)
+	}
+}
+
+func TestTransactionProof(t *testing.T) {
+	apiMock := NewBlockchainAPIMock()
+	producer := block.NewKeyPair()
+	trans := block.CreateRealTransactions(3)
+	bl := block.New(block.VDF([]byte{1}), 4, 0, &trans)
+	bl.Seal(&producer, nil)
+	apiMock.TxProof = bl.TransactionProof(trans.Ts[2].Signature)
+	blockchainAPI = apiMock
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.GET("/api/transaction/:signature/proof", transactionProof)
+	request, err := http.NewRequest(http.MethodGet, "/api/transaction/"+base64.URLEncoding.EncodeToString(trans.Ts[2].Signature)+"/proof", nil)
+	if err != nil {
+		t.Fatalf("Couldn’t create request: %v\n", err)
+	}
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	if response.Code != http.StatusOK {
+		t.Errorf("/api/transaction/proof failed with error code %v.", response.Code)
+	}
+	var resProof TransactionProofModel
+	json.Unmarshal(response.Body.Bytes(), &resProof)
+	signature := base64.StdEncoding.EncodeToString(trans.Ts[2].Signature)
+	if resProof.Signature != signature || apiMock.QueryParams["signature"] != signature || resProof.Index != 2 ||
+		resProof.BlockHeight != 5 || len(resProof.Proof) != len(apiMock.TxProof.Proof) ||
+		resProof.TxRoot != base64.StdEncoding.EncodeToString(bl.TxRoot) ||
+		resProof.Producer != base64.StdEncoding.EncodeToString(producer.Public) {
+		t.Errorf("/api/transaction/proof returned wrong data: %v.", response.Body.String())
+	}
+
+	apiMock.TxProof = nil
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	if response.Code != http.StatusNotFound {
+		t.Errorf("/api/transaction/proof for unknown transaction should fail, code %v.", response.Code)
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"strconv"
@@ -11,6 +12,7 @@ import (
 	"github.com/gin-gonic/contrib/static"
 	"github.com/gin-gonic/gin"
 	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/books"
 )
 
 var blockchainAPI BlockchainAPI
@@ -32,13 +34,135 @@ type TransactionModel struct {
 	Fee           int64
 	ValidVDFValue string
 	Signature     string
+	Memo          string
+	Outputs       []OutputModel
+	Asset         string
+}
+
+// OutputModel is the data model of the batch transfer recipient
+type OutputModel struct {
+	To    string
+	Token int64
+}
+
+// newTransactionModel converts transaction to its data model, binary fields are base64 encoded
+func newTransactionModel(tr *block.Transaction) TransactionModel {
+	return TransactionModel{
+		From:          base64.StdEncoding.EncodeToString(tr.From),
+		To:            base64.StdEncoding.EncodeToString(tr.To),
+		Token:         tr.Token,
+		Fee:           tr.Fee,
+		ValidVDFValue: base64.StdEncoding.EncodeToString(tr.ValidVDFValue),
+		Signature:     base64.StdEncoding.EncodeToString(tr.Signature),
+		Memo:          base64.StdEncoding.EncodeToString(tr.Memo),
+		Outputs:       newOutputModels(tr.Outputs),
+		Asset:         base64.StdEncoding.EncodeToString(tr.Asset),
+	}
+}
+
+func newOutputModels(outputs []block.Output) []OutputModel {
+	res := make([]OutputModel, len(outputs))
+	for i, o := range outputs {
+		res[i].To = base64.StdEncoding.EncodeToString(o.To)
+		res[i].Token = o.Token
+	}
+	return res
 }
 
 // AccountModel is the data model of the Ansiblock blockchain Accounts.
-// It is passed to the front end to display each account public key with its balance
+// It is passed to the front end to display each account public key with its balance.
+// Asset is set when balance of user issued asset was requested.
 type AccountModel struct {
 	PublicKey string
 	Balance   int64
+	Asset     string `json:",omitempty"`
+}
+
+// ProofStepModel is a single step of Merkle proof, Left is true when Hash is the left sibling
+type ProofStepModel struct {
+	Hash string
+	Left bool
+}
+
+// AccountProofModel is the data model of account balance with Merkle inclusion proof.
+// Light clients verify it against the state root they trust.
+type AccountProofModel struct {
+	PublicKey string
+	Balance   int64
+	Nonce     uint64
+	Height    uint64
+	Root      string
+	Found     bool
+	Proof     []ProofStepModel
+}
+
+// TransactionProofModel is the data model of the transaction with Merkle proof of
+// its inclusion into the block. Transaction is the serialized transaction, which
+// is the Merkle leaf, the rest of the fields form the signed block header.
+type TransactionProofModel struct {
+	Signature      string
+	Transaction    string
+	Index          int
+	BlockHeight    uint64
+	Count          uint64
+	VDF            string
+	PrevHash       string
+	TxRoot         string
+	Producer       string
+	BlockSignature string
+	Proof          []ProofStepModel
+}
+
+// TransactionStatusModel is the data model of the transaction receipt.
+// Height is the block of the confirmed transaction or the last block at the moment of rejection.
+type TransactionStatusModel struct {
+	Signature string
+	Status    string
+	Reason    string
+	Height    uint64
+}
+
+// FeesModel is the data model of fees earned by the node as producer
+type FeesModel struct {
+	PublicKey string
+	Earned    int64
+}
+
+// BlockFeesModel is the data model of fees collected from a single block
+type BlockFeesModel struct {
+	Height    uint64
+	Collector string
+	Fee       int64
+}
+
+// SupplyModel is the data model of native token supply, Total includes pending fees
+type SupplyModel struct {
+	Total       int64
+	PendingFees int64
+}
+
+// MempoolModel is the data model of the producer mempool, Transactions are
+// ordered as they will be processed
+type MempoolModel struct {
+	Size         int
+	Capacity     int
+	Transactions []TransactionModel
+}
+
+// FinalityModel is the data model of block finality, blocks up to
+// FinalizedHeight are voted final by more than 2/3 of signers
+type FinalityModel struct {
+	BlockHeight     uint64
+	FinalizedHeight uint64
+}
+
+// ValidatorModel is the data model of a validator, Node is the name of the
+// node associated with the staking identity, it is empty for offline validators
+type ValidatorModel struct {
+	PublicKey string
+	Stake     int64
+	Node      string
+	NodeKey   string
 }
 
 // BlockModel is the data model of the Ansiblock blockchain blocks.
@@ -110,6 +234,11 @@ func accounts(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, "")
 		return
 	}
+	asset, err := base64.StdEncoding.DecodeString(strings.Replace(c.Query("asset"), " ", "+", -1))
+	if err != nil || (len(asset) != 0 && len(asset) != block.AssetIDSize) {
+		c.JSON(http.StatusBadRequest, "")
+		return
+	}
 	bodyBytes, _ := ioutil.ReadAll(c.Request.Body)
 	var accountKeys []string
 	json.Unmarshal(bodyBytes, &accountKeys)
@@ -123,14 +252,174 @@ func accounts(c *gin.Context) {
 		}
 	}
 
-	balances := blockchainAPI.Balances(accountKeys)
+	var balances []int64
+	if len(asset) == 0 {
+		balances = blockchainAPI.Balances(accountKeys)
+	} else {
+		balances = blockchainAPI.AssetBalances(accountKeys, asset)
+	}
 
 	resp := make([]AccountModel, len(balances))
 	for i := 0; i < len(balances); i++ {
 		resp[i].PublicKey = accountKeys[i]
 		resp[i].Balance = balances[i]
+		if len(asset) != 0 {
+			resp[i].Asset = base64.StdEncoding.EncodeToString(asset)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func accountProof(c *gin.Context) {
+	key := strings.Replace(c.Query("accountKey"), " ", "+", -1)
+	proof := blockchainAPI.AccountProof(key)
+	if key == "" || proof == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid parameter",
+		})
+		return
+	}
+	resp := new(AccountProofModel)
+	resp.PublicKey = key
+	resp.Balance = proof.Balance
+	resp.Nonce = proof.Nonce
+	resp.Height = proof.Height
+	resp.Root = base64.StdEncoding.EncodeToString(proof.Root)
+	resp.Found = proof.Found
+	resp.Proof = make([]ProofStepModel, len(proof.Proof))
+	for i, step := range proof.Proof {
+		resp.Proof[i].Hash = base64.StdEncoding.EncodeToString(step.Hash)
+		resp.Proof[i].Left = step.Left
+	}
+	if !proof.Found {
+		c.JSON(http.StatusNotFound, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// decodeSignature accepts URL-safe (padded or not) and standard base64 encodings
+func decodeSignature(signature string) ([]byte, error) {
+	for _, encoding := range []*base64.Encoding{base64.URLEncoding, base64.RawURLEncoding, base64.StdEncoding} {
+		if res, err := encoding.DecodeString(signature); err == nil {
+			return res, nil
+		}
+	}
+	return nil, errors.New("invalid signature encoding")
+}
+
+func transactionStatus(c *gin.Context) {
+	signature, err := decodeSignature(c.Param("signature"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid parameter",
+		})
+		return
 	}
+	receipt := blockchainAPI.TransactionStatus(signature)
+	resp := new(TransactionStatusModel)
+	resp.Signature = base64.StdEncoding.EncodeToString(signature)
+	resp.Status = books.StatusName(receipt.Status)
+	resp.Reason = books.ReasonName(receipt.Reason)
+	resp.Height = receipt.Height
+	if receipt.Status == books.StatusUnknown {
+		c.JSON(http.StatusNotFound, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func transactionProof(c *gin.Context) {
+	signature, err := decodeSignature(c.Param("signature"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid parameter",
+		})
+		return
+	}
+	proof := blockchainAPI.TransactionProof(signature)
+	if proof == nil {
+		c.JSON(http.StatusNotFound, "")
+		return
+	}
+	resp := new(TransactionProofModel)
+	resp.Signature = base64.StdEncoding.EncodeToString(signature)
+	resp.Transaction = base64.StdEncoding.EncodeToString(proof.Transaction.Serialize())
+	resp.Index = proof.Index
+	resp.BlockHeight = proof.Block.Number
+	resp.Count = proof.Block.Count
+	resp.VDF = base64.StdEncoding.EncodeToString(proof.Block.Val)
+	resp.PrevHash = base64.StdEncoding.EncodeToString(proof.Block.PrevHash)
+	resp.TxRoot = base64.StdEncoding.EncodeToString(proof.Block.TxRoot)
+	resp.Producer = base64.StdEncoding.EncodeToString(proof.Block.Producer)
+	resp.BlockSignature = base64.StdEncoding.EncodeToString(proof.Block.Signature)
+	resp.Proof = make([]ProofStepModel, len(proof.Proof))
+	for i, step := range proof.Proof {
+		resp.Proof[i].Hash = base64.StdEncoding.EncodeToString(step.Hash)
+		resp.Proof[i].Left = step.Left
+	}
+	c.JSON(http.StatusOK, resp)
+}
 
+func fees(c *gin.Context) {
+	key := strings.Replace(c.Query("nodeKey"), " ", "+", -1)
+	if _, err := base64.StdEncoding.DecodeString(key); key == "" || err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid parameter",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, FeesModel{PublicKey: key, Earned: blockchainAPI.FeesEarned(key)})
+}
+
+func blockFees(c *gin.Context) {
+	from, err1 := strconv.ParseUint(c.Query("from"), 10, 64)
+	to, err2 := strconv.ParseUint(c.Query("to"), 10, 64)
+	if err1 != nil || err2 != nil || from > to {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid parameter",
+		})
+		return
+	}
+	list := blockchainAPI.BlockFees(from, to)
+	resp := make([]BlockFeesModel, len(list))
+	for i, f := range list {
+		resp[i].Height = f.Height
+		resp[i].Collector = base64.StdEncoding.EncodeToString(f.Collector)
+		resp[i].Fee = f.Fee
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func supply(c *gin.Context) {
+	total, pending := blockchainAPI.Supply()
+	c.JSON(http.StatusOK, SupplyModel{Total: total, PendingFees: pending})
+}
+
+func mempool(c *gin.Context) {
+	size, capacity, trans := blockchainAPI.Mempool()
+	resp := MempoolModel{Size: size, Capacity: capacity, Transactions: make([]TransactionModel, len(trans))}
+	for i := range trans {
+		resp.Transactions[i] = newTransactionModel(&trans[i])
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func finality(c *gin.Context) {
+	c.JSON(http.StatusOK, FinalityModel{BlockHeight: blockchainAPI.BlockHeight(), FinalizedHeight: blockchainAPI.FinalizedHeight()})
+}
+
+func validators(c *gin.Context) {
+	vals := blockchainAPI.Validators()
+	resp := make([]ValidatorModel, len(vals))
+	for i, v := range vals {
+		resp[i] = ValidatorModel{PublicKey: base64.StdEncoding.EncodeToString(v.PublicKey), Stake: v.Stake}
+		if node := blockchainAPI.ValidatorNode(v.PublicKey); node != nil {
+			resp[i].Node = node.NodeName
+			resp[i].NodeKey = base64.StdEncoding.EncodeToString(node.Self)
+		}
+	}
 	c.JSON(http.StatusOK, resp)
 }
 
@@ -164,13 +453,8 @@ func blockTransactions(c *gin.Context) {
 	offset, limit := extractOffsetAndLimit(c.Query("offset"), c.Query("limit"))
 	trans, resOffset := blockchainAPI.BlockTransactionsByHeight(height, offset, limit)
 	resTransactions := make([]TransactionModel, len(trans.Ts))
-	for i, tr := range trans.Ts {
-		resTransactions[i].Fee = tr.Fee
-		resTransactions[i].From = base64.StdEncoding.EncodeToString(tr.From)
-		resTransactions[i].Signature = base64.StdEncoding.EncodeToString(tr.Signature)
-		resTransactions[i].To = base64.StdEncoding.EncodeToString(tr.To)
-		resTransactions[i].Token = tr.Token
-		resTransactions[i].ValidVDFValue = base64.StdEncoding.EncodeToString(tr.ValidVDFValue)
+	for i := range trans.Ts {
+		resTransactions[i] = newTransactionModel(&trans.Ts[i])
 	}
 
 	resp := new(TransactinListModel)
@@ -200,13 +484,8 @@ func transactions(c *gin.Context) {
 	key := c.Query("accountKey")
 	trans, resOffset := blockchainAPI.AccountTransactions(key, offset, limit)
 	resTransactions := make([]TransactionModel, len(trans.Ts))
-	for i, tr := range trans.Ts {
-		resTransactions[i].Fee = tr.Fee
-		resTransactions[i].From = base64.StdEncoding.EncodeToString(tr.From)
-		resTransactions[i].Signature = base64.StdEncoding.EncodeToString(tr.Signature)
-		resTransactions[i].To = base64.StdEncoding.EncodeToString(tr.To)
-		resTransactions[i].Token = tr.Token
-		resTransactions[i].ValidVDFValue = base64.StdEncoding.EncodeToString(tr.ValidVDFValue)
+	for i := range trans.Ts {
+		resTransactions[i] = newTransactionModel(&trans.Ts[i])
 	}
 
 	resp := new(TransactinListModel)
@@ -259,13 +538,8 @@ func findTransactions(c *gin.Context) {
 	}
 
 	resTransactions := make([]TransactionModel, len(trans.Ts))
-	for i, tr := range trans.Ts {
-		resTransactions[i].Fee = tr.Fee
-		resTransactions[i].From = base64.StdEncoding.EncodeToString(tr.From)
-		resTransactions[i].Signature = base64.StdEncoding.EncodeToString(tr.Signature)
-		resTransactions[i].To = base64.StdEncoding.EncodeToString(tr.To)
-		resTransactions[i].Token = tr.Token
-		resTransactions[i].ValidVDFValue = base64.StdEncoding.EncodeToString(tr.ValidVDFValue)
+	for i := range trans.Ts {
+		resTransactions[i] = newTransactionModel(&trans.Ts[i])
 	}
 	resp := new(TransactinListModel)
 	resp.Ts = resTransactions
@@ -293,10 +567,19 @@ func setupRouter() {
 
 	router.GET("/api/stats", stats)
 	router.POST("/api/accounts", accounts)
+	router.GET("/api/accounts/proof", accountProof)
+	router.GET("/api/fees", fees)
+	router.GET("/api/fees/blocks", blockFees)
+	router.GET("/api/supply", supply)
+	router.GET("/api/mempool", mempool)
+	router.GET("/api/finality", finality)
+	router.GET("/api/validators", validators)
 	router.GET("/api/blocks", blocks)
 	router.GET("/api/blockTransactions", blockTransactions)
 	router.GET("/api/nodes", nodes)
 	router.GET("/api/transactions", transactions)
+	router.GET("/api/transaction/:signature", transactionStatus)
+	router.GET("/api/transaction/:signature/proof", transactionProof)
 	router.GET("/api/findBlock", findBlock)
 	router.GET("/api/findTransactions", findTransactions)
 
@@ -0,0 +1,80 @@
+package block
+
+import (
+	"crypto/sha256"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	// AssetIDSize is the size of user issued asset identifier
+	AssetIDSize = sha256.Size
+	// assetTransferSize is the size of TypeMintAsset and TypeAssetTransfer body: asset, to, token
+	assetTransferSize = AssetIDSize + ed25519.PublicKeySize + 8
+)
+
+// NewCreateAsset will create new TransactionV1 object which issues new asset.
+// Supply is credited to the mint authority, which can mint more of the asset
+// later. Fee is paid in native tokens. Asset is identified by AssetID of the transaction.
+func NewCreateAsset(from *KeyPair, authority ed25519.PublicKey, supply int64, fee int64, validVDFValue VDFValue) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeCreateAsset, From: from.Public, To: authority, Token: supply,
+		Fee: fee, ValidVDFValue: validVDFValue}
+	tr.Sign(from)
+	return tr
+}
+
+// NewMintAsset will create new TransactionV1 object which mints token of the asset to the recipient.
+// It must be sent by the asset mint authority.
+func NewMintAsset(from *KeyPair, asset []byte, to ed25519.PublicKey, token int64, fee int64, validVDFValue VDFValue) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeMintAsset, From: from.Public, Asset: asset, To: to, Token: token,
+		Fee: fee, ValidVDFValue: validVDFValue}
+	tr.Sign(from)
+	return tr
+}
+
+// NewAssetTransfer will create new TransactionV1 object which transfers token of the asset.
+// Fee is paid in native tokens.
+func NewAssetTransfer(from *KeyPair, asset []byte, to ed25519.PublicKey, token int64, fee int64, validVDFValue VDFValue) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeAssetTransfer, From: from.Public, Asset: asset, To: to, Token: token,
+		Fee: fee, ValidVDFValue: validVDFValue}
+	tr.Sign(from)
+	return tr
+}
+
+// AssetID returns identifier of the asset issued by TypeCreateAsset transaction
+func (t *Transaction) AssetID() []byte {
+	id := sha256.Sum256(t.Signature)
+	return id[:]
+}
+
+// IsAssetTransaction returns true if transaction moves tokens of user issued asset
+func (t *Transaction) IsAssetTransaction() bool {
+	return t.Type == TypeCreateAsset || t.Type == TypeMintAsset || t.Type == TypeAssetTransfer
+}
+
+// serializeAssetTransfer returns TypeMintAsset and TypeAssetTransfer body
+func (t *Transaction) serializeAssetTransfer() []byte {
+	res := make([]byte, 0, assetTransferSize)
+	res = append(res, fixed(t.Asset, AssetIDSize)...)
+	res = append(res, fixed(t.To, ed25519.PublicKeySize)...)
+	return append(res, uint64ToBytes(uint64(t.Token))...)
+}
+
+// deserializeAssetTransfer reads TypeMintAsset and TypeAssetTransfer body
+func (t *Transaction) deserializeAssetTransfer(body []byte) (int, error) {
+	if len(body) < assetTransferSize {
+		return 0, errShortTransaction
+	}
+	t.Asset = append([]byte(nil), body[:AssetIDSize]...)
+	t.To = append([]byte(nil), body[AssetIDSize:AssetIDSize+ed25519.PublicKeySize]...)
+	t.Token = ByteToInt64(body, AssetIDSize+ed25519.PublicKeySize)
+	return assetTransferSize, nil
+}
+
+// verifyAsset verifies fields of asset transaction types
+func (t *Transaction) verifyAsset() bool {
+	if t.Token < 0 || t.Fee < 0 || len(t.To) != ed25519.PublicKeySize {
+		return false
+	}
+	return t.Type == TypeCreateAsset || len(t.Asset) == AssetIDSize
+}
@@ -0,0 +1,36 @@
+package block
+
+import "testing"
+
+func TestSerializeAsset(t *testing.T) {
+	kp := NewKeyPair()
+	to := NewKeyPair()
+	create := NewCreateAsset(&kp, kp.Public, 1000, 1, VDF([]byte{1}))
+	asset := create.AssetID()
+	if len(asset) != AssetIDSize {
+		t.Errorf("asset id should be %v bytes", AssetIDSize)
+	}
+	for _, tran := range []Transaction{create, NewMintAsset(&kp, asset, to.Public, 10, 0, VDF([]byte{1})),
+		NewAssetTransfer(&kp, asset, to.Public, 10, 2, VDF([]byte{1}))} {
+		var tr Transaction
+		if err := tr.DeserializeFromSlice(tran.Serialize()); err != nil || !tr.Equals(tran) || !tr.VerifySignature() {
+			t.Errorf("Serialize/deserialize of asset transaction problem: %v, %v != %v", err, tr, tran)
+		}
+		if !tran.Verify() || !tran.IsAssetTransaction() || tran.Amount() != tran.Fee {
+			t.Errorf("asset transaction should be valid and cost only the fee")
+		}
+		tran.Token = -1
+		if tran.Verify() {
+			t.Errorf("asset transaction with negative token should be invalid")
+		}
+	}
+	transfer := NewAssetTransfer(&kp, asset[:10], to.Public, 10, 2, VDF([]byte{1}))
+	if transfer.Verify() {
+		t.Errorf("asset transfer should refer to asset id")
+	}
+	s := transfer.Serialize()
+	var tr Transaction
+	if err := tr.DeserializeFromSlice(s[:len(s)-1]); err != errShortTransaction {
+		t.Errorf("expected errShortTransaction, got %v", err)
+	}
+}
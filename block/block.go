@@ -20,12 +20,18 @@ import (
 // if `transactions` is not empty then Block performes one additional iteration
 // and adds transactions' data into the generated VDF value.
 // This way we can timestamp the transactions.
+// `VDFProof` certifies `count` iterations of the VDF scheme selected in genesis,
+// so that the block can be verified without replaying them, see VDFScheme.
+// `Header` links the block to the previous block and is signed by the producer, see Header.
 // Note: application of VDFs is fragile as it requires precise bounds on the
 // attacker’s computation speed.
 type Block struct {
-	Number       uint64
-	Count        uint64
-	Val          VDFValue
+	Number uint64
+	Count  uint64
+	Val    VDFValue
+	// VDFProof holds checkpoints for iterated SHA-256
+	VDFProof []byte
+	Header
 	Transactions *Transactions
 }
 
@@ -49,19 +55,23 @@ func New(previousValue VDFValue, previousBlockNumber uint64, count uint64, trans
 	return Block{Number: previousBlockNumber + 1, Count: c + count, Val: vdfValue, Transactions: trans}
 }
 
-// NextBlock returns Block with increased VDFValue
-func NextBlock(previousValue VDFValue, previousBlockNumber uint64, count uint64, trans *Transactions) Block {
-	nextVal, _ := nextVDF(previousValue, count, trans)
-	return Block{Number: previousBlockNumber + 1, Count: count, Val: nextVal, Transactions: trans}
+// NextBlock returns Block with VDFValue increased by the scheme
+func NextBlock(scheme VDFScheme, previousValue VDFValue, previousBlockNumber uint64, count uint64, trans *Transactions) Block {
+	nextVal, proof := nextVDF(scheme, previousValue, count, trans)
+	return Block{Number: previousBlockNumber + 1, Count: count, Val: nextVal, VDFProof: proof, Transactions: trans}
 }
 
-// Verify method verifies that block is legal
+// Verify method verifies that block is legal, its proof is checked by the VDF scheme of the chain
 // TODO should be added transaction verification
-func (b *Block) Verify(previousValue VDFValue) bool {
+func (b *Block) Verify(scheme VDFScheme, previousValue VDFValue) bool {
 	if b.Transactions != nil && !b.Transactions.Verify() {
 		return false
 	}
-	vdfValue, _ := nextVDF(previousValue, b.Count, b.Transactions)
+	vdfValue, ok := scheme.Verify(previousValue, vdfIterations(b.Count, b.Transactions), b.VDFProof)
+	if !ok {
+		return false
+	}
+	vdfValue, _ = nextVDFForNew(vdfValue, b.Transactions)
 	return bytes.Equal(b.Val, vdfValue)
 }
 
@@ -70,7 +80,9 @@ func (b *Block) Size() int {
 	byteCount := 0
 	byteCount += 8           //block.number
 	byteCount += 8 + VDFSize // block.Count + block.VDFValue
-	byteCount += 4           //len(block.transactions)
+	byteCount += 2 + len(b.VDFProof)
+	byteCount += HeaderSize // block.Header
+	byteCount += 4          //len(block.transactions)
 	if b.Transactions != nil {
 		byteCount += b.Transactions.Size()
 	}
@@ -82,20 +94,25 @@ func (b *Block) String() string {
 	return b.Transactions.String()
 }
 
-// nextVDF returns next value of vdf and count number.
-// nextVDF uses VDF function count number of times and once more
-// if transactions is not empty.
-func nextVDF(previousValue VDFValue, count uint64, transactions *Transactions) (VDFValue, uint64) {
-	value := previousValue
-	returnCount := count
-	for i := uint64(1); i < count; i++ {
-		value = VDF(value)
+// vdfIterations returns number of VDF iterations in the block of count.
+// When transactions are not empty, mixing them in is the last iteration.
+func vdfIterations(count uint64, transactions *Transactions) uint64 {
+	if count != 0 && transactions != nil && transactions.Count() != 0 {
+		return count - 1
 	}
-	value, c := nextVDFForNew(value, transactions)
-	if c == 0 && count != 0 {
-		value = VDF(value)
+	return count
+}
+
+// nextVDF returns next value of vdf and its proof.
+// nextVDF evaluates VDF scheme for the iterations of the block and mixes in
+// transactions if they are not empty.
+func nextVDF(scheme VDFScheme, previousValue VDFValue, count uint64, transactions *Transactions) (VDFValue, []byte) {
+	evaluator := scheme.NewEvaluator(previousValue)
+	for i := vdfIterations(count, transactions); i > 0; i-- {
+		evaluator.Step()
 	}
-	return value, returnCount
+	value, _ := nextVDFForNew(evaluator.Value(), transactions)
+	return value, evaluator.Proof()
 }
 
 func nextVDFForNew(previousValue VDFValue, transactions *Transactions) (VDFValue, uint64) {
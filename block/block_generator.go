@@ -12,8 +12,14 @@ import (
 	"go.uber.org/zap"
 )
 
-const transactionSize = ed25519.PublicKeySize*2 + ed25519.SignatureSize + 16 + sha256.Size
-const maxTransactionsInBlock = int32(network.BlobRealDataSize/transactionSize - 2)
+// transactionSize is the size of TransactionV0 with nonce packed into blob
+const transactionSize = ed25519.PublicKeySize*2 + ed25519.SignatureSize + 16 + sha256.Size + 8 + transactionSizePrefix
+
+// maxTransactionsInBlock leaves room for the block header and the largest vdf proof
+const maxTransactionsInBlock = int32((network.BlobRealDataSize - blockHeaderSize - MaxVDFProofSize) / transactionSize)
+
+// maxBlockDataSize limits size of block transactions, so that the block fits into a single blob
+const maxBlockDataSize = int(maxTransactionsInBlock) * transactionSize
 
 // generatorHelper holds valid VDF value and count of VDF iterations since last block
 type generatorHelper struct {
@@ -47,15 +53,14 @@ func Generator(transactionsReceiver <-chan *Transactions, previousValue VDFValue
 				nb = NewEmpty(generator.validVDFValue, generator.number, generator.count)
 				nb.Transactions = transactions
 				blocks = append(blocks, nb)
+				generator.count = 0
+				generator.number = nb.Number
 				log.Debug("block.Generator: received zero transactions, creating empty block", zap.Uint64("Height", nb.Number))
 			} else {
 				var start int32
 				for start < transactions.Count() {
 					transactionsSlice := new(Transactions)
-					end := start + maxTransactionsInBlock
-					if end > transactions.Count() {
-						end = transactions.Count()
-					}
+					end := blockEnd(transactions, start)
 					transactionsSlice.Ts = transactions.Ts[start:end]
 					nb = New(generator.validVDFValue, generator.number, generator.count, transactionsSlice)
 					blocks = append(blocks, nb)
@@ -75,51 +80,78 @@ func Generator(transactionsReceiver <-chan *Transactions, previousValue VDFValue
 	return out
 }
 
+// blockEnd returns end of the transactions which fit into a single block starting from start
+func blockEnd(transactions *Transactions, start int32) int32 {
+	size := 0
+	end := start
+	for end < transactions.Count() && end-start < maxTransactionsInBlock {
+		size += transactionSizePrefix + transactions.Ts[end].SerializedSize()
+		if size > maxBlockDataSize && end > start {
+			break
+		}
+		end++
+	}
+	return end
+}
+
+// BlockFit returns number of transactions from the beginning of the list which fit into a single block
+func BlockFit(transactions *Transactions) int {
+	return int(blockEnd(transactions, 0))
+}
+
 // GeneratorWithTick accept an input channel to read transactions and hash of previous
 // block. It creates an output channel and returns to the user. The transactions slices are sent
 // through the input channel and newly creates blocks are returned through the out channel.
 // GeneratorWithTick creates new blocks from transactions, also it creates timestamping
 // blocks at very 'tick'. The tick duration is defined by the user. The "tick block" is just the
 // next block in blockchain without transactions.
-func GeneratorWithTick(transactionsReceiver <-chan *Transactions, previousValue VDFValue, startNumber uint64, tickDuration time.Duration) <-chan Block {
+// VDF is iterated with the scheme of the chain between blocks and every block carries its proof.
+// Proofs are computed concurrently with iterations for the next block, blocks are sent in order.
+func GeneratorWithTick(transactionsReceiver <-chan *Transactions, scheme VDFScheme, previousValue VDFValue, startNumber uint64, tickDuration time.Duration) <-chan Block {
 	out := make(chan Block, cap(transactionsReceiver))
+	proven := make(chan provenBlock, cap(transactionsReceiver)+1)
 	generator := generatorHelper{validVDFValue: previousValue, count: 0, number: startNumber}
 	log.Debug("create block generator with tick goroutine")
+	go func() {
+		for p := range proven {
+			p.block.VDFProof = <-p.proof
+			out <- p.block
+		}
+		close(out)
+	}()
 	go func(transactionsReceiver <-chan *Transactions, generator generatorHelper, tickDuration time.Duration) {
 		ticker := time.NewTicker(tickDuration)
 		defer ticker.Stop()
 
+		evaluator := scheme.NewEvaluator(generator.validVDFValue)
 		var nb Block
 		for {
 			select {
 			case <-ticker.C:
-				nb = NewEmpty(generator.validVDFValue, generator.number, generator.count)
-				generator.count = 0
-				generator.validVDFValue = nb.Val
+				nb = NewEmpty(evaluator.Value(), generator.number, evaluator.Iterations())
+				proven <- prove(nb, evaluator)
+				evaluator = scheme.NewEvaluator(nb.Val)
 				generator.number = nb.Number
 				log.Debug("block generator with tick > tick received, create empty block")
-				out <- nb
 			case transactions, ok := <-transactionsReceiver:
 				if !ok {
 					log.Error("block generator's receiver failed, closing channel")
-					close(out)
+					close(proven)
 					return
 				}
 
 				if transactions.Count() == 0 {
-					nb = NewEmpty(generator.validVDFValue, generator.number, generator.count)
+					nb = NewEmpty(evaluator.Value(), generator.number, evaluator.Iterations())
 					log.Debug("block generator with tick > received zero transactions, create empty block")
 				} else {
-					nb = New(generator.validVDFValue, generator.number, generator.count, transactions)
+					nb = New(evaluator.Value(), generator.number, evaluator.Iterations(), transactions)
 					log.Info("block generator with tick > create new block")
 				}
-				generator.count = 0
-				generator.validVDFValue = nb.Val
+				proven <- prove(nb, evaluator)
+				evaluator = scheme.NewEvaluator(nb.Val)
 				generator.number = nb.Number
-				out <- nb
 			default:
-				generator.validVDFValue = VDF(generator.validVDFValue)
-				generator.count++
+				evaluator.Step()
 				log.Debug("block generator with tick > update VDF value")
 			}
 		}
@@ -127,6 +159,21 @@ func GeneratorWithTick(transactionsReceiver <-chan *Transactions, previousValue
 	return out
 }
 
+// provenBlock is a block waiting for the proof of its VDF iterations
+type provenBlock struct {
+	block Block
+	proof <-chan []byte
+}
+
+// prove computes proof of the evaluator in a goroutine, the evaluator must not be stepped any more
+func prove(b Block, evaluator VDFEvaluator) provenBlock {
+	proof := make(chan []byte, 1)
+	go func() {
+		proof <- evaluator.Proof()
+	}()
+	return provenBlock{block: b, proof: proof}
+}
+
 type BlockSaver interface {
 	SaveBlock(blk Block) error
 }
@@ -210,6 +257,7 @@ func Saver(blocks <-chan Block, db BlockSaver) <-chan []Block {
 
 // Batcher thread converts blocks to batches
 // to convert to blobs and send to the socket
+// output is closed after the blocks channel is closed
 func Batcher(blocks <-chan Block) <-chan []Block {
 	out := make(chan []Block, 10)
 	go func() {
@@ -218,9 +266,15 @@ func Batcher(blocks <-chan Block) <-chan []Block {
 			timeout := time.After(1 * time.Second)
 		LoopForBlocks:
 			for {
-				var block Block
 				select {
-				case block = <-blocks:
+				case block, ok := <-blocks:
+					if !ok {
+						if len(bls) != 0 {
+							out <- bls
+						}
+						close(out)
+						return
+					}
 					bls = append(bls, block)
 
 				case <-timeout:
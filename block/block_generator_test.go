@@ -14,7 +14,7 @@ func TestBlockGeneratorCloseChannel(t *testing.T) {
 	var transactions Transactions
 	transactionsReceiver <- &transactions
 	block := <-out
-	if !block.Verify(previousValue) || block.Number != 1 {
+	if !block.Verify(SHA256VDF{}, previousValue) || block.Number != 1 {
 		t.Errorf("can't verify block %v", block)
 	}
 
@@ -32,7 +32,7 @@ func TestBlockGeneratorWithoutTransactions(t *testing.T) {
 	var transactions Transactions
 	transactionsReceiver <- &transactions
 	block := <-out
-	if !block.Verify(previousValue) || block.Number != 11 {
+	if !block.Verify(SHA256VDF{}, previousValue) || block.Number != 11 {
 		t.Fatalf("can't verify block %v", block)
 	}
 }
@@ -44,7 +44,7 @@ func TestBlockGeneratorWithTransactions(t *testing.T) {
 	trans := CreateDummyTransactions(10)
 	transactionsReceiver <- &trans
 	block := <-out
-	if !block.Verify(previousValue) || block.Number != 1 {
+	if !block.Verify(SHA256VDF{}, previousValue) || block.Number != 1 {
 		t.Fatalf("can't verify block %v", block)
 	}
 }
@@ -63,7 +63,7 @@ func TestBlockGeneratorWithManyBlocks(t *testing.T) {
 		trans := CreateDummyTransactions(int64(n))
 		transactionsReceiver <- &trans
 		block := <-out
-		if !block.Verify(previousValue) || block.Number != uint64(i)+1 {
+		if !block.Verify(SHA256VDF{}, previousValue) || block.Number != uint64(i)+1 {
 			t.Fatalf("can't verify block %v", block)
 		}
 		previousValue = block.Val
@@ -79,13 +79,13 @@ func TestBlockGeneratorWithLargeNumberOfTransactions(t *testing.T) {
 	transactionsReceiver <- &trans
 	for i := 0; i < 2; i++ {
 		block := <-out
-		if !block.Verify(previousValue) || block.Number != uint64(i)+1 || block.Transactions.Count() != maxTransactionsInBlock || block.Size() > 65536 {
+		if !block.Verify(SHA256VDF{}, previousValue) || block.Number != uint64(i)+1 || block.Transactions.Count() != maxTransactionsInBlock || block.Size() > 65536 {
 			t.Fatalf("can't verify block %v", block)
 		}
 		previousValue = block.Val
 	}
 	block := <-out
-	if !block.Verify(previousValue) || block.Number != 3 || block.Transactions.Count() != 1 {
+	if !block.Verify(SHA256VDF{}, previousValue) || block.Number != 3 || block.Transactions.Count() != 1 {
 		t.Fatalf("can't verify block %v", block)
 	}
 }
@@ -93,12 +93,12 @@ func TestBlockGeneratorWithLargeNumberOfTransactions(t *testing.T) {
 func TestBlockGeneratorWithTickCloseChannel(t *testing.T) {
 	transactionsReceiver := make(chan *Transactions)
 	previousValue := VDF([]byte("hello"))
-	out := GeneratorWithTick(transactionsReceiver, previousValue, 0, time.Second)
+	out := GeneratorWithTick(transactionsReceiver, SHA256VDF{}, previousValue, 0, time.Second)
 	var transactions Transactions
 	transactionsReceiver <- &transactions
 
 	block := <-out
-	if !block.Verify(previousValue) || block.Number != 1 {
+	if !block.Verify(SHA256VDF{}, previousValue) || block.Number != 1 {
 		t.Errorf("can't verify block %v", block)
 	}
 
@@ -112,11 +112,11 @@ func TestBlockGeneratorWithTickCloseChannel(t *testing.T) {
 func TestBlockGeneratorWithTickWithoutTransactions(t *testing.T) {
 	transactionsReceiver := make(chan *Transactions)
 	previousValue := VDF([]byte("hello"))
-	out := GeneratorWithTick(transactionsReceiver, previousValue, 10, time.Microsecond)
+	out := GeneratorWithTick(transactionsReceiver, SHA256VDF{}, previousValue, 10, time.Microsecond)
 	var transactions Transactions
 	transactionsReceiver <- &transactions
 	block := <-out
-	if !block.Verify(previousValue) || block.Number != 11 {
+	if !block.Verify(SHA256VDF{}, previousValue) || block.Number != 11 {
 		t.Fatalf("can't verify block %v", block)
 	}
 }
@@ -124,11 +124,11 @@ func TestBlockGeneratorWithTickWithoutTransactions(t *testing.T) {
 func TestBlockGeneratorWithTickWithTransactions(t *testing.T) {
 	transactionsReceiver := make(chan *Transactions)
 	previousValue := VDF([]byte("hello"))
-	out := GeneratorWithTick(transactionsReceiver, previousValue, 0, 1*time.Second)
+	out := GeneratorWithTick(transactionsReceiver, SHA256VDF{}, previousValue, 0, 1*time.Second)
 	trans := CreateDummyTransactions(10)
 	transactionsReceiver <- &trans
 	block := <-out
-	if !block.Verify(previousValue) || block.Number != 1 {
+	if !block.Verify(SHA256VDF{}, previousValue) || block.Number != 1 {
 		t.Fatalf("can't verify block %v", block)
 	}
 }
@@ -136,13 +136,13 @@ func TestBlockGeneratorWithTickWithTransactions(t *testing.T) {
 func TestBlockGeneratorWithTickWithDelay(t *testing.T) {
 	transactionsReceiver := make(chan *Transactions)
 	previousValue := VDF([]byte("hello"))
-	out := GeneratorWithTick(transactionsReceiver, previousValue, 0, 1*time.Second)
+	out := GeneratorWithTick(transactionsReceiver, SHA256VDF{}, previousValue, 0, 1*time.Second)
 	// wait for a while to increase VDFValue
 	time.Sleep(time.Microsecond)
 	trans := CreateDummyTransactions(10)
 	transactionsReceiver <- &trans
 	block := <-out
-	if !block.Verify(previousValue) || block.Number != 1 {
+	if !block.Verify(SHA256VDF{}, previousValue) || block.Number != 1 {
 		t.Fatalf("can't verify block %v", block)
 	}
 
@@ -151,7 +151,7 @@ func TestBlockGeneratorWithTickWithDelay(t *testing.T) {
 func TestBlockGeneratorWithTickWithManyBlocks(t *testing.T) {
 	transactionsReceiver := make(chan *Transactions, 1)
 	previousValue := VDF([]byte("hello"))
-	out := GeneratorWithTick(transactionsReceiver, previousValue, 0, time.Microsecond)
+	out := GeneratorWithTick(transactionsReceiver, SHA256VDF{}, previousValue, 0, time.Microsecond)
 
 	go func(transactionsReceiver chan *Transactions) {
 		for i := 0; i < 100; i++ {
@@ -163,7 +163,7 @@ func TestBlockGeneratorWithTickWithManyBlocks(t *testing.T) {
 
 	for i := 0; i < 200; i++ {
 		block := <-out
-		if !block.Verify(previousValue) || block.Number != uint64(i)+1 {
+		if !block.Verify(SHA256VDF{}, previousValue) || block.Number != uint64(i)+1 {
 			t.Errorf("can't verify block %d\n %v", i, block)
 		}
 		previousValue = block.Val
@@ -173,16 +173,16 @@ func TestBlockGeneratorWithTickWithManyBlocks(t *testing.T) {
 func TestBlockGeneratorWithTickBlock(t *testing.T) {
 	transactionsReceiver := make(chan *Transactions)
 	previousValue := VDF([]byte("hello"))
-	out := GeneratorWithTick(transactionsReceiver, previousValue, 0, time.Microsecond)
+	out := GeneratorWithTick(transactionsReceiver, SHA256VDF{}, previousValue, 0, time.Microsecond)
 	// without transactions there should eventually happen tick block
 	block := <-out
-	if !block.Verify(previousValue) || block.Number != 1 {
+	if !block.Verify(SHA256VDF{}, previousValue) || block.Number != 1 {
 		t.Fatalf("can't verify block %v", block)
 	}
 
 	previousValue = block.Val
 	block = <-out
-	if !block.Verify(previousValue) {
+	if !block.Verify(SHA256VDF{}, previousValue) {
 		t.Fatalf("can't verify block %v", block)
 	}
 }
@@ -225,3 +225,21 @@ func TestBatcher(t *testing.T) {
 		t.Errorf("Batcher error: %v!=%v\n", len(res), len(blocks))
 	}
 }
+
+func TestBlockGeneratorEmptyBlockNumbers(t *testing.T) {
+	transactionsReceiver := make(chan *Transactions)
+	previousValue := VDF([]byte("hello"))
+	out := Generator(transactionsReceiver, previousValue, 5)
+	for i := uint64(6); i < 9; i++ {
+		var transactions Transactions
+		if i == 7 {
+			transactions = CreateDummyTransactions(3)
+		}
+		transactionsReceiver <- &transactions
+		block := <-out
+		if !block.Verify(SHA256VDF{}, previousValue) || block.Number != i {
+			t.Fatalf("block %v should follow previous block, got %v", i, block.Number)
+		}
+		previousValue = block.Val
+	}
+}
@@ -38,7 +38,7 @@ func TestVerify(t *testing.T) {
 	val0 := VDF([]byte("hello"))
 	transactions := new(Transactions)
 	block := New(VDF(val0), 0, 1, transactions)
-	if !block.Verify(val0) {
+	if !block.Verify(SHA256VDF{}, val0) {
 		t.Fatalf("Verify Function: can't Verify(%v, %v)", block, val0)
 	}
 }
@@ -50,7 +50,7 @@ func TestVerifyFalse(t *testing.T) {
 	tran1 := NewTransaction(&keypair1, keypair2.Public, 1, 5, []byte{6, 7, 8})
 	transactions := Transactions{Ts: []Transaction{tran1}}
 	block := New(VDF(val0), 0, 1, &transactions)
-	if block.Verify(val0) {
+	if block.Verify(SHA256VDF{}, val0) {
 		t.Fatalf("Verify Function: can't Verify(%v, %v)", block, val0)
 	}
 }
@@ -70,7 +70,7 @@ func TestNextBlock(t *testing.T) {
 	val0 := VDF([]byte("hello"))
 	val1 := VDF(val0)
 	transactions := new(Transactions)
-	bl := NextBlock(val0, 0, 1, transactions)
+	bl := NextBlock(SHA256VDF{}, val0, 0, 1, transactions)
 	if bl.Count != 1 || !bytes.Equal(bl.Val, val1) || bl.Number != 1 {
 		t.Errorf("NextBlock Error")
 	}
@@ -99,7 +99,7 @@ func TestSize(t *testing.T) {
 	val := VDF([]byte("hello"))
 	transactions := CreateDummyTransactions(100)
 	block := New(val, 0, 1000, &transactions)
-	size := 52 + transactions.Size()
+	size := 52 + vdfProofSizePrefix + HeaderSize + transactions.Size()
 	if size != block.Size() {
 		t.Errorf("Size: wring size")
 	}
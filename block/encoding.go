@@ -0,0 +1,375 @@
+package block
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// TransactionType defines kind of the transaction and layout of its body
+type TransactionType = byte
+
+const (
+	// TransactionV0 is the legacy fixed layout:
+	// signature, from, to, token, fee, vdf value and optional nonce.
+	// It is recognized by its size and supports only TypeTransfer.
+	TransactionV0 byte = 0
+	// TransactionV1 layout is: signature, from, version, type, fee, vdf value,
+	// nonce followed by the body of the transaction type and optional memo
+	// (memo size byte and the memo). Encodings of TransactionV0 sizes are padded
+	// with zero byte before the memo, see padded.
+	TransactionV1 byte = 1
+	// TransactionV2 is TransactionV1 signed by several signers, see multisig.go.
+	// Its layout is TransactionV1 followed by cosignatures footer.
+	TransactionV2 byte = 2
+)
+
+// MaxMemoSize is the maximal size of transaction memo
+const MaxMemoSize = 64
+
+const (
+	// TypeTransfer transfers Token from the sender to To, its body is: to, token
+	TypeTransfer TransactionType = iota
+	// TypeBatchTransfer transfers tokens from the sender to every output,
+	// its body is: number of outputs followed by outputs, each one is: to, token
+	TypeBatchTransfer
+	// TypeRegisterMultisig makes the sender a multisig account, its body is:
+	// threshold, number of signers followed by signer public keys
+	TypeRegisterMultisig
+	// TypeEscrow moves Token from the sender to escrow, see escrow.go. Its body
+	// is: to, token, release height, release count, cancel height, witness
+	TypeEscrow
+	// TypeEscrowRelease pays escrow to its recipient, its body is the escrow transaction signature
+	TypeEscrowRelease
+	// TypeEscrowCancel returns escrow to its sender, its body is the escrow transaction signature
+	TypeEscrowCancel
+	// TypeCreateAsset issues user asset, see asset.go. Its body is: mint authority, supply
+	TypeCreateAsset
+	// TypeMintAsset mints more of the asset, its body is: asset, to, token
+	TypeMintAsset
+	// TypeAssetTransfer transfers tokens of the asset, its body is: asset, to, token
+	TypeAssetTransfer
+	// TypeStake delegates Token to the validator To, see stake.go. Its body is: validator, token
+	TypeStake
+	// TypeUnstake starts unbonding of Token delegated to the validator To, its body is: validator, token
+	TypeUnstake
+	// TypeSlash reports equivocation of a signer, see evidence.go. Its body is the evidence
+	TypeSlash
+	// TypeBindNode binds the node To to the sender validator, see stake.go. Its body is: node
+	TypeBindNode
+)
+
+const (
+	// outputSize is the size of serialized batch transfer output
+	outputSize = ed25519.PublicKeySize + 8
+	// MaxOutputs is the maximal number of batch transfer outputs, number of
+	// outputs is serialized as a single byte. Transaction with MaxOutputs
+	// outputs and memo of MaxMemoSize fits into a single packet, see TestSerializeBatch.
+	MaxOutputs = 255
+)
+
+const (
+	// legacyTransactionSize is the size of TransactionV0 without nonce
+	legacyTransactionSize = ed25519.SignatureSize + ed25519.PublicKeySize*2 + 16 + sha256.Size
+	// versionOffset is the position of the version byte in TransactionV1
+	versionOffset = ed25519.SignatureSize + ed25519.PublicKeySize
+	// headerSize is the size of TransactionV1 common fields, before the body
+	headerSize = versionOffset + 2 + 8 + sha256.Size + 8
+)
+
+var (
+	errShortTransaction       = errors.New("transaction data is too short")
+	errLongTransaction        = errors.New("transaction data is too long")
+	errUnknownVersion         = errors.New("unknown transaction version")
+	errUnknownTransactionType = errors.New("unknown transaction type")
+	errInvalidMemo            = errors.New("invalid transaction memo")
+	errInvalidOutputs         = errors.New("invalid number of batch transfer outputs")
+)
+
+// fixed returns b padded or truncated to the size
+func fixed(b []byte, size int) []byte {
+	res := make([]byte, size)
+	copy(res, b)
+	return res
+}
+
+func uint64ToBytes(v uint64) []byte {
+	return []byte{byte(v >> 56), byte(v >> 48), byte(v >> 40), byte(v >> 32),
+		byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+// Serialize is responsible converting Transaction to byte slice
+func (t *Transaction) Serialize() []byte {
+	res := make([]byte, 0, headerSize+ed25519.PublicKeySize+8)
+	res = append(res, fixed(t.Signature, ed25519.SignatureSize)...)
+	res = append(res, t.payload()...)
+	if t.Version == TransactionV2 {
+		res = append(res, t.cosignaturesFooter()...)
+	}
+	return res
+}
+
+// SerializedSize returns size of the serialized transaction
+func (t *Transaction) SerializedSize() int {
+	size := ed25519.SignatureSize + len(t.payload())
+	if t.Version == TransactionV2 {
+		size += cosignaturesFooterSize(len(t.Cosignatures))
+	}
+	return size
+}
+
+// payload returns serialized transaction without signature
+func (t *Transaction) payload() []byte {
+	if t.Version == TransactionV0 {
+		return t.payloadV0()
+	}
+	res := make([]byte, 0, headerSize-ed25519.SignatureSize+ed25519.PublicKeySize+8)
+	res = append(res, fixed(t.From, ed25519.PublicKeySize)...)
+	res = append(res, t.Version, t.Type)
+	res = append(res, uint64ToBytes(uint64(t.Fee))...)
+	res = append(res, fixed(t.ValidVDFValue, sha256.Size)...)
+	res = append(res, uint64ToBytes(t.Nonce)...)
+	switch t.Type {
+	case TypeTransfer, TypeCreateAsset, TypeStake, TypeUnstake:
+		res = append(res, fixed(t.To, ed25519.PublicKeySize)...)
+		res = append(res, uint64ToBytes(uint64(t.Token))...)
+	case TypeBatchTransfer:
+		res = append(res, byte(len(t.Outputs)))
+		res = append(res, SerializeOutputs(t.Outputs)...)
+	case TypeRegisterMultisig:
+		res = append(res, t.Threshold, byte(len(t.Signers)))
+		for _, signer := range t.Signers {
+			res = append(res, fixed(signer, ed25519.PublicKeySize)...)
+		}
+	case TypeEscrow:
+		res = append(res, t.serializeEscrow()...)
+	case TypeEscrowRelease, TypeEscrowCancel:
+		res = append(res, fixed(t.Escrow, escrowReferenceSize)...)
+	case TypeMintAsset, TypeAssetTransfer:
+		res = append(res, t.serializeAssetTransfer()...)
+	case TypeSlash:
+		res = append(res, t.serializeEvidence()...)
+	case TypeBindNode:
+		res = append(res, fixed(t.To, ed25519.PublicKeySize)...)
+	}
+	size := len(res)
+	if len(t.Memo) > 0 {
+		size += 1 + len(t.Memo)
+	}
+	if padded(ed25519.SignatureSize + size) {
+		res = append(res, 0)
+	}
+	if len(t.Memo) > 0 {
+		res = append(res, byte(len(t.Memo)))
+		res = append(res, t.Memo...)
+	}
+	return res
+}
+
+// padded returns true if transaction of the size is confused with TransactionV0,
+// such transaction is padded with zero byte which is not a valid memo size
+func padded(size int) bool {
+	return size == legacyTransactionSize || size == legacyTransactionSize+8
+}
+
+func (t *Transaction) payloadV0() []byte {
+	res := make([]byte, 0, legacyTransactionSize-ed25519.SignatureSize+8)
+	res = append(res, fixed(t.From, ed25519.PublicKeySize)...)
+	res = append(res, fixed(t.To, ed25519.PublicKeySize)...)
+	res = append(res, uint64ToBytes(uint64(t.Token))...)
+	res = append(res, uint64ToBytes(uint64(t.Fee))...)
+	res = append(res, fixed(t.ValidVDFValue, sha256.Size)...)
+	if t.Nonce != 0 {
+		res = append(res, uint64ToBytes(t.Nonce)...)
+	}
+	return res
+}
+
+// DeserializeFromSlice is responsible for creating Transaction object from byte slice.
+// b must contain exactly one serialized transaction, TransactionV0 is recognized by its size.
+func (t *Transaction) DeserializeFromSlice(b []byte) error {
+	if len(b) < legacyTransactionSize {
+		return errShortTransaction
+	}
+	if len(b) > MaxTransactionSize() {
+		return errLongTransaction
+	}
+	if len(b) == legacyTransactionSize || len(b) == legacyTransactionSize+8 {
+		t.deserializeV0(b)
+		return nil
+	}
+	if len(b) < headerSize {
+		return errShortTransaction
+	}
+	res := Transaction{Version: b[versionOffset], Type: b[versionOffset+1]}
+	switch res.Version {
+	case TransactionV1:
+	case TransactionV2:
+		var err error
+		if res.Cosignatures, b, err = deserializeCosignatures(b); err != nil {
+			return err
+		}
+	default:
+		return errUnknownVersion
+	}
+	if len(b) < headerSize {
+		return errShortTransaction
+	}
+	res.Signature = append([]byte(nil), b[:ed25519.SignatureSize]...)
+	start := ed25519.SignatureSize
+	res.From = append([]byte(nil), b[start:start+ed25519.PublicKeySize]...)
+	start += ed25519.PublicKeySize + 2
+	res.Fee = ByteToInt64(b, start)
+	start += 8
+	res.ValidVDFValue = append([]byte(nil), b[start:start+sha256.Size]...)
+	start += sha256.Size
+	res.Nonce = uint64(ByteToInt64(b, start))
+	start += 8
+	n, err := res.deserializeBody(b[start:])
+	if err != nil {
+		return err
+	}
+	memo := b[start+n:]
+	if len(memo) > 0 && memo[0] == 0 {
+		if !padded(len(b) - 1) {
+			return errInvalidMemo
+		}
+		memo = memo[1:]
+	}
+	if err = res.deserializeMemo(memo); err != nil {
+		return err
+	}
+	*t = res
+	return nil
+}
+
+// deserializeBody reads body of the transaction type and returns number of bytes read
+func (t *Transaction) deserializeBody(body []byte) (int, error) {
+	switch t.Type {
+	case TypeTransfer, TypeCreateAsset, TypeStake, TypeUnstake:
+		if len(body) < ed25519.PublicKeySize+8 {
+			return 0, errShortTransaction
+		}
+		t.To = append([]byte(nil), body[:ed25519.PublicKeySize]...)
+		t.Token = ByteToInt64(body, ed25519.PublicKeySize)
+		return ed25519.PublicKeySize + 8, nil
+	case TypeBatchTransfer:
+		if len(body) < 1 {
+			return 0, errShortTransaction
+		}
+		n := int(body[0])
+		if n == 0 || n > MaxOutputs {
+			return 0, errInvalidOutputs
+		}
+		if len(body) < 1+n*outputSize {
+			return 0, errShortTransaction
+		}
+		outputs, err := DeserializeOutputs(body[1 : 1+n*outputSize])
+		if err != nil {
+			return 0, err
+		}
+		t.Outputs = outputs
+		return 1 + n*outputSize, nil
+	case TypeRegisterMultisig:
+		if len(body) < 2 {
+			return 0, errShortTransaction
+		}
+		n := int(body[1])
+		if len(body) < 2+n*ed25519.PublicKeySize {
+			return 0, errShortTransaction
+		}
+		t.Threshold = body[0]
+		t.Signers = make([]ed25519.PublicKey, n)
+		for i := range t.Signers {
+			start := 2 + i*ed25519.PublicKeySize
+			t.Signers[i] = append([]byte(nil), body[start:start+ed25519.PublicKeySize]...)
+		}
+		return 2 + n*ed25519.PublicKeySize, nil
+	case TypeEscrow:
+		return t.deserializeEscrow(body)
+	case TypeEscrowRelease, TypeEscrowCancel:
+		if len(body) < escrowReferenceSize {
+			return 0, errShortTransaction
+		}
+		t.Escrow = append([]byte(nil), body[:escrowReferenceSize]...)
+		return escrowReferenceSize, nil
+	case TypeMintAsset, TypeAssetTransfer:
+		return t.deserializeAssetTransfer(body)
+	case TypeSlash:
+		return t.deserializeEvidence(body)
+	case TypeBindNode:
+		if len(body) < ed25519.PublicKeySize {
+			return 0, errShortTransaction
+		}
+		t.To = append([]byte(nil), body[:ed25519.PublicKeySize]...)
+		return ed25519.PublicKeySize, nil
+	}
+	return 0, errUnknownTransactionType
+}
+
+// SerializeOutputs converts batch transfer outputs to bytes
+func SerializeOutputs(outputs []Output) []byte {
+	res := make([]byte, 0, len(outputs)*outputSize)
+	for _, o := range outputs {
+		res = append(res, fixed(o.To, ed25519.PublicKeySize)...)
+		res = append(res, uint64ToBytes(uint64(o.Token))...)
+	}
+	return res
+}
+
+// DeserializeOutputs creates batch transfer outputs from bytes returned by SerializeOutputs
+func DeserializeOutputs(b []byte) ([]Output, error) {
+	if len(b)%outputSize != 0 {
+		return nil, errInvalidOutputs
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	outputs := make([]Output, len(b)/outputSize)
+	for i := range outputs {
+		start := i * outputSize
+		outputs[i].To = append([]byte(nil), b[start:start+ed25519.PublicKeySize]...)
+		outputs[i].Token = ByteToInt64(b, start+ed25519.PublicKeySize)
+	}
+	return outputs, nil
+}
+
+// deserializeMemo reads memo which follows the body, b must be empty when there is no memo
+func (t *Transaction) deserializeMemo(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	size := int(b[0])
+	if size == 0 || size > MaxMemoSize {
+		return errInvalidMemo
+	}
+	if len(b) < 1+size {
+		return errShortTransaction
+	}
+	if len(b) > 1+size {
+		return errLongTransaction
+	}
+	t.Memo = append([]byte(nil), b[1:]...)
+	return nil
+}
+
+func (t *Transaction) deserializeV0(b []byte) {
+	*t = Transaction{Version: TransactionV0, Type: TypeTransfer}
+	start := 0
+	t.Signature = append([]byte(nil), b[start:start+ed25519.SignatureSize]...)
+	start += ed25519.SignatureSize
+	t.From = append([]byte(nil), b[start:start+ed25519.PublicKeySize]...)
+	start += ed25519.PublicKeySize
+	t.To = append([]byte(nil), b[start:start+ed25519.PublicKeySize]...)
+	start += ed25519.PublicKeySize
+	t.Token = ByteToInt64(b, start)
+	start += 8
+	t.Fee = ByteToInt64(b, start)
+	start += 8
+	t.ValidVDFValue = append([]byte(nil), b[start:start+sha256.Size]...)
+	start += sha256.Size
+	if len(b) >= start+8 {
+		t.Nonce = uint64(ByteToInt64(b, start))
+	}
+}
@@ -0,0 +1,241 @@
+package block
+
+import (
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/network"
+	"golang.org/x/crypto/ed25519"
+)
+
+func newTransactionV1(token int64, nonce uint64) Transaction {
+	kp := NewKeyPair()
+	to := NewKeyPair()
+	tr := Transaction{Version: TransactionV1, Type: TypeTransfer, From: kp.Public, To: to.Public, Token: token, Fee: 1,
+		ValidVDFValue: VDF([]byte{1}), Nonce: nonce}
+	tr.Sign(&kp)
+	return tr
+}
+
+func TestSerializeV1(t *testing.T) {
+	tran := newTransactionV1(10, 0)
+	s := tran.Serialize()
+	if len(s) != tran.SerializedSize() || len(s) == MinTransactionSize() || len(s) == TransactionSize() {
+		t.Errorf("unexpected size of TransactionV1 %v", len(s))
+	}
+	if s[versionOffset] != TransactionV1 || s[versionOffset+1] != TypeTransfer {
+		t.Errorf("version and type should follow sender key")
+	}
+	if !ed25519.Verify(s[64:96], s[64:], s[:64]) {
+		t.Errorf("signature should cover whole TransactionV1 after the signature")
+	}
+	var tr Transaction
+	if err := tr.DeserializeFromSlice(s); err != nil || !tr.Equals(tran) || !tr.VerifySignature() {
+		t.Errorf("Serialize/deserialize of TransactionV1 problem: %v, %v != %v", err, tr, tran)
+	}
+}
+
+func TestDeserializeV0(t *testing.T) {
+	tran := CreateRealTransaction(5)
+	var tr Transaction
+	if err := tr.DeserializeFromSlice(tran.Serialize()); err != nil || tr.Version != TransactionV0 || !tr.Equals(tran) {
+		t.Errorf("legacy layout should be decoded as TransactionV0: %v", err)
+	}
+}
+
+func TestDeserializeErrors(t *testing.T) {
+	tran := newTransactionV1(10, 3)
+	s := tran.Serialize()
+	var tr Transaction
+	if err := tr.DeserializeFromSlice(s[:100]); err != errShortTransaction {
+		t.Errorf("expected errShortTransaction, got %v", err)
+	}
+	if err := tr.DeserializeFromSlice(s[:len(s)-1]); err != errShortTransaction {
+		t.Errorf("expected errShortTransaction for truncated body, got %v", err)
+	}
+	if err := tr.DeserializeFromSlice(append(s, 1, 'a', 'b')); err != errLongTransaction {
+		t.Errorf("expected errLongTransaction, got %v", err)
+	}
+	if err := tr.DeserializeFromSlice(make([]byte, MaxTransactionSize()+1)); err != errLongTransaction {
+		t.Errorf("expected errLongTransaction for huge data, got %v", err)
+	}
+	s[versionOffset] = 7
+	if err := tr.DeserializeFromSlice(s); err != errUnknownVersion {
+		t.Errorf("expected errUnknownVersion, got %v", err)
+	}
+	s[versionOffset] = TransactionV1
+	s[versionOffset+1] = 200
+	if err := tr.DeserializeFromSlice(s); err != errUnknownTransactionType {
+		t.Errorf("expected errUnknownTransactionType, got %v", err)
+	}
+}
+
+func TestBlocksToBlobsMixedVersions(t *testing.T) {
+	trs := CreateRealTransactions(10)
+	for i := 0; i < 10; i += 2 {
+		trs.Ts[i] = newTransactionV1(int64(i), uint64(i))
+	}
+	blocks := []Block{Block{Number: 1, Count: 1, Val: VDF([]byte{1}), Transactions: &trs}}
+	resBlocks := BlobsToBlocks(BlocksToBlobs(blocks))
+	if len(resBlocks) != 1 || !TransactionSetEqual(trs.Ts, resBlocks[0].Transactions.Ts) {
+		t.Errorf("blob should carry transactions of different versions")
+	}
+}
+
+func TestBlobsToBlocksTruncated(t *testing.T) {
+	trs := CreateRealTransactions(10)
+	blocks := []Block{Block{Number: 1, Count: 1, Val: VDF([]byte{1}), Transactions: &trs}}
+	blobs := BlocksToBlobs(blocks)
+	blobs.Bs[0].Size -= 100
+	if res := BlobsToBlocks(blobs); len(res) != 0 {
+		t.Errorf("truncated block should be dropped, got %v blocks", len(res))
+	}
+	blobs.Bs[0].Size = network.BlobDataSize + 1
+	BlobsToBlocks(blobs)
+}
+
+func TestSerializeMemo(t *testing.T) {
+	kp := NewKeyPair()
+	tran := NewTransactionWithMemo(&kp, kp.Public, 10, 1, VDF([]byte{1}), []byte("invoice-42"))
+	s := tran.Serialize()
+	var tr Transaction
+	if err := tr.DeserializeFromSlice(s); err != nil || !tr.Equals(tran) || !tr.VerifySignature() {
+		t.Errorf("Serialize/deserialize of transaction with memo problem: %v, %v != %v", err, tr, tran)
+	}
+	tr.Memo = []byte("invoice-43")
+	if tr.VerifySignature() {
+		t.Errorf("memo should be covered by the signature")
+	}
+	if !tran.Verify() {
+		t.Errorf("transaction with memo should be valid")
+	}
+	tr.Memo = make([]byte, MaxMemoSize+1)
+	if tr.Verify() {
+		t.Errorf("too long memo should be invalid")
+	}
+	tr.Memo = []byte("memo")
+	tr.Version = TransactionV0
+	if tr.Verify() {
+		t.Errorf("memo requires TransactionV1")
+	}
+
+	s[len(s)-len(tran.Memo)-1] = MaxMemoSize + 1
+	if err := tr.DeserializeFromSlice(s); err != errInvalidMemo {
+		t.Errorf("expected errInvalidMemo, got %v", err)
+	}
+	s[len(s)-len(tran.Memo)-1] = byte(len(tran.Memo) + 1)
+	if err := tr.DeserializeFromSlice(s); err != errShortTransaction {
+		t.Errorf("expected errShortTransaction, got %v", err)
+	}
+}
+
+func TestSerializePadded(t *testing.T) {
+	kp := NewKeyPair()
+	signer := NewKeyPair()
+	multisig := Transaction{Version: TransactionV1, Type: TypeRegisterMultisig, From: kp.Public, Threshold: 1,
+		Signers: []ed25519.PublicKey{signer.Public}, Fee: 1, ValidVDFValue: VDF([]byte{1}), Memo: []byte("abc")}
+	multisig.Sign(&kp)
+	bind := NewBindNode(&kp, signer.Public, 1, VDF([]byte{1}))
+	bind.Memo = []byte("node1")
+	bind.Sign(&kp)
+	for _, tran := range []Transaction{multisig, bind} {
+		s := tran.Serialize()
+		if len(s) != TransactionSize()+1 || len(s) != tran.SerializedSize() {
+			t.Errorf("transaction of TransactionV0 size should be padded, got %v bytes", len(s))
+		}
+		var tr Transaction
+		if err := tr.DeserializeFromSlice(s); err != nil || tr.Version != TransactionV1 || !tr.Equals(tran) || !tr.Verify() {
+			t.Errorf("padded transaction should be decoded as TransactionV1: %v, %v != %v", err, tr, tran)
+		}
+		if !VerifySignatures(s) || IsMultisigned(s) {
+			t.Errorf("padding should be covered by the signature")
+		}
+		if VerifySignatures(append(s[:len(s)-len(tran.Memo)-2:len(s)-len(tran.Memo)-2], s[len(s)-len(tran.Memo)-1:]...)) {
+			t.Errorf("transaction without padding should not be signed by the sender")
+		}
+	}
+
+	tran := NewTransactionWithMemo(&kp, kp.Public, 10, 1, VDF([]byte{1}), []byte("invoice-42"))
+	s := tran.Serialize()
+	s = append(s[:len(s)-len(tran.Memo)-1], append([]byte{0}, s[len(s)-len(tran.Memo)-1:]...)...)
+	var tr Transaction
+	if err := tr.DeserializeFromSlice(s); err != errInvalidMemo {
+		t.Errorf("padding of transaction which needs none should be invalid, got %v", err)
+	}
+}
+
+func newOutputs(n int) []Output {
+	outputs := make([]Output, n)
+	for i := range outputs {
+		kp := NewKeyPair()
+		outputs[i] = Output{To: kp.Public, Token: int64(i + 1)}
+	}
+	return outputs
+}
+
+func TestSerializeBatch(t *testing.T) {
+	kp := NewKeyPair()
+	tran := NewBatchTransaction(&kp, newOutputs(MaxOutputs), 2, VDF([]byte{1}))
+	tran.Memo = make([]byte, MaxMemoSize)
+	tran.Sign(&kp)
+	s := tran.Serialize()
+	if len(s) > MaxTransactionSize() {
+		t.Errorf("batch with maximal number of outputs does not fit into a packet: %v", len(s))
+	}
+	var tr Transaction
+	if err := tr.DeserializeFromSlice(s); err != nil || !tr.Equals(tran) || !tr.VerifySignature() {
+		t.Errorf("Serialize/deserialize of batch transfer problem: %v", err)
+	}
+	if !tran.Verify() || tran.Amount() != int64(MaxOutputs*(MaxOutputs+1)/2+2) {
+		t.Errorf("batch transfer should be valid with amount %v", tran.Amount())
+	}
+	tr.Outputs[3].Token = 100
+	if tr.VerifySignature() {
+		t.Errorf("outputs should be covered by the signature")
+	}
+	tr.Outputs[3].Token = -1
+	if tr.Verify() {
+		t.Errorf("batch transfer with negative output should be invalid")
+	}
+
+	s[headerSize] = 0
+	if err := tr.DeserializeFromSlice(s); err != errInvalidOutputs {
+		t.Errorf("expected errInvalidOutputs, got %v", err)
+	}
+	s[headerSize] = MaxOutputs
+	if err := tr.DeserializeFromSlice(s[:len(s)-outputSize]); err != errShortTransaction {
+		t.Errorf("expected errShortTransaction, got %v", err)
+	}
+}
+
+func TestBlocksToBlobsBatch(t *testing.T) {
+	trs := CreateRealTransactions(10)
+	kp := NewKeyPair()
+	trs.Ts[3] = NewBatchTransaction(&kp, newOutputs(7), 0, VDF([]byte{1}))
+	blocks := []Block{Block{Number: 1, Count: 1, Val: VDF([]byte{1}), Transactions: &trs}}
+	resBlocks := BlobsToBlocks(BlocksToBlobs(blocks))
+	if len(resBlocks) != 1 || !TransactionSetEqual(trs.Ts, resBlocks[0].Transactions.Ts) {
+		t.Errorf("blob should carry batch transfers")
+	}
+}
+
+func TestBlobsToBlocksEmptyBlocks(t *testing.T) {
+	trs := CreateRealTransactions(3)
+	first := New(VDF([]byte{1}), 1, 0, &trs)
+	blocks := []Block{first, NewEmpty(first.Val, 2, 0), NewEmpty(first.Val, 3, 0)}
+	for i := 1; i < len(blocks); i++ {
+		blocks[i].Transactions = new(Transactions)
+	}
+	resBlocks := BlobsToBlocks(BlocksToBlobs(blocks))
+	if len(resBlocks) != 3 || resBlocks[1].Number != 3 || resBlocks[2].Number != 4 || resBlocks[2].Transactions.Count() != 0 {
+		t.Errorf("empty blocks with the same vdf value should be kept separately, got %v blocks", len(resBlocks))
+	}
+}
+
+func TestBlobsToBlocksZeroPadding(t *testing.T) {
+	trs := CreateRealTransactions(3)
+	blobs := BlocksToBlobs([]Block{New(VDF([]byte{1}), 1, 0, &trs)})
+	blobs.Bs[0].Size += 500
+	if res := BlobsToBlocks(blobs); len(res) != 1 || res[0].Number != 2 {
+		t.Errorf("zero padding of recovered blob should not be read as a block, got %v blocks", len(res))
+	}
+}
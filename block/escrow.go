@@ -0,0 +1,124 @@
+package block
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	// escrowSize is the size of TypeEscrow body: to, token, release height,
+	// release count, cancel height and witness
+	escrowSize = ed25519.PublicKeySize + 8 + 8 + 8 + 8 + ed25519.PublicKeySize
+	// escrowReferenceSize is the size of TypeEscrowRelease and TypeEscrowCancel
+	// body, the signature of the escrow transaction
+	escrowReferenceSize = ed25519.SignatureSize
+)
+
+var errInvalidEscrow = errors.New("invalid escrow condition")
+
+// EscrowCondition defines when escrowed tokens can be moved. Tokens are released
+// to the recipient once any of release conditions holds: block height reaches
+// ReleaseHeight, total VDF count reaches ReleaseCount or Witness signs the release.
+// Zero values disable corresponding conditions. The sender can cancel the escrow
+// and get tokens back once block height reaches CancelHeight.
+type EscrowCondition struct {
+	ReleaseHeight uint64
+	ReleaseCount  uint64
+	Witness       ed25519.PublicKey
+	CancelHeight  uint64
+}
+
+// NewEscrow will create new TransactionV1 object which moves token from the sender
+// to escrow. Recipient gets token minus fee once the condition holds.
+func NewEscrow(from *KeyPair, to ed25519.PublicKey, token int64, fee int64, condition EscrowCondition, validVDFValue VDFValue) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeEscrow, From: from.Public, To: to, Token: token, Fee: fee,
+		Condition: condition, ValidVDFValue: validVDFValue}
+	tr.Sign(from)
+	return tr
+}
+
+// NewEscrowRelease will create new TransactionV1 object which releases escrow
+// created by transaction with the escrow signature. It is sent by the witness
+// or, once release height or count is reached, by anyone.
+func NewEscrowRelease(from *KeyPair, escrow []byte, fee int64, validVDFValue VDFValue) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeEscrowRelease, From: from.Public, Escrow: escrow, Fee: fee,
+		ValidVDFValue: validVDFValue}
+	tr.Sign(from)
+	return tr
+}
+
+// NewEscrowCancel will create new TransactionV1 object which returns escrowed
+// tokens to the sender of the escrow
+func NewEscrowCancel(from *KeyPair, escrow []byte, fee int64, validVDFValue VDFValue) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeEscrowCancel, From: from.Public, Escrow: escrow, Fee: fee,
+		ValidVDFValue: validVDFValue}
+	tr.Sign(from)
+	return tr
+}
+
+// Releasable returns true if release condition holds at the given block height and total VDF count
+func (c *EscrowCondition) Releasable(height uint64, count uint64) bool {
+	return (c.ReleaseHeight > 0 && height >= c.ReleaseHeight) || (c.ReleaseCount > 0 && count >= c.ReleaseCount)
+}
+
+// Cancelable returns true if the sender can cancel escrow at the given block height
+func (c *EscrowCondition) Cancelable(height uint64) bool {
+	return c.CancelHeight > 0 && height >= c.CancelHeight
+}
+
+// valid returns true if escrow can be released by some condition
+func (c *EscrowCondition) valid() bool {
+	if len(c.Witness) != 0 && len(c.Witness) != ed25519.PublicKeySize {
+		return false
+	}
+	return c.ReleaseHeight > 0 || c.ReleaseCount > 0 || len(c.Witness) != 0
+}
+
+// serializeEscrow returns TypeEscrow body
+func (t *Transaction) serializeEscrow() []byte {
+	res := make([]byte, 0, escrowSize)
+	res = append(res, fixed(t.To, ed25519.PublicKeySize)...)
+	res = append(res, uint64ToBytes(uint64(t.Token))...)
+	res = append(res, uint64ToBytes(t.Condition.ReleaseHeight)...)
+	res = append(res, uint64ToBytes(t.Condition.ReleaseCount)...)
+	res = append(res, uint64ToBytes(t.Condition.CancelHeight)...)
+	return append(res, fixed(t.Condition.Witness, ed25519.PublicKeySize)...)
+}
+
+// deserializeEscrow reads TypeEscrow body, zero witness means there is no witness
+func (t *Transaction) deserializeEscrow(body []byte) (int, error) {
+	if len(body) < escrowSize {
+		return 0, errShortTransaction
+	}
+	start := 0
+	t.To = append([]byte(nil), body[:ed25519.PublicKeySize]...)
+	start += ed25519.PublicKeySize
+	t.Token = ByteToInt64(body, start)
+	start += 8
+	t.Condition.ReleaseHeight = uint64(ByteToInt64(body, start))
+	start += 8
+	t.Condition.ReleaseCount = uint64(ByteToInt64(body, start))
+	start += 8
+	t.Condition.CancelHeight = uint64(ByteToInt64(body, start))
+	start += 8
+	witness := body[start : start+ed25519.PublicKeySize]
+	for _, b := range witness {
+		if b != 0 {
+			t.Condition.Witness = append([]byte(nil), witness...)
+			break
+		}
+	}
+	return escrowSize, nil
+}
+
+// verifyEscrow verifies fields of escrow transaction types
+func (t *Transaction) verifyEscrow() bool {
+	switch t.Type {
+	case TypeEscrow:
+		return t.Condition.valid() && 0 <= t.Fee && t.Fee <= t.Token
+	case TypeEscrowRelease, TypeEscrowCancel:
+		return len(t.Escrow) == escrowReferenceSize && t.Fee >= 0
+	}
+	return false
+}
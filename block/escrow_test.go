@@ -0,0 +1,52 @@
+package block
+
+import "testing"
+
+func TestSerializeEscrow(t *testing.T) {
+	kp := NewKeyPair()
+	to := NewKeyPair()
+	witness := NewKeyPair()
+	conditions := []EscrowCondition{
+		EscrowCondition{ReleaseHeight: 10, CancelHeight: 20},
+		EscrowCondition{ReleaseCount: 1000},
+		EscrowCondition{Witness: witness.Public, CancelHeight: 5},
+	}
+	for _, c := range conditions {
+		tran := NewEscrow(&kp, to.Public, 10, 1, c, VDF([]byte{1}))
+		if !tran.Verify() || tran.Amount() != 10 {
+			t.Errorf("escrow %v should be valid", c)
+		}
+		var tr Transaction
+		if err := tr.DeserializeFromSlice(tran.Serialize()); err != nil || !tr.Equals(tran) || !tr.VerifySignature() {
+			t.Errorf("Serialize/deserialize of escrow problem: %v, %v != %v", err, tr, tran)
+		}
+	}
+	tran := NewEscrow(&kp, to.Public, 10, 1, EscrowCondition{CancelHeight: 5}, VDF([]byte{1}))
+	if tran.Verify() {
+		t.Errorf("escrow without release condition should be invalid")
+	}
+	s := tran.Serialize()
+	var tr Transaction
+	if err := tr.DeserializeFromSlice(s[:len(s)-1]); err != errShortTransaction {
+		t.Errorf("expected errShortTransaction, got %v", err)
+	}
+}
+
+func TestSerializeEscrowRelease(t *testing.T) {
+	kp := NewKeyPair()
+	escrow := NewEscrow(&kp, kp.Public, 10, 1, EscrowCondition{ReleaseHeight: 1, CancelHeight: 2}, VDF([]byte{1}))
+	for _, tran := range []Transaction{NewEscrowRelease(&kp, escrow.Signature, 1, VDF([]byte{1})),
+		NewEscrowCancel(&kp, escrow.Signature, 0, VDF([]byte{1}))} {
+		var tr Transaction
+		if err := tr.DeserializeFromSlice(tran.Serialize()); err != nil || !tr.Equals(tran) || !tr.VerifySignature() {
+			t.Errorf("Serialize/deserialize of escrow reference problem: %v", err)
+		}
+		if !tran.Verify() || tran.Amount() != tran.Fee {
+			t.Errorf("escrow reference should be valid and cost only the fee")
+		}
+		tran.Escrow = tran.Escrow[:10]
+		if tran.Verify() {
+			t.Errorf("escrow reference should be a transaction signature")
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package block
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// EvidenceSize is the size of serialized evidence: two conflicting votes
+const EvidenceSize = 2 * VoteSize
+
+var errInvalidEvidence = errors.New("invalid evidence data")
+
+// Evidence proves that a signer equivocated: it signed two different block
+// hashes for the same block number. Header of every block carries the
+// producer's vote, so two different blocks with the same number are attributable.
+type Evidence struct {
+	First  Vote
+	Second Vote
+}
+
+// NewEvidence creates evidence from two conflicting votes. Votes are ordered
+// by block hash, so that the same offence always gives the same evidence.
+func NewEvidence(first Vote, second Vote) Evidence {
+	if bytes.Compare(first.Val, second.Val) > 0 {
+		first, second = second, first
+	}
+	return Evidence{First: first, Second: second}
+}
+
+// NewSlash will create new TransactionV1 object which reports the evidence.
+// Reporter pays fee, which may be zero.
+func NewSlash(from *KeyPair, evidence Evidence, fee int64, validVDFValue VDFValue) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeSlash, From: from.Public, Evidence: &evidence, Fee: fee,
+		ValidVDFValue: validVDFValue}
+	tr.Sign(from)
+	return tr
+}
+
+// Verify checks that both votes are valid, are signed by the same voter for
+// the same block number and differ in block hash
+func (e *Evidence) Verify() bool {
+	return e.First.Verify() && e.Second.Verify() && bytes.Equal(e.First.Voter, e.Second.Voter) &&
+		e.First.Number == e.Second.Number && !bytes.Equal(e.First.Val, e.Second.Val)
+}
+
+// Offender returns key of the equivocating signer
+func (e *Evidence) Offender() ed25519.PublicKey {
+	return e.First.Voter
+}
+
+// ID identifies the offence: offender followed by block number. Evidence of
+// the same offence with other votes has the same ID.
+func (e *Evidence) ID() []byte {
+	id := make([]byte, 0, ed25519.PublicKeySize+8)
+	id = append(id, e.First.Voter...)
+	return append(id, uint64ToBytes(e.First.Number)...)
+}
+
+// Serialize converts evidence to bytes
+func (e *Evidence) Serialize() []byte {
+	data := make([]byte, 0, EvidenceSize)
+	data = append(data, e.First.Serialize()...)
+	return append(data, e.Second.Serialize()...)
+}
+
+// DeserializeEvidence converts bytes to evidence
+func DeserializeEvidence(data []byte) (Evidence, error) {
+	if len(data) < EvidenceSize {
+		return Evidence{}, errInvalidEvidence
+	}
+	first, err := DeserializeVote(data[:VoteSize])
+	if err != nil {
+		return Evidence{}, err
+	}
+	second, err := DeserializeVote(data[VoteSize:EvidenceSize])
+	if err != nil {
+		return Evidence{}, err
+	}
+	return Evidence{First: first, Second: second}, nil
+}
+
+// serializeEvidence returns TypeSlash body
+func (t *Transaction) serializeEvidence() []byte {
+	if t.Evidence == nil {
+		return make([]byte, EvidenceSize)
+	}
+	return fixed(t.Evidence.Serialize(), EvidenceSize)
+}
+
+// deserializeEvidence reads TypeSlash body
+func (t *Transaction) deserializeEvidence(body []byte) (int, error) {
+	if len(body) < EvidenceSize {
+		return 0, errShortTransaction
+	}
+	evidence, err := DeserializeEvidence(body[:EvidenceSize])
+	if err != nil {
+		return 0, err
+	}
+	t.Evidence = &evidence
+	return EvidenceSize, nil
+}
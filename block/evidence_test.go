@@ -0,0 +1,55 @@
+package block
+
+import "testing"
+
+func TestEvidence(t *testing.T) {
+	kp := NewKeyPair()
+	first := NewVote(&kp, 5, VDF([]byte{1}))
+	second := NewVote(&kp, 5, VDF([]byte{2}))
+	evidence := NewEvidence(first, second)
+	reversed := NewEvidence(second, first)
+	if !evidence.Verify() || !reversed.Verify() {
+		t.Errorf("conflicting votes should be valid evidence")
+	}
+	if string(reversed.Serialize()) != string(evidence.Serialize()) {
+		t.Errorf("evidence should not depend on order of votes")
+	}
+	res, err := DeserializeEvidence(evidence.Serialize())
+	if err != nil || !res.Verify() || string(res.ID()) != string(evidence.ID()) {
+		t.Errorf("Serialize/deserialize of evidence problem: %v", err)
+	}
+	if _, err := DeserializeEvidence(evidence.Serialize()[:EvidenceSize-1]); err != errInvalidEvidence {
+		t.Errorf("expected errInvalidEvidence, got %v", err)
+	}
+
+	other := NewKeyPair()
+	invalid := []Evidence{
+		NewEvidence(first, first),
+		NewEvidence(first, NewVote(&kp, 6, VDF([]byte{2}))),
+		NewEvidence(first, NewVote(&other, 5, VDF([]byte{2}))),
+	}
+	for _, e := range invalid {
+		if e.Verify() {
+			t.Errorf("evidence %v should be invalid", e)
+		}
+	}
+}
+
+func TestSerializeSlash(t *testing.T) {
+	kp := NewKeyPair()
+	reporter := NewKeyPair()
+	evidence := NewEvidence(NewVote(&kp, 5, VDF([]byte{1})), NewVote(&kp, 5, VDF([]byte{2})))
+	tran := NewSlash(&reporter, evidence, 0, VDF([]byte{1}))
+	s := tran.Serialize()
+	if len(s) > MaxTransactionSize() || !tran.Verify() || tran.Amount() != 0 {
+		t.Errorf("slash should be valid and fit into a packet: %v", len(s))
+	}
+	var tr Transaction
+	if err := tr.DeserializeFromSlice(s); err != nil || !tr.Equals(tran) || !tr.VerifySignature() {
+		t.Errorf("Serialize/deserialize of slash problem: %v", err)
+	}
+	tr.Evidence.Second = tr.Evidence.First
+	if tr.Verify() || tr.VerifySignature() {
+		t.Errorf("slash with invalid evidence should be invalid")
+	}
+}
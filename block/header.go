@@ -0,0 +1,125 @@
+package block
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/Ansiblock/Ansiblock/merkle"
+	"golang.org/x/crypto/ed25519"
+)
+
+// HeaderSize is the size of serialized header: previous block hash,
+// transactions root, state root, producer and its signature
+const HeaderSize = sha256.Size + 2*merkle.HashSize + ed25519.PublicKeySize + ed25519.SignatureSize
+
+// Header links the block to the previous one and authenticates its producer.
+// PrevHash is the hash of the previous block, TxRoot is Merkle root over
+// serialized transactions of the block. StateRoot is the root of account
+// state after the block, it is set only on blocks anchoring the state.
+// Signature is the producer's vote for the block hash, so two signed headers
+// with the same number and different hashes are evidence of equivocation.
+type Header struct {
+	PrevHash  []byte
+	TxRoot    []byte
+	StateRoot []byte
+	Producer  ed25519.PublicKey
+	Signature []byte
+}
+
+// transactionLeaves returns Merkle leaves of the transactions: their serialized data
+func transactionLeaves(trans *Transactions) [][]byte {
+	if trans == nil {
+		return nil
+	}
+	leaves := make([][]byte, len(trans.Ts))
+	for i := range trans.Ts {
+		leaves[i] = trans.Ts[i].Serialize()
+	}
+	return leaves
+}
+
+// TransactionsRoot returns Merkle root over serialized transactions
+func TransactionsRoot(trans *Transactions) []byte {
+	return merkle.Root(transactionLeaves(trans))
+}
+
+// Hash returns hash of the block: number, count, vdf value and the header
+// without signature. Fields of the header which are not set are hashed as zeros.
+func (b *Block) Hash() []byte {
+	h := sha256.New()
+	var num [8]byte
+	binary.BigEndian.PutUint64(num[:], b.Number)
+	h.Write(num[:])
+	binary.BigEndian.PutUint64(num[:], b.Count)
+	h.Write(num[:])
+	h.Write(fixed(b.Val, VDFSize))
+	h.Write(fixed(b.PrevHash, sha256.Size))
+	h.Write(fixed(b.TxRoot, merkle.HashSize))
+	h.Write(fixed(b.StateRoot, merkle.HashSize))
+	h.Write(fixed(b.Producer, ed25519.PublicKeySize))
+	return h.Sum(nil)
+}
+
+// Seal links the block to the previous one and signs it by the producer.
+// StateRoot should be set before the block is sealed.
+func (b *Block) Seal(producer *KeyPair, prev *Block) {
+	b.PrevHash = nil
+	if prev != nil {
+		b.PrevHash = prev.Hash()
+	}
+	b.TxRoot = TransactionsRoot(b.Transactions)
+	b.Producer = producer.Public
+	vote := NewVote(producer, b.Number, b.Hash())
+	b.Signature = vote.Signature
+}
+
+// Vote returns the producer's vote carried by the header
+func (b *Block) Vote() Vote {
+	return Vote{Number: b.Number, Val: b.Hash(), Voter: b.Producer, Signature: b.Signature}
+}
+
+// VerifyHeader checks that the block is signed by the producer and that
+// transactions root matches transactions of the block
+func (b *Block) VerifyHeader(producer ed25519.PublicKey) bool {
+	if !bytes.Equal(b.Producer, producer) || !bytes.Equal(b.TxRoot, TransactionsRoot(b.Transactions)) {
+		return false
+	}
+	vote := b.Vote()
+	return vote.Verify()
+}
+
+// serializeHeader returns fixed size header data
+func (b *Block) serializeHeader() []byte {
+	data := make([]byte, 0, HeaderSize)
+	data = append(data, fixed(b.PrevHash, sha256.Size)...)
+	data = append(data, fixed(b.TxRoot, merkle.HashSize)...)
+	data = append(data, fixed(b.StateRoot, merkle.HashSize)...)
+	data = append(data, fixed(b.Producer, ed25519.PublicKeySize)...)
+	return append(data, fixed(b.Signature, ed25519.SignatureSize)...)
+}
+
+// deserializeHeader reads header written by serializeHeader. Header of
+// unsigned block is read as empty.
+func (b *Block) deserializeHeader(data []byte) {
+	start := 0
+	b.PrevHash = optional(data[start : start+sha256.Size])
+	start += sha256.Size
+	b.TxRoot = optional(data[start : start+merkle.HashSize])
+	start += merkle.HashSize
+	b.StateRoot = optional(data[start : start+merkle.HashSize])
+	start += merkle.HashSize
+	b.Producer = optional(data[start : start+ed25519.PublicKeySize])
+	start += ed25519.PublicKeySize
+	b.Signature = optional(data[start : start+ed25519.SignatureSize])
+}
+
+// optional returns copy of data, or nil if data is all zeros
+func optional(data []byte) []byte {
+	for _, c := range data {
+		if c != 0 {
+			return append([]byte(nil), data...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package block
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/network"
+)
+
+func TestSeal(t *testing.T) {
+	producer := NewKeyPair()
+	prev := NewEmpty(VDF([]byte("header")), 0, 0)
+	trans := CreateRealTransactions(3)
+	bl := New(prev.Val, prev.Number, 0, &trans)
+	bl.Seal(&producer, &prev)
+	if !bytes.Equal(bl.PrevHash, prev.Hash()) || !bytes.Equal(bl.TxRoot, TransactionsRoot(&trans)) {
+		t.Errorf("sealed block should link to the previous block and its transactions")
+	}
+	if !bl.VerifyHeader(producer.Public) {
+		t.Errorf("sealed block should be signed by the producer")
+	}
+	other := NewKeyPair()
+	if bl.VerifyHeader(other.Public) {
+		t.Errorf("block should not be signed by other producer")
+	}
+
+	tampered := bl
+	tampered.Transactions = &Transactions{Ts: trans.Ts[:2]}
+	if tampered.VerifyHeader(producer.Public) {
+		t.Errorf("block with changed transactions should not match its header")
+	}
+	tampered = bl
+	tampered.StateRoot = bl.TxRoot
+	if tampered.VerifyHeader(producer.Public) {
+		t.Errorf("block hash should cover the state root")
+	}
+	tampered = bl
+	tampered.Count++
+	if tampered.VerifyHeader(producer.Public) || bytes.Equal(tampered.Hash(), bl.Hash()) {
+		t.Errorf("block hash should cover the count")
+	}
+}
+
+func TestHeaderVote(t *testing.T) {
+	producer := NewKeyPair()
+	prev := NewEmpty(VDF([]byte("header")), 0, 0)
+	first := NewEmpty(prev.Val, prev.Number, 1)
+	first.Seal(&producer, &prev)
+	vote := first.Vote()
+	if !vote.Verify() || !bytes.Equal(vote.Signature, NewVote(&producer, first.Number, first.Hash()).Signature) {
+		t.Errorf("header signature should be the producer's vote for the block")
+	}
+	second := NewEmpty(prev.Val, prev.Number, 2)
+	second.Seal(&producer, &prev)
+	evidence := NewEvidence(first.Vote(), second.Vote())
+	if !evidence.Verify() || !bytes.Equal(evidence.Offender(), producer.Public) {
+		t.Errorf("two signed blocks with the same number should be evidence")
+	}
+}
+
+func TestHeaderBlobs(t *testing.T) {
+	producer := NewKeyPair()
+	prev := NewEmpty(VDF([]byte("header")), 0, 0)
+	trans := CreateRealTransactions(5)
+	first := New(prev.Val, prev.Number, 0, &trans)
+	first.StateRoot = TransactionsRoot(&trans)
+	first.Seal(&producer, &prev)
+	second := NewEmpty(first.Val, first.Number, 0)
+	second.Transactions = new(Transactions)
+	blocks := BlobsToBlocks(BlocksToBlobs([]Block{first, second}))
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %v", len(blocks))
+	}
+	if !blocks[0].VerifyHeader(producer.Public) || !bytes.Equal(blocks[0].Hash(), first.Hash()) {
+		t.Errorf("header should be carried by blobs")
+	}
+	if !bytes.Equal(blocks[0].StateRoot, first.StateRoot) {
+		t.Errorf("state root should be carried by blobs")
+	}
+	if blocks[1].PrevHash != nil || blocks[1].StateRoot != nil || blocks[1].Producer != nil || blocks[1].Signature != nil {
+		t.Errorf("unsigned block should have empty header")
+	}
+	if first.Size()+second.Size()+network.DataOffset != int(BlocksToBlobs([]Block{first, second}).Bs[0].Size) {
+		t.Errorf("block size should include the header")
+	}
+}
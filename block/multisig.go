@@ -0,0 +1,168 @@
+package block
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// MaxSigners is the maximal number of signers of multisig account
+const MaxSigners = 8
+
+// cosignatureSize is the size of serialized cosignature: public key and signature
+const cosignatureSize = ed25519.PublicKeySize + ed25519.SignatureSize
+
+var errInvalidCosignatures = errors.New("invalid transaction cosignatures")
+
+// Cosignature is a signature of one of the multisig account signers
+type Cosignature struct {
+	PublicKey ed25519.PublicKey
+	Signature []byte
+}
+
+// NewMultisigRegistration will create new Transaction object which makes the
+// sender a multisig account. Afterwards its transactions must be signed by at
+// least threshold of signers, see Cosign.
+func NewMultisigRegistration(from *KeyPair, threshold byte, signers []ed25519.PublicKey, fee int64, validVDFValue VDFValue) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeRegisterMultisig, From: from.Public, Threshold: threshold,
+		Signers: signers, Fee: fee, ValidVDFValue: validVDFValue}
+	tr.Sign(from)
+	return tr
+}
+
+// Cosign adds signature of the signer to the transaction and converts it to
+// TransactionV2. Signers sign the same data, so they can cosign independently.
+// Signature of the first cosigner becomes the transaction Signature.
+func (t *Transaction) Cosign(keypair *KeyPair) {
+	if t.Version != TransactionV2 {
+		t.Version = TransactionV2
+		t.Cosignatures = nil
+	}
+	sig := ed25519.Sign(keypair.Private, t.signData())
+	t.Cosignatures = append(t.Cosignatures, Cosignature{PublicKey: keypair.Public, Signature: sig})
+	t.Signature = t.Cosignatures[0].Signature
+}
+
+// ReplayKey identifies the transaction for replay protection. Transaction of
+// a single signer is identified by its signature. Cosigned transaction is
+// identified by hash of the signed data, as the same transaction cosigned by
+// other signers or in other order has other Signature.
+func (t *Transaction) ReplayKey() []byte {
+	if t.Version != TransactionV2 {
+		return t.Signature
+	}
+	hash := sha256.Sum256(t.signData())
+	return hash[:]
+}
+
+// cosignaturesFooterSize returns size of the TransactionV2 footer with n cosignatures.
+// First cosignature is stored in the signature slot, so only its public key is in the footer.
+func cosignaturesFooterSize(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return ed25519.PublicKeySize + (n-1)*cosignatureSize + 1
+}
+
+// cosignaturesFooter returns footer of TransactionV2: public key of the first
+// cosigner, the other cosignatures and the number of cosignatures
+func (t *Transaction) cosignaturesFooter() []byte {
+	n := len(t.Cosignatures)
+	res := make([]byte, 0, cosignaturesFooterSize(n))
+	for i, c := range t.Cosignatures {
+		res = append(res, fixed(c.PublicKey, ed25519.PublicKeySize)...)
+		if i > 0 {
+			res = append(res, fixed(c.Signature, ed25519.SignatureSize)...)
+		}
+	}
+	return append(res, byte(n))
+}
+
+// deserializeCosignatures reads footer of TransactionV2 and returns
+// cosignatures and the transaction data without the footer
+func deserializeCosignatures(b []byte) ([]Cosignature, []byte, error) {
+	n := int(b[len(b)-1])
+	if n == 0 || n > MaxSigners {
+		return nil, nil, errInvalidCosignatures
+	}
+	size := cosignaturesFooterSize(n)
+	if len(b) < ed25519.SignatureSize+size {
+		return nil, nil, errShortTransaction
+	}
+	footer := b[len(b)-size:]
+	cosignatures := make([]Cosignature, n)
+	cosignatures[0].PublicKey = append([]byte(nil), footer[:ed25519.PublicKeySize]...)
+	cosignatures[0].Signature = append([]byte(nil), b[:ed25519.SignatureSize]...)
+	start := ed25519.PublicKeySize
+	for i := 1; i < n; i++ {
+		cosignatures[i].PublicKey = append([]byte(nil), footer[start:start+ed25519.PublicKeySize]...)
+		start += ed25519.PublicKeySize
+		cosignatures[i].Signature = append([]byte(nil), footer[start:start+ed25519.SignatureSize]...)
+		start += ed25519.SignatureSize
+	}
+	return cosignatures, b[:len(b)-size], nil
+}
+
+// IsMultisigned returns true if b is serialized TransactionV2
+func IsMultisigned(b []byte) bool {
+	return len(b) != legacyTransactionSize && len(b) != legacyTransactionSize+8 &&
+		len(b) > versionOffset && b[versionOffset] == TransactionV2
+}
+
+// VerifySignatures verifies all signatures of the serialized transaction without
+// deserializing it. TransactionV0 and TransactionV1 are signed by the sender,
+// TransactionV2 by each of its cosigners.
+func VerifySignatures(b []byte) bool {
+	if len(b) < legacyTransactionSize || len(b) > MaxTransactionSize() {
+		return false
+	}
+	if !IsMultisigned(b) {
+		return ed25519.Verify(b[ed25519.SignatureSize:versionOffset], b[ed25519.SignatureSize:], b[:ed25519.SignatureSize])
+	}
+	cosignatures, data, err := deserializeCosignatures(b)
+	if err != nil {
+		return false
+	}
+	for _, c := range cosignatures {
+		if !ed25519.Verify(c.PublicKey, data[ed25519.SignatureSize:], c.Signature) {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyCosignatures verifies signatures of TransactionV2
+func (t *Transaction) verifyCosignatures() bool {
+	if len(t.Cosignatures) == 0 || len(t.Cosignatures) > MaxSigners ||
+		!bytes.Equal(t.Signature, t.Cosignatures[0].Signature) {
+		return false
+	}
+	data := t.signData()
+	for _, c := range t.Cosignatures {
+		if len(c.PublicKey) != ed25519.PublicKeySize || !ed25519.Verify(c.PublicKey, data, c.Signature) {
+			return false
+		}
+	}
+	return true
+}
+
+// validSigners returns true if multisig registration has from 1 to MaxSigners
+// distinct signers and threshold is between 1 and number of signers
+func (t *Transaction) validSigners() bool {
+	if len(t.Signers) == 0 || len(t.Signers) > MaxSigners || t.Threshold == 0 || int(t.Threshold) > len(t.Signers) {
+		return false
+	}
+	for i := range t.Signers {
+		if len(t.Signers[i]) != ed25519.PublicKeySize {
+			return false
+		}
+		for j := 0; j < i; j++ {
+			if bytes.Equal(t.Signers[i], t.Signers[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
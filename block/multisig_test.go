@@ -0,0 +1,120 @@
+package block
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+func newSigners(n int) ([]KeyPair, []ed25519.PublicKey) {
+	kps := make([]KeyPair, n)
+	signers := make([]ed25519.PublicKey, n)
+	for i := range kps {
+		kps[i] = NewKeyPair()
+		signers[i] = kps[i].Public
+	}
+	return kps, signers
+}
+
+func TestSerializeMultisigRegistration(t *testing.T) {
+	kp := NewKeyPair()
+	_, signers := newSigners(3)
+	tran := NewMultisigRegistration(&kp, 2, signers, 1, VDF([]byte{1}))
+	if !tran.Verify() || tran.Amount() != 1 {
+		t.Errorf("multisig registration should be valid")
+	}
+	var tr Transaction
+	if err := tr.DeserializeFromSlice(tran.Serialize()); err != nil || !tr.Equals(tran) || !tr.VerifySignature() {
+		t.Errorf("Serialize/deserialize of multisig registration problem: %v", err)
+	}
+	tr.Threshold = 4
+	if tr.Verify() {
+		t.Errorf("threshold above number of signers should be invalid")
+	}
+	tr.Threshold = 0
+	if tr.Verify() {
+		t.Errorf("zero threshold should be invalid")
+	}
+	tr.Threshold = 1
+	tr.Signers = append(tr.Signers, tr.Signers[0])
+	if tr.Verify() {
+		t.Errorf("duplicate signers should be invalid")
+	}
+}
+
+func TestSerializeCosigned(t *testing.T) {
+	kps, _ := newSigners(3)
+	from := NewKeyPair()
+	tran := NewTransactionWithMemo(&from, from.Public, 10, 1, VDF([]byte{1}), []byte("cosigned"))
+	for i := range kps {
+		tran.Cosign(&kps[i])
+	}
+	s := tran.Serialize()
+	if tran.Version != TransactionV2 || len(s) != tran.SerializedSize() || !IsMultisigned(s) {
+		t.Errorf("cosigned transaction should be TransactionV2 of size %v", len(s))
+	}
+	var tr Transaction
+	if err := tr.DeserializeFromSlice(s); err != nil || !tr.Equals(tran) || !tr.VerifySignature() {
+		t.Errorf("Serialize/deserialize of cosigned transaction problem: %v", err)
+	}
+	if !VerifySignatures(s) {
+		t.Errorf("all cosignatures should be valid")
+	}
+	s[len(s)-2] ^= 1
+	if VerifySignatures(s) {
+		t.Errorf("damaged cosignature should be detected")
+	}
+	s[len(s)-2] ^= 1
+	s[headerSize] ^= 1
+	if VerifySignatures(s) {
+		t.Errorf("cosignatures should cover transaction body")
+	}
+	s[headerSize] ^= 1
+
+	s[len(s)-1] = MaxSigners + 1
+	if err := tr.DeserializeFromSlice(s); err != errInvalidCosignatures {
+		t.Errorf("expected errInvalidCosignatures, got %v", err)
+	}
+	s[len(s)-1] = 0
+	if err := tr.DeserializeFromSlice(s); err != errInvalidCosignatures {
+		t.Errorf("expected errInvalidCosignatures, got %v", err)
+	}
+}
+
+func TestVerifySignaturesSingleSigner(t *testing.T) {
+	v0 := CreateRealTransaction(5)
+	v1 := newTransactionV1(10, 1)
+	for _, tran := range []Transaction{v0, v1} {
+		s := tran.Serialize()
+		if IsMultisigned(s) || !VerifySignatures(s) {
+			t.Errorf("transaction of version %v should be signed by the sender", tran.Version)
+		}
+		s[len(s)-1] ^= 1
+		if VerifySignatures(s) {
+			t.Errorf("damaged transaction of version %v should be detected", tran.Version)
+		}
+	}
+}
+
+func TestReplayKey(t *testing.T) {
+	kps, _ := newSigners(2)
+	from := NewKeyPair()
+	tran := NewTransactionWithMemo(&from, from.Public, 10, 1, VDF([]byte{1}), []byte("replay"))
+	if !bytes.Equal(tran.ReplayKey(), tran.Signature) {
+		t.Errorf("transaction of single signer should be identified by its signature")
+	}
+	tran.Cosign(&kps[0])
+	tran.Cosign(&kps[1])
+	reordered := tran
+	reordered.Cosignatures = []Cosignature{tran.Cosignatures[1], tran.Cosignatures[0]}
+	reordered.Signature = reordered.Cosignatures[0].Signature
+	if !reordered.VerifySignature() || !bytes.Equal(reordered.ReplayKey(), tran.ReplayKey()) {
+		t.Errorf("reordered cosignatures should not change replay key")
+	}
+	other := tran
+	other.Token++
+	if bytes.Equal(other.ReplayKey(), tran.ReplayKey()) {
+		t.Errorf("replay key should cover the signed data")
+	}
+}
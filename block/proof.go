@@ -0,0 +1,42 @@
+package block
+
+import (
+	"bytes"
+
+	"github.com/Ansiblock/Ansiblock/merkle"
+)
+
+// TransactionProof proves that the transaction is included into the block
+// without the rest of block transactions. Block carries the signed header
+// only, its Transactions are not set. Index is position of the transaction
+// in the block.
+type TransactionProof struct {
+	Block       Block
+	Transaction Transaction
+	Index       int
+	Proof       merkle.Proof
+}
+
+// TransactionProof returns proof of inclusion of the transaction with given
+// signature into the block, nil if the block does not contain it
+func (b *Block) TransactionProof(signature []byte) *TransactionProof {
+	leaves := transactionLeaves(b.Transactions)
+	for i := range leaves {
+		if !bytes.Equal(b.Transactions.Ts[i].Signature, signature) {
+			continue
+		}
+		proof, _ := merkle.NewProof(leaves, i)
+		header := *b
+		header.Transactions = nil
+		return &TransactionProof{Block: header, Transaction: b.Transactions.Ts[i], Index: i, Proof: proof}
+	}
+	return nil
+}
+
+// Verify checks that the transaction is a leaf of the transactions root and
+// that the header is signed by its producer. The caller should compare the
+// producer with the one it trusts.
+func (p *TransactionProof) Verify() bool {
+	vote := p.Block.Vote()
+	return vote.Verify() && merkle.Verify(p.Block.TxRoot, p.Transaction.Serialize(), p.Proof)
+}
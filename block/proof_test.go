@@ -0,0 +1,34 @@
+package block
+
+import (
+	"testing"
+)
+
+func TestTransactionProof(t *testing.T) {
+	producer := NewKeyPair()
+	prev := NewEmpty(VDF([]byte("proof")), 0, 0)
+	trans := CreateRealTransactions(7)
+	bl := New(prev.Val, prev.Number, 0, &trans)
+	bl.Seal(&producer, &prev)
+	for i := range trans.Ts {
+		proof := bl.TransactionProof(trans.Ts[i].Signature)
+		if proof == nil || proof.Index != i || proof.Block.Transactions != nil || !proof.Verify() {
+			t.Fatalf("proof of transaction %v should be valid", i)
+		}
+	}
+	if bl.TransactionProof([]byte("unknown")) != nil {
+		t.Errorf("proof of unknown transaction should be nil")
+	}
+
+	proof := bl.TransactionProof(trans.Ts[3].Signature)
+	proof.Transaction = trans.Ts[4]
+	if proof.Verify() {
+		t.Errorf("proof should not verify other transaction")
+	}
+	proof = bl.TransactionProof(trans.Ts[3].Signature)
+	proof.Block.TxRoot = TransactionsRoot(&Transactions{Ts: trans.Ts[3:4]})
+	proof.Proof = nil
+	if proof.Verify() {
+		t.Errorf("proof should not verify with root which is not signed by the producer")
+	}
+}
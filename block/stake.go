@@ -0,0 +1,45 @@
+package block
+
+import "golang.org/x/crypto/ed25519"
+
+// NewStake will create new TransactionV1 object which delegates token to the
+// validator. Like transfer, fee is included in token, so token-fee is staked.
+func NewStake(from *KeyPair, validator ed25519.PublicKey, token int64, fee int64, validVDFValue VDFValue) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeStake, From: from.Public, To: validator, Token: token,
+		Fee: fee, ValidVDFValue: validVDFValue}
+	tr.Sign(from)
+	return tr
+}
+
+// NewUnstake will create new TransactionV1 object which starts unbonding of token
+// delegated to the validator. Unbonded token returns to the sender after cool-down.
+func NewUnstake(from *KeyPair, validator ed25519.PublicKey, token int64, fee int64, validVDFValue VDFValue) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeUnstake, From: from.Public, To: validator, Token: token,
+		Fee: fee, ValidVDFValue: validVDFValue}
+	tr.Sign(from)
+	return tr
+}
+
+// NewBindNode will create new TransactionV1 object which binds the node to the
+// validator. It is signed by the validator, so the node can not claim stake
+// of a validator which did not bind it.
+func NewBindNode(validator *KeyPair, node ed25519.PublicKey, fee int64, validVDFValue VDFValue) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeBindNode, From: validator.Public, To: node,
+		Fee: fee, ValidVDFValue: validVDFValue}
+	tr.Sign(validator)
+	return tr
+}
+
+// verifyStake verifies fields of stake transaction types
+func (t *Transaction) verifyStake() bool {
+	if len(t.To) != ed25519.PublicKeySize || t.Fee < 0 {
+		return false
+	}
+	switch t.Type {
+	case TypeStake:
+		return t.Fee < t.Token
+	case TypeBindNode:
+		return t.Token == 0
+	}
+	return t.Token > 0
+}
@@ -0,0 +1,38 @@
+package block
+
+import "testing"
+
+func TestSerializeStake(t *testing.T) {
+	kp := NewKeyPair()
+	validator := NewKeyPair()
+	stake := NewStake(&kp, validator.Public, 10, 1, VDF([]byte{1}))
+	unstake := NewUnstake(&kp, validator.Public, 5, 1, VDF([]byte{1}))
+	bind := NewBindNode(&validator, kp.Public, 1, VDF([]byte{1}))
+	if !stake.Verify() || stake.Amount() != 10 {
+		t.Errorf("stake should be valid with amount %v", stake.Amount())
+	}
+	if !unstake.Verify() || unstake.Amount() != 1 {
+		t.Errorf("unstake should be valid with amount %v", unstake.Amount())
+	}
+	if !bind.Verify() || bind.Amount() != 1 {
+		t.Errorf("node binding should be valid with amount %v", bind.Amount())
+	}
+	for _, tran := range []Transaction{stake, unstake, bind} {
+		var tr Transaction
+		if err := tr.DeserializeFromSlice(tran.Serialize()); err != nil || !tr.Equals(tran) || !tr.VerifySignature() {
+			t.Errorf("Serialize/deserialize of stake problem: %v, %v != %v", err, tr, tran)
+		}
+	}
+	if tran := NewStake(&kp, validator.Public, 1, 1, VDF([]byte{1})); tran.Verify() {
+		t.Errorf("stake of nothing should be invalid")
+	}
+	if tran := NewUnstake(&kp, validator.Public, 0, 1, VDF([]byte{1})); tran.Verify() {
+		t.Errorf("unstake of nothing should be invalid")
+	}
+	if tran := NewStake(&kp, validator.Public[:5], 10, 1, VDF([]byte{1})); tran.Verify() {
+		t.Errorf("stake to invalid validator should be invalid")
+	}
+	if tran := NewBindNode(&validator, kp.Public[:5], 1, VDF([]byte{1})); tran.Verify() {
+		t.Errorf("binding of invalid node should be invalid")
+	}
+}
@@ -2,10 +2,10 @@ package block
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"fmt"
 	"strconv"
 
+	"github.com/Ansiblock/Ansiblock/network"
 	"golang.org/x/crypto/ed25519"
 )
 
@@ -13,12 +13,30 @@ import (
 // For now we treat transaction as `token` transfer `from`
 // user `to` another user. This transfer may have a `fee`.
 type Transaction struct {
+	Version       byte            // wire format, see encoding.go
+	Type          TransactionType // kind of the transaction
 	From          ed25519.PublicKey
 	To            ed25519.PublicKey
 	Token         int64
 	Fee           int64
 	ValidVDFValue VDFValue
 	Signature     []byte
+	Nonce         uint64              // optional sequence number of the sender, zero relies on ValidVDFValue
+	Memo          []byte              // reference covered by the signature, requires TransactionV1
+	Outputs       []Output            // recipients of TypeBatchTransfer, To and Token are not used by it
+	Threshold     byte                // TypeRegisterMultisig
+	Signers       []ed25519.PublicKey // TypeRegisterMultisig
+	Cosignatures  []Cosignature       // TransactionV2, see multisig.go
+	Condition     EscrowCondition     // TypeEscrow
+	Escrow        []byte              // signature of the escrow released or cancelled
+	Asset         []byte              // TypeMintAsset and TypeAssetTransfer
+	Evidence      *Evidence           // equivocation reported by TypeSlash
+}
+
+// Output is a single recipient of batch transfer
+type Output struct {
+	To    ed25519.PublicKey
+	Token int64
 }
 
 // NewTransaction will create new Transaction object
@@ -28,44 +46,28 @@ func NewTransaction(from *KeyPair, to ed25519.PublicKey, token int64, fee int64,
 	return tr
 }
 
-// Serialize is responsible converting Transaction to byte array
-func (t *Transaction) Serialize() *[256]byte {
-	var res [256]byte
-	start := 0
-	for i := 0; i < ed25519.SignatureSize; i++ {
-		res[start+i] = t.Signature[i]
-	}
-	start += ed25519.SignatureSize
-	for i := 0; i < len(t.From); i++ {
-		res[start+i] = t.From[i]
-	}
-	start += len(t.From)
-	for i := 0; i < len(t.To); i++ {
-		res[start+i] = t.To[i]
-	}
-	start += len(t.To)
-	res[start] = byte(t.Token >> 56)
-	res[start+1] = byte(t.Token >> 48)
-	res[start+2] = byte(t.Token >> 40)
-	res[start+3] = byte(t.Token >> 32)
-	res[start+4] = byte(t.Token >> 24)
-	res[start+5] = byte(t.Token >> 16)
-	res[start+6] = byte(t.Token >> 8)
-	res[start+7] = byte(t.Token)
-	start += 8
-	res[start] = byte(t.Fee >> 56)
-	res[start+1] = byte(t.Fee >> 48)
-	res[start+2] = byte(t.Fee >> 40)
-	res[start+3] = byte(t.Fee >> 32)
-	res[start+4] = byte(t.Fee >> 24)
-	res[start+5] = byte(t.Fee >> 16)
-	res[start+6] = byte(t.Fee >> 8)
-	res[start+7] = byte(t.Fee)
-	start += 8
-	for i := 0; i < sha256.Size; i++ {
-		res[start+i] = t.ValidVDFValue[i]
-	}
-	return &res
+// NewTransactionWithNonce will create new Transaction object with sender's sequence number
+func NewTransactionWithNonce(from *KeyPair, to ed25519.PublicKey, token int64, fee int64, validVDFValue VDFValue, nonce uint64) Transaction {
+	tr := Transaction{From: from.Public, To: to, Token: token, Fee: fee, ValidVDFValue: validVDFValue, Nonce: nonce}
+	tr.Sign(from)
+	return tr
+}
+
+// NewTransactionWithMemo will create new TransactionV1 object with memo
+func NewTransactionWithMemo(from *KeyPair, to ed25519.PublicKey, token int64, fee int64, validVDFValue VDFValue, memo []byte) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeTransfer, From: from.Public, To: to, Token: token, Fee: fee,
+		ValidVDFValue: validVDFValue, Memo: memo}
+	tr.Sign(from)
+	return tr
+}
+
+// NewBatchTransaction will create new TransactionV1 object which transfers tokens
+// from the sender to every output. Sender pays sum of outputs plus fee.
+func NewBatchTransaction(from *KeyPair, outputs []Output, fee int64, validVDFValue VDFValue) Transaction {
+	tr := Transaction{Version: TransactionV1, Type: TypeBatchTransfer, From: from.Public, Outputs: outputs, Fee: fee,
+		ValidVDFValue: validVDFValue}
+	tr.Sign(from)
+	return tr
 }
 
 // TODO check on littleendian system
@@ -77,42 +79,66 @@ func ByteToInt32(b []byte, st int) int32 {
 	return int32(uint32(b[st+3]) | uint32(b[st+2])<<8 | uint32(b[st+1])<<16 | uint32(b[st])<<24)
 }
 
-// DeserializeFromSlice is responsible for creating Transaction object from byte slice
-func (t *Transaction) DeserializeFromSlice(b []byte) {
-	t.From = make([]byte, ed25519.PublicKeySize)
-	t.To = make([]byte, ed25519.PublicKeySize)
-	t.ValidVDFValue = make([]byte, sha256.Size)
-	t.Signature = make([]byte, ed25519.SignatureSize)
-	start := 0
-	for i := 0; i < ed25519.SignatureSize; i++ {
-		t.Signature[i] = b[start+i]
+// Verify method verifies that transaction fee is correct and memo can be serialized.
+// Batch transfer must have from 1 to MaxOutputs outputs with non negative tokens.
+// Multisig registration must have valid signers and threshold.
+// Escrow must have at least one release condition.
+// Asset transactions must have non negative token and fee.
+// Stake must stake more than its fee, unstake must unstake some token.
+// Node binding must not carry token. Slash must carry valid evidence of equivocation.
+func (t *Transaction) Verify() bool {
+	if len(t.Memo) > MaxMemoSize || (len(t.Memo) > 0 && t.Version == TransactionV0) {
+		return false
 	}
-	start += ed25519.SignatureSize
-	for i := 0; i < ed25519.PublicKeySize; i++ {
-		t.From[i] = b[start+i]
+	if t.Version == TransactionV0 && t.Type != TypeTransfer {
+		return false
 	}
-	start += ed25519.PublicKeySize
-	for i := 0; i < ed25519.PublicKeySize; i++ {
-		t.To[i] = b[start+i]
+	if t.Version != TransactionV0 && t.SerializedSize() > MaxTransactionSize() {
+		return false
 	}
-	start += ed25519.PublicKeySize
-	t.Token = ByteToInt64(b[:], start)
-	start += 8
-	t.Fee = ByteToInt64(b[:], start)
-	start += 8
-	for i := 0; i < sha256.Size; i++ {
-		t.ValidVDFValue[i] = b[start+i]
+	switch t.Type {
+	case TypeRegisterMultisig:
+		return t.validSigners() && t.Fee >= 0
+	case TypeEscrow, TypeEscrowRelease, TypeEscrowCancel:
+		return t.verifyEscrow()
+	case TypeCreateAsset, TypeMintAsset, TypeAssetTransfer:
+		return t.verifyAsset()
+	case TypeStake, TypeUnstake, TypeBindNode:
+		return t.verifyStake()
+	case TypeSlash:
+		return t.Evidence != nil && t.Evidence.Verify() && t.Fee >= 0
 	}
+	if t.Type == TypeBatchTransfer {
+		if len(t.Outputs) == 0 || len(t.Outputs) > MaxOutputs || t.Fee < 0 {
+			return false
+		}
+		sum := t.Fee
+		for _, o := range t.Outputs {
+			if o.Token < 0 || sum+o.Token < sum {
+				return false
+			}
+			sum += o.Token
+		}
+		return true
+	}
+	return 0 <= t.Fee && t.Fee <= t.Token
 }
 
-// Deserialize is responsible for creating Transaction object from byte array
-func (t *Transaction) Deserialize(b *[256]byte) {
-	t.DeserializeFromSlice(b[:])
-}
-
-// Verify method verifies that transaction fee is correct
-func (t *Transaction) Verify() bool {
-	return 0 <= t.Fee && t.Fee <= t.Token
+// Amount returns number of native tokens withdrawn from the sender. Batch
+// transfer withdraws all its outputs at once, so either all are paid or none
+func (t *Transaction) Amount() int64 {
+	switch t.Type {
+	case TypeBatchTransfer:
+		sum := t.Fee
+		for _, o := range t.Outputs {
+			sum += o.Token
+		}
+		return sum
+	case TypeRegisterMultisig, TypeEscrowRelease, TypeEscrowCancel, TypeCreateAsset, TypeMintAsset, TypeAssetTransfer,
+		TypeUnstake, TypeSlash, TypeBindNode:
+		return t.Fee
+	}
+	return t.Token
 }
 
 // Equals compares two transactions
@@ -120,9 +146,39 @@ func (t *Transaction) Equals(tran Transaction) bool {
 
 	if t.Fee != tran.Fee || !bytes.Equal(t.From, tran.From) ||
 		!bytes.Equal(t.To, tran.To) || t.Token != tran.Token ||
-		!bytes.Equal(t.Signature, tran.Signature) {
+		!bytes.Equal(t.Signature, tran.Signature) || t.Nonce != tran.Nonce ||
+		t.Version != tran.Version || t.Type != tran.Type || !bytes.Equal(t.Memo, tran.Memo) ||
+		len(t.Outputs) != len(tran.Outputs) {
+		return false
+	}
+	for i := range t.Outputs {
+		if !bytes.Equal(t.Outputs[i].To, tran.Outputs[i].To) || t.Outputs[i].Token != tran.Outputs[i].Token {
+			return false
+		}
+	}
+	if t.Condition.ReleaseHeight != tran.Condition.ReleaseHeight || t.Condition.ReleaseCount != tran.Condition.ReleaseCount ||
+		t.Condition.CancelHeight != tran.Condition.CancelHeight || !bytes.Equal(t.Condition.Witness, tran.Condition.Witness) ||
+		!bytes.Equal(t.Escrow, tran.Escrow) || !bytes.Equal(t.Asset, tran.Asset) {
+		return false
+	}
+	if (t.Evidence == nil) != (tran.Evidence == nil) ||
+		(t.Evidence != nil && !bytes.Equal(t.Evidence.Serialize(), tran.Evidence.Serialize())) {
+		return false
+	}
+	if t.Threshold != tran.Threshold || len(t.Signers) != len(tran.Signers) || len(t.Cosignatures) != len(tran.Cosignatures) {
 		return false
 	}
+	for i := range t.Signers {
+		if !bytes.Equal(t.Signers[i], tran.Signers[i]) {
+			return false
+		}
+	}
+	for i := range t.Cosignatures {
+		if !bytes.Equal(t.Cosignatures[i].PublicKey, tran.Cosignatures[i].PublicKey) ||
+			!bytes.Equal(t.Cosignatures[i].Signature, tran.Cosignatures[i].Signature) {
+			return false
+		}
+	}
 	return true
 }
 
@@ -131,32 +187,9 @@ func (t *Transaction) String() string {
 	return fmt.Sprintf("{ \nFrom: %v, \nTo: %v, \nAmount: %v, Fee: %v}", t.From, t.To, t.Token, t.Fee)
 }
 
+// signData returns data covered by the signature, it is the serialized transaction without signature
 func (t *Transaction) signData() []byte {
-	sData := make([]byte, 0, 112)
-	sData = append(sData, t.From...)
-	sData = append(sData, t.To...)
-	// sData = append(sData, int64toBytes(t.Token)...)
-	// sData = append(sData, int64toBytes(t.Fee)...)
-
-	sData = append(sData, byte(t.Token>>56))
-	sData = append(sData, byte(t.Token>>48))
-	sData = append(sData, byte(t.Token>>40))
-	sData = append(sData, byte(t.Token>>32))
-	sData = append(sData, byte(t.Token>>24))
-	sData = append(sData, byte(t.Token>>16))
-	sData = append(sData, byte(t.Token>>8))
-	sData = append(sData, byte(t.Token))
-	sData = append(sData, byte(t.Fee>>56))
-	sData = append(sData, byte(t.Fee>>48))
-	sData = append(sData, byte(t.Fee>>40))
-	sData = append(sData, byte(t.Fee>>32))
-	sData = append(sData, byte(t.Fee>>24))
-	sData = append(sData, byte(t.Fee>>16))
-	sData = append(sData, byte(t.Fee>>8))
-	sData = append(sData, byte(t.Fee))
-
-	sData = append(sData, t.ValidVDFValue...)
-	return sData
+	return t.payload()
 }
 
 // Sign method signs Transaction with ed25519
@@ -165,15 +198,29 @@ func (t *Transaction) Sign(keypair *KeyPair) {
 	t.Signature = ed25519.Sign(keypair.Private, sigData)
 }
 
-// VerifySignature method verifies Transaction signature
+// VerifySignature method verifies Transaction signature, TransactionV2 is verified
+// against all its cosigners
 func (t *Transaction) VerifySignature() bool {
+	if t.Version == TransactionV2 {
+		return t.verifyCosignatures()
+	}
 	return len(t.From) == ed25519.PublicKeySize &&
 		ed25519.Verify(t.From, t.signData(), t.Signature)
 }
 
-// TransactionSize returns size of transaction in bytes
+// MinTransactionSize returns size of the smallest valid transaction, TransactionV0 without nonce
+func MinTransactionSize() int {
+	return legacyTransactionSize
+}
+
+// TransactionSize returns size of TransactionV0 in bytes, including nonce
 func TransactionSize() int {
-	return ed25519.PublicKeySize*2 + ed25519.SignatureSize + 16 + sha256.Size
+	return legacyTransactionSize + 8
+}
+
+// MaxTransactionSize returns maximal size of serialized transaction, it must fit into a single packet
+func MaxTransactionSize() int {
+	return network.PacketDataSize
 }
 
 // CreateDummyTransaction responsible for creating dummy Transaction object
@@ -76,7 +76,7 @@ func TestSerialize(t *testing.T) {
 	for i := int64(0); i < 10; i++ {
 		tr := CreateRealTransaction(i)
 		a := Transaction{}
-		a.Deserialize(tr.Serialize())
+		a.DeserializeFromSlice(tr.Serialize())
 		if !a.Equals(tr) {
 			t.Errorf("Serialize/deserialize problem: %v != %v", a, tr)
 		}
@@ -95,7 +95,7 @@ func TestSigVerify2(t *testing.T) {
 	tran := CreateRealTransaction(1)
 	s := tran.Serialize()
 	var tr Transaction
-	tr.Deserialize(s)
+	tr.DeserializeFromSlice(s)
 	if !tr.Equals(tran) {
 		t.Errorf("!!SigVerify problem: %v\n != %v\n ", tran, tr)
 	}
@@ -124,3 +124,34 @@ func TestCreateRealTransactionFrom(t *testing.T) {
 		t.Errorf("CreateRealTransactionFrom error %v!=%v\n%v\n!=\n%v\n", tran.Token, 10, tran.From, from.Public)
 	}
 }
+
+func TestTransactionNonce(t *testing.T) {
+	from := NewKeyPair()
+	to := NewKeyPair()
+	tran := NewTransactionWithNonce(&from, to.Public, 10, 1, VDF([]byte("hello")), 7)
+	if tran.SerializedSize() != TransactionSize() {
+		t.Errorf("transaction with nonce size %v != %v", tran.SerializedSize(), TransactionSize())
+	}
+	s := tran.Serialize()
+	if !ed25519.Verify(s[64:96], s[64:TransactionSize()], s[0:64]) {
+		t.Errorf("nonce should be part of signed data")
+	}
+	var tr Transaction
+	tr.DeserializeFromSlice(s[:tran.SerializedSize()])
+	if !tr.Equals(tran) || tr.Nonce != 7 || !tr.VerifySignature() {
+		t.Errorf("deserialized transaction differs: %v != %v", tr, tran)
+	}
+	tr.Nonce = 8
+	if tr.VerifySignature() {
+		t.Errorf("changed nonce should invalidate signature")
+	}
+
+	legacy := NewTransaction(&from, to.Public, 10, 1, VDF([]byte("hello")))
+	if legacy.SerializedSize() != MinTransactionSize() {
+		t.Errorf("transaction without nonce size %v != %v", legacy.SerializedSize(), MinTransactionSize())
+	}
+	tr.DeserializeFromSlice(legacy.Serialize()[:MinTransactionSize()])
+	if tr.Nonce != 0 || !tr.VerifySignature() {
+		t.Errorf("legacy transaction should be deserialized without nonce")
+	}
+}
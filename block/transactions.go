@@ -2,13 +2,29 @@ package block
 
 import (
 	"crypto/sha256"
+	"errors"
 	"net"
 	"runtime"
 
+	"github.com/Ansiblock/Ansiblock/log"
 	"github.com/Ansiblock/Ansiblock/network"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/ed25519"
 )
 
+const (
+	// transactionSizePrefix is the size of transaction length stored before each transaction in blob
+	transactionSizePrefix = 2
+	// vdfProofSizePrefix is the size of vdf proof length stored before the proof in blob
+	vdfProofSizePrefix = 2
+	// blockHeaderSize is the size of block number, count, vdf value, empty vdf proof, header
+	// and number of transactions in blob
+	blockHeaderSize = 8 + 8 + sha256.Size + vdfProofSizePrefix + HeaderSize + 4
+)
+
+// errShortBlock is returned when blob ends in the middle of the block
+var errShortBlock = errors.New("blob data is too short for the block")
+
 // Transactions represents slice of Transaction-s
 type Transactions struct {
 	Ts []Transaction
@@ -67,6 +83,31 @@ func (ts *Transactions) verifyThreads() bool {
 	return true
 }
 
+// FirstInvalidSignature returns index of the first transaction with invalid signature, or -1 if all signatures are valid
+func (ts *Transactions) FirstInvalidSignature() int {
+	threads := runtime.NumCPU()
+	res := make(chan int, threads)
+	l := len(ts.Ts)
+	for i := 0; i < threads; i++ {
+		go func(start int, finish int) {
+			for j := start; j < finish; j++ {
+				if !ts.Ts[j].VerifySignature() {
+					res <- j
+					return
+				}
+			}
+			res <- -1
+		}(i*l/threads, (i+1)*l/threads)
+	}
+	first := -1
+	for i := 0; i < threads; i++ {
+		if j := <-res; j >= 0 && (first < 0 || j < first) {
+			first = j
+		}
+	}
+	return first
+}
+
 func (ts *Transactions) verifySerial() bool {
 	for _, trans := range ts.Ts {
 		if !trans.Verify() {
@@ -96,9 +137,8 @@ func (ts *Transactions) Equals(trs Transactions) bool {
 
 func fromPackets(ts *Transactions, start int, finish int, packets *network.Packets, res chan bool) {
 	for i := start; i < finish; i++ {
-		if packets.Ps[i].Size != 0 {
-			ts.Ts[i].Deserialize(&packets.Ps[i].Data)
-		} else {
+		size := int(packets.Ps[i].Size)
+		if size > len(packets.Ps[i].Data) || ts.Ts[i].DeserializeFromSlice(packets.Ps[i].Data[:size]) != nil {
 			ts.Ts[i] = Transaction{Fee: -1}
 		}
 	}
@@ -129,8 +169,7 @@ func (ts *Transactions) ToPackets(addr net.Addr) *network.Packets {
 	counter := make(chan bool, 100)
 	for i := range ts.Ts {
 		go func(i int) {
-			packets.Ps[i].Data = *ts.Ts[i].Serialize()
-			packets.Ps[i].Size = uint8(TransactionSize())
+			packets.Ps[i].Size = uint16(copy(packets.Ps[i].Data[:], ts.Ts[i].Serialize()))
 			packets.Ps[i].Addr = addr
 			counter <- true
 		}(i)
@@ -141,9 +180,13 @@ func (ts *Transactions) ToPackets(addr net.Addr) *network.Packets {
 	return packets
 }
 
-// Size method returns size of transactions in bytes
+// Size method returns size of transactions in bytes, as they are packed into blob
 func (ts *Transactions) Size() int {
-	return len(ts.Ts) * TransactionSize()
+	size := 0
+	for i := range ts.Ts {
+		size += transactionSizePrefix + ts.Ts[i].SerializedSize()
+	}
+	return size
 }
 
 // countNumberOfBlocksInBlob determines how many blocks will be in a single block
@@ -163,7 +206,6 @@ func countNumberOfBlocksInBlob(blocks []Block, start int) int {
 func convertToBlob(blocks []Block, start int, end int) *network.Blob {
 	res := new(network.Blob)
 	st := network.DataOffset
-	tranSize := TransactionSize()
 	for i := start; i <= end; i++ {
 		res.Data[st+0] = byte(blocks[i].Number >> 56)
 		res.Data[st+1] = byte(blocks[i].Number >> 48)
@@ -185,19 +227,27 @@ func convertToBlob(blocks []Block, start int, end int) *network.Blob {
 		for j := 0; j < VDFSize; j++ {
 			res.Data[st+8+j] = blocks[i].Val[j]
 		}
+		st += 8 + VDFSize
+		res.Data[st] = byte(len(blocks[i].VDFProof) >> 8)
+		res.Data[st+1] = byte(len(blocks[i].VDFProof))
+		st += vdfProofSizePrefix
+		st += copy(res.Data[st:], blocks[i].VDFProof)
+		st += copy(res.Data[st:], blocks[i].serializeHeader())
 		count := blocks[i].Transactions.Count()
-		res.Data[st+40] = byte(count >> 24)
-		res.Data[st+41] = byte(count >> 16)
-		res.Data[st+42] = byte(count >> 8)
-		res.Data[st+43] = byte(count)
+		res.Data[st+0] = byte(count >> 24)
+		res.Data[st+1] = byte(count >> 16)
+		res.Data[st+2] = byte(count >> 8)
+		res.Data[st+3] = byte(count)
+		st += 4
 		trans := blocks[i].Transactions
 		for j := 0; j < int(count); j++ {
+			// every transaction is prefixed by its size, so different versions can be mixed
 			tranData := trans.Ts[j].Serialize()
-			for k := 0; k < tranSize; k++ {
-				res.Data[st+44+j*tranSize+k] = tranData[k]
-			}
+			res.Data[st] = byte(len(tranData) >> 8)
+			res.Data[st+1] = byte(len(tranData))
+			st += transactionSizePrefix
+			st += copy(res.Data[st:], tranData)
 		}
-		st += 44 + tranSize*int(count)
 	}
 	res.Size = uint32(st)
 	// fmt.Println("++++++++convertToBlob blob index size n", res.Index(), res.Size, ByteToInt32(res.Data[network.DataOffset+16+32:], 0))
@@ -225,52 +275,101 @@ func BlocksToBlobs(blocks []Block) *network.Blobs {
 	return res
 }
 
-// BlobsToBlocks will deserialize slice of blobs into slice of Blocks
+// blockFromBlob deserializes single block starting at data[0]. It returns the
+// block and number of bytes read.
+func blockFromBlob(data []byte) (*Block, int, error) {
+	if len(data) < blockHeaderSize {
+		return nil, 0, errShortBlock
+	}
+	b := new(Block)
+	start := 0
+	b.Number = uint64(ByteToInt64(data, start))
+	start += 8
+	b.Count = uint64(ByteToInt64(data, start))
+	start += 8
+	b.Val = append([]byte(nil), data[start:start+sha256.Size]...)
+	start += sha256.Size
+	proofSize := int(data[start])<<8 | int(data[start+1])
+	start += vdfProofSizePrefix
+	if proofSize > len(data)-blockHeaderSize {
+		return nil, 0, errShortBlock
+	}
+	if proofSize != 0 {
+		b.VDFProof = append([]byte(nil), data[start:start+proofSize]...)
+		start += proofSize
+	}
+	b.deserializeHeader(data[start : start+HeaderSize])
+	start += HeaderSize
+	n := int(ByteToInt32(data, start))
+	start += 4
+	if n < 0 || n*(transactionSizePrefix+MinTransactionSize()) > len(data)-start {
+		return nil, 0, errShortBlock
+	}
+	b.Transactions = new(Transactions)
+	b.Transactions.Ts = make([]Transaction, n)
+	for j := 0; j < n; j++ {
+		if len(data)-start < transactionSizePrefix {
+			return nil, 0, errShortBlock
+		}
+		size := int(data[start])<<8 | int(data[start+1])
+		start += transactionSizePrefix
+		if len(data)-start < size {
+			return nil, 0, errShortBlock
+		}
+		if err := b.Transactions.Ts[j].DeserializeFromSlice(data[start : start+size]); err != nil {
+			return nil, 0, err
+		}
+		start += size
+	}
+	return b, start, nil
+}
+
+// zeroPadding returns true if the rest of the blob is zero, blobs recovered
+// from the coding group are padded with zeros to the size of the largest blob
+func zeroPadding(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// blockKey identifies block in BlobsToBlocks. Empty block without VDF iterations
+// has the same value as the previous block, so the number is part of the key.
+type blockKey struct {
+	number uint64
+	val    string
+}
+
+// BlobsToBlocks will deserialize slice of blobs into slice of Blocks.
+// Malformed rest of the blob is dropped.
 // TODO should be optimized!!!
 func BlobsToBlocks(blobs *network.Blobs) []Block {
-	blockMap := make(map[string]Block)
-	blockIndexesMap := make(map[string]int)
+	blockMap := make(map[blockKey]Block)
+	blockIndexesMap := make(map[blockKey]int)
 
 	blockIndex := 0
 	for _, bl := range blobs.Bs {
 		start := network.DataOffset
-		for start < int(bl.Size) {
-			number := ByteToInt64(bl.Data[start:], 0)
-			start += 8
-			count := ByteToInt64(bl.Data[start:], 0)
-			vdf := make([]byte, sha256.Size)
-			start += 8
-			for j := 0; j < sha256.Size; j++ {
-				vdf[j] = bl.Data[start+j]
-			}
-			start += sha256.Size
-			n := ByteToInt32(bl.Data[start:], 0)
-			start += 4
-			transactions := make([]Transaction, n)
-			for j := int32(0); j < n; j++ {
-				// fmt.Printf("bl.Data = %v\n", len(bl.Data))
-				// fmt.Printf("start = %v\n", start)
-				transactions[j].DeserializeFromSlice(bl.Data[start:])
-				start += 176
+		end := int(bl.Size)
+		if end > len(bl.Data) {
+			end = len(bl.Data)
+		}
+		for start < end && !zeroPadding(bl.Data[start:end]) {
+			b, n, err := blockFromBlob(bl.Data[start:end])
+			if err != nil {
+				log.Error("can not deserialize block from blob", zap.Uint64("Index", bl.Index()), zap.Error(err))
+				break
 			}
-			if bl, ok := blockMap[string(vdf)]; ok {
-				trans := bl.Transactions
-				trans.Ts = append(trans.Ts, transactions...)
+			start += n
+			key := blockKey{number: b.Number, val: string(b.Val)}
+			if old, ok := blockMap[key]; ok {
+				trans := old.Transactions
+				trans.Ts = append(trans.Ts, b.Transactions.Ts...)
 			} else {
-				b := new(Block)
-				b.Count = uint64(count)
-				b.Val = make([]byte, sha256.Size)
-				b.Number = uint64(number)
-				for i := range vdf {
-					b.Val[i] = vdf[i]
-				}
-				trans := new(Transactions)
-				for i := range transactions {
-					trans.Ts = append(trans.Ts, transactions[i])
-				}
-				b.Transactions = trans
-				blockMap[string(vdf)] = *b
-				blockIndexesMap[string(vdf)] = blockIndex
+				blockMap[key] = *b
+				blockIndexesMap[key] = blockIndex
 				blockIndex++
 			}
 		}
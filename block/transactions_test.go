@@ -8,16 +8,16 @@ import (
 
 func TestSize2(t *testing.T) {
 	trs := CreateRealTransactions(10)
-	if trs.Size() != 10*176 {
-		t.Errorf("Transactions size problem: %v != %v", trs.Size(), 10*176)
+	if trs.Size() != 10*(176+2) {
+		t.Errorf("Transactions size problem: %v != %v", trs.Size(), 10*(176+2))
 	}
 }
 
 func TestBlocksToBlobs(t *testing.T) {
 	blocks := make([]Block, 10)
-	trans := make([]Transaction, 0, 370)
+	trans := make([]Transaction, 0, 350)
 	for i := 0; i < 10; i++ {
-		trs := CreateRealTransactions(370)
+		trs := CreateRealTransactions(350)
 		trans = append(trans, trs.Ts...)
 		blocks[i].Transactions = &trs
 		blocks[i].Count = 1
@@ -83,7 +83,7 @@ func TestBlocksToBlobs3(t *testing.T) {
 		blocks[i].Count = 1
 		blocks[i].Val = VDF([]byte{byte(i), 2, 3})
 	}
-	trs := CreateRealTransactions(370)
+	trs := CreateRealTransactions(350)
 	trans = append(trans, trs.Ts...)
 	blocks[10].Transactions = &trs
 	blocks[10].Count = 1
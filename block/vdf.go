@@ -8,7 +8,9 @@
 package block
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"errors"
 )
 
 // VDFValue represents the value of Verifiable Delayed Function.
@@ -28,3 +30,168 @@ func ExtendedVDF(data []byte, val VDFValue) VDFValue {
 	appended := append(val, data...)
 	return VDF(appended)
 }
+
+// VDFScheme is an implementation of verifiable delay function used by blocks.
+// Output after zero iterations is the input itself. Verify checks that proof
+// certifies given number of iterations starting from input and returns the output.
+type VDFScheme interface {
+	Name() string
+	NewEvaluator(input VDFValue) VDFEvaluator
+	Verify(input VDFValue, iterations uint64, proof []byte) (VDFValue, bool)
+}
+
+// VDFEvaluator computes VDF incrementally, one iteration per Step.
+// Proof returns proof for the iterations performed so far.
+type VDFEvaluator interface {
+	Step()
+	Iterations() uint64
+	Value() VDFValue
+	Proof() []byte
+}
+
+const (
+	// SHA256VDFName is the name of iterated SHA-256 scheme in genesis configuration
+	SHA256VDFName = "sha256"
+	// WesolowskiVDFName is the name of Wesolowski scheme in genesis configuration
+	WesolowskiVDFName = "wesolowski"
+)
+
+const (
+	// MaxVDFProofSize is the largest proof of any scheme, blocks leave room for it
+	MaxVDFProofSize = 2 * MaxVDFModulusBits / 8
+	// checkpointInterval is the initial number of SHA-256 iterations between checkpoints
+	checkpointInterval = 1024
+	// maxCheckpoints is the number of SHA-256 checkpoints which fit into the proof with the interval
+	maxCheckpoints = (MaxVDFProofSize - 8) / VDFSize
+)
+
+var errUnknownVDF = errors.New("unknown vdf scheme")
+
+// NewVDFScheme returns VDF scheme by its name from genesis configuration.
+// Empty name selects iterated SHA-256, modulus is used by Wesolowski scheme only.
+func NewVDFScheme(name string, modulus []byte) (VDFScheme, error) {
+	switch name {
+	case "", SHA256VDFName:
+		return SHA256VDF{}, nil
+	case WesolowskiVDFName:
+		return NewWesolowskiVDF(modulus)
+	}
+	return nil, errUnknownVDF
+}
+
+// SHA256VDF is iterated SHA-256 chain. Its proof is a list of checkpoints:
+// intermediate values after every interval iterations. Verification replays
+// the segments between checkpoints concurrently, without checkpoints it
+// replays all the iterations serially.
+type SHA256VDF struct{}
+
+// Name of the scheme
+func (SHA256VDF) Name() string {
+	return SHA256VDFName
+}
+
+// NewEvaluator returns evaluator starting from input
+func (SHA256VDF) NewEvaluator(input VDFValue) VDFEvaluator {
+	return &sha256Evaluator{value: input, interval: checkpointInterval}
+}
+
+// Verify replays the iterations, segments between checkpoints are verified in parallel
+func (SHA256VDF) Verify(input VDFValue, iterations uint64, proof []byte) (VDFValue, bool) {
+	if len(proof) == 0 {
+		return iterateVDF(input, iterations), true
+	}
+	interval, checkpoints, ok := parseCheckpoints(proof)
+	if !ok || iterations == 0 || uint64(len(checkpoints)) != (iterations-1)/interval {
+		return nil, false
+	}
+	res := make(chan bool, len(checkpoints))
+	start := input
+	for _, checkpoint := range checkpoints {
+		go func(start, checkpoint VDFValue) {
+			res <- bytes.Equal(iterateVDF(start, interval), checkpoint)
+		}(start, checkpoint)
+		start = checkpoint
+	}
+	value := iterateVDF(start, iterations-uint64(len(checkpoints))*interval)
+	valid := true
+	for range checkpoints {
+		valid = <-res && valid
+	}
+	return value, valid
+}
+
+// iterateVDF applies VDF to the value given number of times
+func iterateVDF(value VDFValue, iterations uint64) VDFValue {
+	for i := uint64(0); i < iterations; i++ {
+		value = VDF(value)
+	}
+	return value
+}
+
+// parseCheckpoints reads interval and checkpoints written by sha256Evaluator.Proof
+func parseCheckpoints(proof []byte) (uint64, []VDFValue, bool) {
+	if len(proof) < 8 || (len(proof)-8)%VDFSize != 0 {
+		return 0, nil, false
+	}
+	interval := uint64(ByteToInt64(proof, 0))
+	if interval == 0 {
+		return 0, nil, false
+	}
+	checkpoints := make([]VDFValue, 0, (len(proof)-8)/VDFSize)
+	for start := 8; start < len(proof); start += VDFSize {
+		checkpoints = append(checkpoints, proof[start:start+VDFSize])
+	}
+	return interval, checkpoints, true
+}
+
+// sha256Evaluator records value after every interval iterations. When there
+// are too many checkpoints to fit into a block, the interval is doubled and
+// every other checkpoint is dropped.
+type sha256Evaluator struct {
+	value       VDFValue
+	iterations  uint64
+	interval    uint64
+	checkpoints []VDFValue
+}
+
+func (e *sha256Evaluator) Step() {
+	e.value = VDF(e.value)
+	e.iterations++
+	if e.iterations%e.interval != 0 {
+		return
+	}
+	e.checkpoints = append(e.checkpoints, e.value)
+	if len(e.checkpoints) > maxCheckpoints {
+		for i := 1; i < len(e.checkpoints); i += 2 {
+			e.checkpoints[i/2] = e.checkpoints[i]
+		}
+		e.checkpoints = e.checkpoints[:len(e.checkpoints)/2]
+		e.interval *= 2
+	}
+}
+
+func (e *sha256Evaluator) Iterations() uint64 {
+	return e.iterations
+}
+
+func (e *sha256Evaluator) Value() VDFValue {
+	return e.value
+}
+
+// Proof returns interval and checkpoints before the final value,
+// or nil when there are no checkpoints
+func (e *sha256Evaluator) Proof() []byte {
+	checkpoints := e.checkpoints
+	if len(checkpoints) != 0 && e.iterations%e.interval == 0 {
+		checkpoints = checkpoints[:len(checkpoints)-1]
+	}
+	if len(checkpoints) == 0 {
+		return nil
+	}
+	proof := make([]byte, 0, 8+len(checkpoints)*VDFSize)
+	proof = append(proof, uint64ToBytes(e.interval)...)
+	for _, checkpoint := range checkpoints {
+		proof = append(proof, checkpoint...)
+	}
+	return proof
+}
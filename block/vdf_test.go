@@ -1,6 +1,7 @@
 package block
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 	// . "github.com/Ansiblock/Ansiblock/block"
@@ -47,3 +48,97 @@ func BenchmarkExtendedVDF(b *testing.B) {
 		hash = ExtendedVDF(data, hash)
 	}
 }
+
+func TestSHA256VDFScheme(t *testing.T) {
+	input := VDF([]byte("scheme"))
+	evaluator := SHA256VDF{}.NewEvaluator(input)
+	for i := 0; i < 10; i++ {
+		evaluator.Step()
+	}
+	value, ok := SHA256VDF{}.Verify(input, evaluator.Iterations(), evaluator.Proof())
+	if !ok || !bytes.Equal(value, evaluator.Value()) || evaluator.Iterations() != 10 || evaluator.Proof() != nil {
+		t.Errorf("SHA256 scheme should verify its own evaluation without checkpoints")
+	}
+	if _, ok = (SHA256VDF{}).Verify(input, 10, []byte{1}); ok {
+		t.Errorf("SHA256 scheme should not accept malformed checkpoints")
+	}
+}
+
+func TestSHA256VDFCheckpoints(t *testing.T) {
+	input := VDF([]byte("checkpoints"))
+	for _, iterations := range []uint64{checkpointInterval, checkpointInterval + 1, 5*checkpointInterval - 1, 40 * checkpointInterval} {
+		evaluator := SHA256VDF{}.NewEvaluator(input)
+		for i := uint64(0); i < iterations; i++ {
+			evaluator.Step()
+		}
+		proof := evaluator.Proof()
+		value, ok := SHA256VDF{}.Verify(input, iterations, proof)
+		if !ok || !bytes.Equal(value, iterateVDF(input, iterations)) || len(proof) > MaxVDFProofSize {
+			t.Fatalf("checkpoints of %v iterations should verify", iterations)
+		}
+		if _, ok = (SHA256VDF{}).Verify(input, iterations+checkpointInterval*8, proof); ok && proof != nil {
+			t.Errorf("checkpoints should not verify other number of iterations")
+		}
+		if proof == nil {
+			continue
+		}
+		tampered := append([]byte(nil), proof...)
+		tampered[8] ^= 1
+		if _, ok = (SHA256VDF{}).Verify(input, iterations, tampered); ok {
+			t.Errorf("tampered checkpoint should fail")
+		}
+	}
+}
+
+func TestNewVDFScheme(t *testing.T) {
+	if scheme, err := NewVDFScheme("", nil); err != nil || scheme.Name() != SHA256VDFName {
+		t.Errorf("default scheme should be SHA256, got %v %v", scheme, err)
+	}
+	if _, err := NewVDFScheme("unknown", nil); err != errUnknownVDF {
+		t.Errorf("unknown scheme should fail, got %v", err)
+	}
+	if _, err := NewVDFScheme(WesolowskiVDFName, []byte{1, 2, 3}); err != errVDFModulus {
+		t.Errorf("small modulus should fail, got %v", err)
+	}
+}
+
+// benchmarkVDFIterations is the length of the chain in generation and verification benchmarks
+const benchmarkVDFIterations = 1 << 16
+
+func reportVDFThroughput(b *testing.B) {
+	b.ReportMetric(float64(benchmarkVDFIterations)*float64(b.N)/b.Elapsed().Seconds(), "iterations/s")
+}
+
+func benchmarkSHA256VDFProof(input VDFValue) []byte {
+	evaluator := SHA256VDF{}.NewEvaluator(input)
+	for i := 0; i < benchmarkVDFIterations; i++ {
+		evaluator.Step()
+	}
+	return evaluator.Proof()
+}
+
+func BenchmarkSHA256VDFGenerate(b *testing.B) {
+	input := VDF([]byte("benchmark"))
+	for n := 0; n < b.N; n++ {
+		benchmarkSHA256VDFProof(input)
+	}
+	reportVDFThroughput(b)
+}
+
+func BenchmarkSHA256VDFVerifySerial(b *testing.B) {
+	input := VDF([]byte("benchmark"))
+	for n := 0; n < b.N; n++ {
+		SHA256VDF{}.Verify(input, benchmarkVDFIterations, nil)
+	}
+	reportVDFThroughput(b)
+}
+
+func BenchmarkSHA256VDFVerifyCheckpoints(b *testing.B) {
+	input := VDF([]byte("benchmark"))
+	proof := benchmarkSHA256VDFProof(input)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		SHA256VDF{}.Verify(input, benchmarkVDFIterations, proof)
+	}
+	reportVDFThroughput(b)
+}
@@ -0,0 +1,68 @@
+package block
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// VoteSize is the size of serialized vote: number, block hash, voter and signature
+const VoteSize = 8 + sha256.Size + ed25519.PublicKeySize + ed25519.SignatureSize
+
+var errInvalidVote = errors.New("invalid vote data")
+
+// Vote is the attestation of a signer that it verified and applied the block
+// with given number and hash, Val is the block hash
+type Vote struct {
+	Number    uint64
+	Val       VDFValue
+	Voter     ed25519.PublicKey
+	Signature []byte
+}
+
+// NewVote creates vote of the key pair owner for the block
+func NewVote(voter *KeyPair, number uint64, val VDFValue) Vote {
+	vote := Vote{Number: number, Val: val, Voter: voter.Public}
+	vote.Signature = ed25519.Sign(voter.Private, vote.signData())
+	return vote
+}
+
+// signData returns data covered by the signature: block number followed by block hash
+func (v *Vote) signData() []byte {
+	data := make([]byte, 8+len(v.Val))
+	binary.BigEndian.PutUint64(data, v.Number)
+	copy(data[8:], v.Val)
+	return data
+}
+
+// Verify verifies signature of the vote
+func (v *Vote) Verify() bool {
+	return len(v.Voter) == ed25519.PublicKeySize && len(v.Val) == sha256.Size &&
+		ed25519.Verify(v.Voter, v.signData(), v.Signature)
+}
+
+// Serialize converts vote to bytes
+func (v *Vote) Serialize() []byte {
+	data := make([]byte, 0, VoteSize)
+	data = append(data, v.signData()...)
+	data = append(data, v.Voter...)
+	return append(data, v.Signature...)
+}
+
+// DeserializeVote converts bytes to vote
+func DeserializeVote(data []byte) (Vote, error) {
+	var vote Vote
+	if len(data) < VoteSize {
+		return vote, errInvalidVote
+	}
+	vote.Number = binary.BigEndian.Uint64(data)
+	start := 8
+	vote.Val = append(VDFValue(nil), data[start:start+sha256.Size]...)
+	start += sha256.Size
+	vote.Voter = append(ed25519.PublicKey(nil), data[start:start+ed25519.PublicKeySize]...)
+	start += ed25519.PublicKeySize
+	vote.Signature = append([]byte(nil), data[start:start+ed25519.SignatureSize]...)
+	return vote, nil
+}
@@ -0,0 +1,29 @@
+package block
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestVote(t *testing.T) {
+	kp := NewKeyPair()
+	vote := NewVote(&kp, 7, VDF([]byte("vote")))
+	if !vote.Verify() {
+		t.Errorf("vote should be valid")
+	}
+	data := vote.Serialize()
+	if len(data) != VoteSize {
+		t.Errorf("unexpected size of serialized vote %v", len(data))
+	}
+	res, err := DeserializeVote(data)
+	if err != nil || res.Number != 7 || !bytes.Equal(res.Val, vote.Val) || !bytes.Equal(res.Voter, kp.Public) || !res.Verify() {
+		t.Errorf("Serialize/deserialize of vote problem: %v", err)
+	}
+	res.Number = 8
+	if res.Verify() {
+		t.Errorf("block number should be covered by the signature")
+	}
+	if _, err = DeserializeVote(data[:VoteSize-1]); err != errInvalidVote {
+		t.Errorf("expected errInvalidVote, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package block
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+const (
+	// MaxVDFModulusBits is the largest RSA modulus supported by Wesolowski VDF
+	MaxVDFModulusBits = 2048
+	// minVDFModulusBits is the smallest RSA modulus accepted by Wesolowski VDF
+	minVDFModulusBits = 512
+	// primeBits is the size of Fiat-Shamir challenge prime
+	primeBits = 128
+)
+
+var errVDFModulus = errors.New("vdf modulus should be odd and from 512 to 2048 bits")
+
+// WesolowskiVDF is Wesolowski's verifiable delay function over RSA group.
+// Input is hashed to group element x, output is y = x^(2^T) mod N and the
+// proof is pi = x^floor(2^T/l) where l is a prime derived from x, y and T.
+// Verifier checks pi^l * x^(2^T mod l) = y, which costs two exponentiations
+// with small exponents regardless of T. Elements are taken modulo ±1, so
+// that the proof can't be changed by negating it.
+// Factorization of the modulus must be unknown to everyone, so it is generated
+// once for the genesis and the factors are discarded.
+type WesolowskiVDF struct {
+	modulus *big.Int
+	size    int
+}
+
+// NewWesolowskiVDF returns Wesolowski VDF over RSA group with given modulus
+func NewWesolowskiVDF(modulus []byte) (*WesolowskiVDF, error) {
+	n := new(big.Int).SetBytes(modulus)
+	if n.BitLen() < minVDFModulusBits || n.BitLen() > MaxVDFModulusBits || n.Bit(0) == 0 {
+		return nil, errVDFModulus
+	}
+	return &WesolowskiVDF{modulus: n, size: (n.BitLen() + 7) / 8}, nil
+}
+
+// GenerateVDFModulus returns RSA modulus of given bits for Wesolowski VDF.
+// Its factors are not kept anywhere.
+func GenerateVDFModulus(bits int) ([]byte, error) {
+	for {
+		p, err := rand.Prime(rand.Reader, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := rand.Prime(rand.Reader, bits-bits/2)
+		if err != nil {
+			return nil, err
+		}
+		n := new(big.Int).Mul(p, q)
+		if n.BitLen() == bits {
+			return n.Bytes(), nil
+		}
+	}
+}
+
+// Name of the scheme
+func (w *WesolowskiVDF) Name() string {
+	return WesolowskiVDFName
+}
+
+// NewEvaluator returns evaluator starting from input
+func (w *WesolowskiVDF) NewEvaluator(input VDFValue) VDFEvaluator {
+	x := w.hashToGroup(input)
+	return &wesolowskiEvaluator{vdf: w, input: input, x: x, y: new(big.Int).Set(x)}
+}
+
+// Verify checks the proof and returns output of the VDF
+func (w *WesolowskiVDF) Verify(input VDFValue, iterations uint64, proof []byte) (VDFValue, bool) {
+	if iterations == 0 {
+		return input, len(proof) == 0
+	}
+	if len(proof) != 2*w.size {
+		return nil, false
+	}
+	y := new(big.Int).SetBytes(proof[:w.size])
+	pi := new(big.Int).SetBytes(proof[w.size:])
+	if !w.element(y) || !w.element(pi) {
+		return nil, false
+	}
+	x := w.hashToGroup(input)
+	l := w.hashToPrime(x, y, iterations)
+	r := new(big.Int).Exp(big.NewInt(2), new(big.Int).SetUint64(iterations), l)
+	res := new(big.Int).Exp(pi, l, w.modulus)
+	res.Mul(res, new(big.Int).Exp(x, r, w.modulus))
+	res.Mod(res, w.modulus)
+	if w.normalize(res).Cmp(y) != 0 {
+		return nil, false
+	}
+	return w.output(y), true
+}
+
+// normalize returns representative of {v, -v}: the smaller one
+func (w *WesolowskiVDF) normalize(v *big.Int) *big.Int {
+	neg := new(big.Int).Sub(w.modulus, v)
+	if neg.Cmp(v) < 0 {
+		return neg
+	}
+	return v
+}
+
+// element checks that v is normalized non-zero element of the group
+func (w *WesolowskiVDF) element(v *big.Int) bool {
+	return v.Sign() > 0 && v.Cmp(w.modulus) < 0 && w.normalize(v) == v
+}
+
+// bytes returns fixed size encoding of the element
+func (w *WesolowskiVDF) bytes(v *big.Int) []byte {
+	return v.FillBytes(make([]byte, w.size))
+}
+
+// output returns VDF value for the group element
+func (w *WesolowskiVDF) output(y *big.Int) VDFValue {
+	return VDF(w.bytes(y))
+}
+
+// hashToGroup maps the input to normalized group element
+func (w *WesolowskiVDF) hashToGroup(input VDFValue) *big.Int {
+	data := make([]byte, 0, w.size+sha256.Size)
+	for i := byte(0); len(data) < w.size; i++ {
+		h := sha256.Sum256(append([]byte{i}, input...))
+		data = append(data, h[:]...)
+	}
+	x := new(big.Int).SetBytes(data[:w.size])
+	x.Mod(x, w.modulus)
+	if x.Cmp(big.NewInt(1)) <= 0 {
+		x.SetInt64(2)
+	}
+	return w.normalize(x)
+}
+
+// hashToPrime returns Fiat-Shamir challenge: prime derived from x, y and number of iterations
+func (w *WesolowskiVDF) hashToPrime(x, y *big.Int, iterations uint64) *big.Int {
+	prefix := append(w.bytes(x), w.bytes(y)...)
+	prefix = append(prefix, uint64ToBytes(iterations)...)
+	var counter [8]byte
+	l := new(big.Int)
+	for i := uint64(0); ; i++ {
+		binary.BigEndian.PutUint64(counter[:], i)
+		h := sha256.Sum256(append(prefix, counter[:]...))
+		l.SetBytes(h[:primeBits/8])
+		l.SetBit(l, primeBits-1, 1)
+		l.SetBit(l, 0, 1)
+		if l.ProbablyPrime(20) {
+			return l
+		}
+	}
+}
+
+// prove returns x^floor(2^T/l) computing bits of the quotient by long division
+func (w *WesolowskiVDF) prove(x, l *big.Int, iterations uint64) *big.Int {
+	pi := big.NewInt(1)
+	r := big.NewInt(1)
+	for i := uint64(0); i < iterations; i++ {
+		r.Lsh(r, 1)
+		pi.Mul(pi, pi)
+		if r.Cmp(l) >= 0 {
+			r.Sub(r, l)
+			pi.Mul(pi, x)
+		}
+		pi.Mod(pi, w.modulus)
+	}
+	return w.normalize(pi)
+}
+
+type wesolowskiEvaluator struct {
+	vdf        *WesolowskiVDF
+	input      VDFValue
+	x, y       *big.Int
+	iterations uint64
+}
+
+func (e *wesolowskiEvaluator) Step() {
+	e.y.Mul(e.y, e.y)
+	e.y.Mod(e.y, e.vdf.modulus)
+	e.iterations++
+}
+
+func (e *wesolowskiEvaluator) Iterations() uint64 {
+	return e.iterations
+}
+
+func (e *wesolowskiEvaluator) Value() VDFValue {
+	if e.iterations == 0 {
+		return e.input
+	}
+	return e.vdf.output(e.vdf.normalize(new(big.Int).Set(e.y)))
+}
+
+// Proof costs as many group operations as the evaluation itself
+func (e *wesolowskiEvaluator) Proof() []byte {
+	if e.iterations == 0 {
+		return nil
+	}
+	y := e.vdf.normalize(new(big.Int).Set(e.y))
+	l := e.vdf.hashToPrime(e.x, y, e.iterations)
+	pi := e.vdf.prove(e.x, l, e.iterations)
+	return append(e.vdf.bytes(y), e.vdf.bytes(pi)...)
+}
@@ -0,0 +1,113 @@
+package block
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func testWesolowski(t testing.TB) *WesolowskiVDF {
+	modulus, err := GenerateVDFModulus(minVDFModulusBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWesolowskiVDF(modulus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestWesolowskiVDF(t *testing.T) {
+	w := testWesolowski(t)
+	input := VDF([]byte("wesolowski"))
+	evaluator := w.NewEvaluator(input)
+	if !bytes.Equal(evaluator.Value(), input) || evaluator.Proof() != nil {
+		t.Errorf("evaluator without iterations should return input")
+	}
+	for i := 0; i < 1000; i++ {
+		evaluator.Step()
+	}
+	proof := evaluator.Proof()
+	value, ok := w.Verify(input, 1000, proof)
+	if !ok || !bytes.Equal(value, evaluator.Value()) || len(proof) != 2*w.size {
+		t.Fatalf("proof should verify evaluation")
+	}
+	if _, ok = w.Verify(input, 999, proof); ok {
+		t.Errorf("proof should not verify other number of iterations")
+	}
+	if _, ok = w.Verify(VDF(input), 1000, proof); ok {
+		t.Errorf("proof should not verify other input")
+	}
+	tampered := append([]byte(nil), proof...)
+	tampered[len(tampered)-1] ^= 1
+	if _, ok = w.Verify(input, 1000, tampered); ok {
+		t.Errorf("tampered proof should fail")
+	}
+	pi := new(big.Int).SetBytes(proof[w.size:])
+	negated := append(w.bytes(new(big.Int).SetBytes(proof[:w.size])), w.bytes(pi.Sub(w.modulus, pi))...)
+	if _, ok = w.Verify(input, 1000, negated); ok {
+		t.Errorf("negated proof should fail")
+	}
+}
+
+func TestWesolowskiBlocks(t *testing.T) {
+	w := testWesolowski(t)
+	prev := NewEmpty(VDF([]byte("blocks")), 0, 0)
+	empty := NextBlock(w, prev.Val, prev.Number, 100, &Transactions{})
+	trans := CreateRealTransactions(3)
+	full := NextBlock(w, empty.Val, empty.Number, 50, &trans)
+	if !empty.Verify(w, prev.Val) || !full.Verify(w, empty.Val) || empty.VDFProof == nil {
+		t.Fatalf("blocks with proofs should verify")
+	}
+	if empty.Verify(SHA256VDF{}, prev.Val) {
+		t.Errorf("block should be verified by the scheme of the chain only")
+	}
+	blocks := BlobsToBlocks(BlocksToBlobs([]Block{empty, full}))
+	if len(blocks) != 2 || !bytes.Equal(blocks[1].VDFProof, full.VDFProof) || !blocks[1].Verify(w, empty.Val) {
+		t.Fatalf("proof should be carried by blobs")
+	}
+	blocks[1].VDFProof = empty.VDFProof
+	if blocks[1].Verify(w, empty.Val) {
+		t.Errorf("block with other proof should fail")
+	}
+}
+
+func TestWesolowskiGeneratorWithTick(t *testing.T) {
+	w := testWesolowski(t)
+	transactionsReceiver := make(chan *Transactions, 1)
+	previousValue := VDF([]byte("hello"))
+	out := GeneratorWithTick(transactionsReceiver, w, previousValue, 0, 10*time.Millisecond)
+	trans := CreateRealTransactions(3)
+	transactionsReceiver <- &trans
+	for i := 0; i < 5; i++ {
+		block := <-out
+		if !block.Verify(w, previousValue) || block.Number != uint64(i)+1 {
+			t.Fatalf("block %v should carry proof of its iterations", i+1)
+		}
+		previousValue = block.Val
+	}
+}
+
+func BenchmarkWesolowskiEvaluate(b *testing.B) {
+	evaluator := testWesolowski(b).NewEvaluator(VDF([]byte("benchmark")))
+	for n := 0; n < b.N; n++ {
+		evaluator.Step()
+	}
+	evaluator.Proof()
+}
+
+func BenchmarkWesolowskiVerify(b *testing.B) {
+	w := testWesolowski(b)
+	input := VDF([]byte("benchmark"))
+	evaluator := w.NewEvaluator(input)
+	for i := 0; i < 10000; i++ {
+		evaluator.Step()
+	}
+	proof := evaluator.Proof()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		w.Verify(input, 10000, proof)
+	}
+}
@@ -0,0 +1,120 @@
+package books
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"golang.org/x/crypto/ed25519"
+)
+
+var (
+	// errAssetNotFound is returned when transaction refers to unknown asset
+	errAssetNotFound = errors.New("asset not found")
+	// errNotMintAuthority is returned when asset is minted by somebody else than its mint authority
+	errNotMintAuthority = errors.New("sender is not mint authority of the asset")
+	// errAssetSupplyOverflow is returned when minting would overflow asset supply
+	errAssetSupplyOverflow = errors.New("asset supply overflow")
+)
+
+// Asset is a user issued token, its tokens are minted by Authority only
+type Asset struct {
+	Authority []byte
+	Supply    int64
+}
+
+// AssetBalanceKey identifies balance of the account in user issued asset
+type AssetBalanceKey struct {
+	Account string
+	Asset   string
+}
+
+func assetBalanceKey(account []byte, asset []byte) AssetBalanceKey {
+	return AssetBalanceKey{Account: string(account), Asset: string(asset)}
+}
+
+// checkAsset checks that asset transaction can be applied before fee is withdrawn.
+// Must be called under bm.lock
+func (bm *Accounts) checkAsset(tran *block.Transaction) error {
+	if tran.Type != block.TypeMintAsset && tran.Type != block.TypeAssetTransfer {
+		return nil
+	}
+	asset, ok := bm.assets[string(tran.Asset)]
+	if !ok {
+		return errAssetNotFound
+	}
+	if tran.Type == block.TypeAssetTransfer {
+		if bm.assetBalances[assetBalanceKey(tran.From, tran.Asset)] < tran.Token {
+			return errInsufficientFunds
+		}
+		return nil
+	}
+	if !bytes.Equal(tran.From, asset.Authority) {
+		return errNotMintAuthority
+	}
+	if asset.Supply+tran.Token < asset.Supply {
+		return errAssetSupplyOverflow
+	}
+	return nil
+}
+
+// applyAssetWithdraw issues or mints the asset and withdraws transferred asset
+// tokens from the sender, the fee is paid in native tokens. TypeCreateAsset uses
+// To as the mint authority and Token as the supply. Must be called under bm.lock
+func (bm *Accounts) applyAssetWithdraw(tran *block.Transaction) {
+	switch tran.Type {
+	case block.TypeCreateAsset:
+		id := tran.AssetID()
+		bm.assets[string(id)] = Asset{Authority: tran.To, Supply: tran.Token}
+		bm.assetBalances[assetBalanceKey(tran.To, id)] += tran.Token
+	case block.TypeMintAsset:
+		asset := bm.assets[string(tran.Asset)]
+		asset.Supply += tran.Token
+		bm.assets[string(tran.Asset)] = asset
+		bm.assetBalances[assetBalanceKey(tran.To, tran.Asset)] += tran.Token
+	case block.TypeAssetTransfer:
+		bm.assetBalances[assetBalanceKey(tran.From, tran.Asset)] -= tran.Token
+	}
+}
+
+// applyAssetDeposit deposits transferred asset tokens to the recipient.
+// Must be called under bm.lock
+func (bm *Accounts) applyAssetDeposit(tran *block.Transaction) {
+	if tran.Type == block.TypeAssetTransfer {
+		bm.assetBalances[assetBalanceKey(tran.To, tran.Asset)] += tran.Token
+	}
+}
+
+// AssetBalance returns balance of the account in the asset, empty asset means native token
+func (bm *Accounts) AssetBalance(publicKey ed25519.PublicKey, asset []byte) int64 {
+	if len(asset) == 0 {
+		return bm.Balance(publicKey)
+	}
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	return bm.assetBalances[assetBalanceKey(publicKey, asset)]
+}
+
+// AssetOf returns the asset with given identifier
+func (bm *Accounts) AssetOf(id []byte) (Asset, bool) {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	asset, ok := bm.assets[string(id)]
+	return asset, ok
+}
+
+func copyAssets(m map[string]Asset) map[string]Asset {
+	res := make(map[string]Asset, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
+func copyAssetBalances(m map[AssetBalanceKey]int64) map[AssetBalanceKey]int64 {
+	res := make(map[AssetBalanceKey]int64, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
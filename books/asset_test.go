@@ -0,0 +1,64 @@
+package books
+
+import (
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+func TestProcessTransactionsAsset(t *testing.T) {
+	bm := NewBookManager()
+	issuer := block.NewKeyPair()
+	authority := block.NewKeyPair()
+	user := block.NewKeyPair()
+	bm.CreateAccount(issuer.Public, 10)
+	bm.CreateAccount(authority.Public, 10)
+	bm.CreateAccount(user.Public, 10)
+	vdf := block.VDF([]byte("asset"))
+	bm.AddValidVDFValue(vdf)
+
+	create := block.NewCreateAsset(&issuer, authority.Public, 1000, 1, vdf)
+	if r := processOne(bm, create); r != ReasonNone || bm.Balance(issuer.Public) != 9 {
+		t.Errorf("asset should be created for native fee, got %v", r)
+	}
+	asset := create.AssetID()
+	if a, ok := bm.AssetOf(asset); !ok || a.Supply != 1000 || bm.AssetBalance(authority.Public, asset) != 1000 {
+		t.Errorf("supply should be credited to mint authority, got %v", a)
+	}
+	if bm.AssetBalance(authority.Public, nil) != 10 {
+		t.Errorf("empty asset should mean native token")
+	}
+
+	transfer := block.NewAssetTransfer(&authority, asset, user.Public, 300, 1, vdf)
+	if r := processOne(bm, transfer); r != ReasonNone {
+		t.Errorf("asset transfer should be applied, got %v", r)
+	}
+	if bm.AssetBalance(authority.Public, asset) != 700 || bm.AssetBalance(user.Public, asset) != 300 ||
+		bm.Balance(authority.Public) != 9 || bm.Balance(user.Public) != 10 {
+		t.Errorf("asset transfer should move asset tokens and pay native fee")
+	}
+	if r := processOne(bm, block.NewAssetTransfer(&user, asset, authority.Public, 301, 0, vdf)); r != ReasonInsufficientFunds {
+		t.Errorf("asset transfer above asset balance should be rejected, got %v", r)
+	}
+	unknown := make([]byte, block.AssetIDSize)
+	if r := processOne(bm, block.NewAssetTransfer(&user, unknown, authority.Public, 0, 0, vdf)); r != ReasonAssetNotFound {
+		t.Errorf("transfer of unknown asset should be rejected, got %v", r)
+	}
+
+	if r := processOne(bm, block.NewMintAsset(&user, asset, user.Public, 10, 0, vdf)); r != ReasonNotMintAuthority {
+		t.Errorf("only mint authority can mint, got %v", r)
+	}
+	if r := processOne(bm, block.NewMintAsset(&authority, asset, user.Public, 50, 0, vdf)); r != ReasonNone ||
+		bm.AssetBalance(user.Public, asset) != 350 {
+		t.Errorf("mint authority should mint, got %v", r)
+	}
+	if a, _ := bm.AssetOf(asset); a.Supply != 1050 {
+		t.Errorf("minting should increase supply, got %v", a.Supply)
+	}
+
+	restored := NewBookManager()
+	restored.ImportState(bm.ExportState())
+	if !restored.Equals(bm) || restored.AssetBalance(user.Public, asset) != 350 {
+		t.Errorf("state should keep asset balances")
+	}
+}
@@ -27,61 +27,203 @@ var (
 
 	// ErrNegativeTokens is returned when requested a debit or credit of negative tokens.
 	errNegativeTokens = errors.New("debit or credit of negative tokens")
+
+	// errInvalidNonce is returned when transaction nonce is not the next nonce of the sender.
+	errInvalidNonce = errors.New("invalid nonce")
 )
 
 // Accounts stores account balances and ledger info.
 type Accounts struct {
 	balances          map[string]int64
+	nonces            map[string]uint64
+	multisig          map[string]Multisig
+	escrows           map[string]Escrow
+	assets            map[string]Asset
+	assetBalances     map[AssetBalanceKey]int64
+	feeCollector      []byte
+	pendingFees       int64
+	feesEarned        map[string]int64
+	blockFees         map[uint64]BlockFees
 	lock              sync.Mutex
 	ledger            *Ledger
 	transactionsTotal uint64
 	blocksTotal       uint64
+	vdfCount          uint64
+	store             Store
+	receipts          *receipts
+	strict            bool
+	vdfScheme         block.VDFScheme
+	history           *history
+	schedule          *LeaderSchedule
+	epochSeeds        map[uint64]block.VDFValue
+	epochStakes       map[uint64]map[string]int64
+	finality          *Finality
+	stakes            map[StakeKey]int64
+	unbonds           map[string]Unbond
+	bindings          map[string]string
+	unstakeCooldown   uint64
+	slashed           map[string]bool
+	burned            int64
+	proofTree         *stateTree
 }
 
 // NewBookManager creates new Accounts object
 func NewBookManager() *Accounts {
 	bm := new(Accounts)
 	bm.balances = make(map[string]int64)
+	bm.nonces = make(map[string]uint64)
+	bm.multisig = make(map[string]Multisig)
+	bm.escrows = make(map[string]Escrow)
+	bm.assets = make(map[string]Asset)
+	bm.assetBalances = make(map[AssetBalanceKey]int64)
+	bm.feesEarned = make(map[string]int64)
+	bm.blockFees = make(map[uint64]BlockFees)
+	bm.epochSeeds = make(map[uint64]block.VDFValue)
+	bm.epochStakes = make(map[uint64]map[string]int64)
+	bm.stakes = make(map[StakeKey]int64)
+	bm.unbonds = make(map[string]Unbond)
+	bm.bindings = make(map[string]string)
+	bm.unstakeCooldown = DefaultUnstakeCooldown
+	bm.slashed = make(map[string]bool)
+	bm.vdfScheme = block.SHA256VDF{}
 	bm.ledger = newLedger()
+	bm.receipts = newReceipts()
 	bm.transactionsTotal = 0
 	bm.blocksTotal = 0
 	return bm
 }
 
+// negativeTokens returns true if transaction debits or credits negative number of tokens
+func negativeTokens(tran *block.Transaction) bool {
+	if tran.Amount() < 0 {
+		return true
+	}
+	for _, o := range tran.Outputs {
+		if o.Token < 0 {
+			return true
+		}
+	}
+	return tran.IsAssetTransaction() && tran.Token < 0
+}
+
+// applyTransactionWithdraw withdraws tokens from the sender and applies the sender side of the transaction
 func (bm *Accounts) applyTransactionWithdraw(tran *block.Transaction) error {
-	if tran.Token < 0 {
+	if negativeTokens(tran) {
 		return errNegativeTokens
 	}
 	bm.lock.Lock()
 	defer bm.lock.Unlock()
 	fromBalance, ok := bm.balances[string(tran.From)]
-	if !ok {
+	// reporter of slash does not need an account
+	if !ok && tran.Type != block.TypeSlash {
 		return errAccountNotFound
 	}
-	err := bm.ledger.addSignature(tran.Signature, tran.ValidVDFValue)
+	if err := bm.checkWithdraw(tran); err != nil {
+		return err
+	}
+	balance, nonce, err := bm.withdraw(tran, fromBalance, bm.nonces[string(tran.From)])
 	if err != nil {
 		return err
 	}
-	if fromBalance < tran.Token {
-		bm.ledger.removeSignature(tran.Signature, tran.ValidVDFValue)
-		return errInsufficientFunds
+	bm.balances[string(tran.From)] = balance
+	if tran.Nonce != 0 {
+		bm.nonces[string(tran.From)] = nonce
+	}
+	// fee is pending until the block is processed, see CollectFees
+	bm.pendingFees += tran.Fee
+	switch tran.Type {
+	case block.TypeRegisterMultisig:
+		bm.registerMultisig(tran)
+	case block.TypeEscrow, block.TypeEscrowRelease, block.TypeEscrowCancel:
+		bm.applyEscrow(tran)
+	case block.TypeCreateAsset, block.TypeMintAsset, block.TypeAssetTransfer:
+		bm.applyAssetWithdraw(tran)
+	case block.TypeUnstake:
+		bm.applyUnstake(tran)
+	case block.TypeBindNode:
+		bm.applyBindNode(tran)
+	case block.TypeSlash:
+		bm.applySlash(tran)
 	}
-	bm.balances[string(tran.From)] -= tran.Token
 	atomic.AddUint64(&bm.transactionsTotal, 1)
 	return nil
 }
 
+// checkWithdraw checks that the sender may issue the transaction, it only reads the state
+func (bm *Accounts) checkWithdraw(tran *block.Transaction) error {
+	if err := bm.authorize(tran); err != nil {
+		return err
+	}
+	if err := bm.checkEscrow(tran); err != nil {
+		return err
+	}
+	if err := bm.checkStake(tran); err != nil {
+		return err
+	}
+	if err := bm.checkSlash(tran); err != nil {
+		return err
+	}
+	return bm.checkAsset(tran)
+}
+
+// withdraw checks replay protection and balance of the sender. Transactions with
+// nonce must carry the next nonce of the sender and are not checked against the
+// vdf window of the ledger. It returns new balance and nonce of the sender, the
+// state of the account is not changed.
+func (bm *Accounts) withdraw(tran *block.Transaction, balance int64, nonce uint64) (int64, uint64, error) {
+	amount := tran.Amount()
+	if tran.Nonce != 0 {
+		if tran.Nonce != nonce+1 {
+			return balance, nonce, errInvalidNonce
+		}
+		if balance < amount {
+			return balance, nonce, errInsufficientFunds
+		}
+		return balance - amount, tran.Nonce, nil
+	}
+	err := bm.ledger.addSignature(tran.ReplayKey(), tran.ValidVDFValue)
+	if err != nil {
+		return balance, nonce, err
+	}
+	if balance < amount {
+		bm.ledger.removeSignature(tran.ReplayKey(), tran.ValidVDFValue)
+		return balance, nonce, errInsufficientFunds
+	}
+	return balance - amount, nonce, nil
+}
+
 func (bm *Accounts) applyTransactionDeposit(tran *block.Transaction) {
 	bm.lock.Lock()
 	defer bm.lock.Unlock()
-	bm.balances[string(tran.To)] += tran.Token - tran.Fee
+	switch tran.Type {
+	case block.TypeBatchTransfer:
+		for _, o := range tran.Outputs {
+			bm.balances[string(o.To)] += o.Token
+		}
+	case block.TypeTransfer:
+		bm.balances[string(tran.To)] += tran.Token - tran.Fee
+	case block.TypeAssetTransfer:
+		bm.applyAssetDeposit(tran)
+	case block.TypeStake:
+		bm.applyStakeDeposit(tran)
+	}
 }
 
+// processTransactionsWithdraws applies withdraws in order of transactions, large
+// batches of transfers are applied by several threads, see parallel.go
 func (bm *Accounts) processTransactionsWithdraws(trans block.Transactions) block.Transactions {
 	log.Info(fmt.Sprintf("AccountManager: apply withdraws %v transactions", len(trans.Ts)))
+	if parallelizable(trans.Ts) {
+		return bm.processWithdrawsThreads(trans)
+	}
+	return bm.processWithdrawsSerial(trans)
+}
+
+func (bm *Accounts) processWithdrawsSerial(trans block.Transactions) block.Transactions {
 	res := make([]block.Transaction, 0, len(trans.Ts))
 	for _, tran := range trans.Ts {
 		err := bm.applyTransactionWithdraw(&tran)
+		bm.recordTransaction(&tran, err)
 		if err == nil {
 			res = append(res, tran)
 		} else {
@@ -107,16 +249,141 @@ func (bm *Accounts) ProcessTransactions(trans block.Transactions) block.Transact
 }
 
 // ProcessBlocks process a list of blocks.
+// In strict mode blocks are verified and replayed, see SetStrictVerification.
 // TODO: change copying transactions
 func (bm *Accounts) ProcessBlocks(blocks []block.Block) (err error) {
 	log.Info(fmt.Sprintf("AccountManager: process %v blocks", len(blocks)))
+	var restore func()
+	if bm.strict {
+		restore = bm.saveBatch(blocks)
+	}
 	for _, bl := range blocks {
-		bm.UpdateLastBlock(&bl)
-		bm.ProcessTransactions(*bl.Transactions)
+		err := bm.applyBlock(bl, bm.strict)
+		if err == nil {
+			err = bm.anchorState(&bl, bm.strict)
+		}
+		if err != nil {
+			if restore != nil {
+				restore()
+			}
+			return err
+		}
+		bm.recordBlock(bl)
+	}
+	return bm.Commit()
+}
+
+// applyBlock applies transactions of the block, in strict mode the block is verified
+func (bm *Accounts) applyBlock(bl block.Block, strict bool) error {
+	if strict {
+		if err := bm.verifyBlock(&bl); err != nil {
+			return err
+		}
 	}
+	bm.UpdateLastBlock(&bl)
+	res := bm.ProcessTransactions(*bl.Transactions)
+	if strict && len(res.Ts) != len(bl.Transactions.Ts) {
+		return bm.rejectedTransaction(&bl, res.Ts)
+	}
+	bm.ConfirmTransactions(&res, bl.Number)
+	bm.AdvanceStakes(&res, bl.Number)
+	bm.CollectFees(&res, bl.Number)
 	return nil
 }
 
+// LoadBookManager creates Accounts object from the last state committed to the store.
+// Returned Accounts commits its further changes to the same store.
+func LoadBookManager(store Store) (*Accounts, error) {
+	state, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	bm := NewBookManager()
+	bm.ImportState(state)
+	bm.store = store
+	return bm, nil
+}
+
+// SetStore sets store where Commit saves account state
+func (bm *Accounts) SetStore(store Store) {
+	bm.store = store
+}
+
+// Commit saves current account state to the store, if there is one
+func (bm *Accounts) Commit() error {
+	if bm.store == nil {
+		return nil
+	}
+	return bm.store.Save(bm.ExportState())
+}
+
+// ExportState returns copy of account state which can be persisted
+func (bm *Accounts) ExportState() *State {
+	state := new(State)
+	bm.lock.Lock()
+	state.Balances = make(map[string]int64, len(bm.balances))
+	for k, v := range bm.balances {
+		state.Balances[k] = v
+	}
+	state.Nonces = make(map[string]uint64, len(bm.nonces))
+	for k, v := range bm.nonces {
+		state.Nonces[k] = v
+	}
+	state.Multisig = copyMultisig(bm.multisig)
+	state.Escrows = copyEscrows(bm.escrows)
+	state.Assets = copyAssets(bm.assets)
+	state.AssetBalances = copyAssetBalances(bm.assetBalances)
+	state.PendingFees = bm.pendingFees
+	state.FeesEarned = copyFeesEarned(bm.feesEarned)
+	state.BlockFees = copyBlockFees(bm.blockFees)
+	state.EpochSeeds = copyEpochSeeds(bm.epochSeeds)
+	state.EpochStakes = copyEpochStakes(bm.epochStakes)
+	state.Stakes = copyStakes(bm.stakes)
+	state.Unbonds = copyUnbonds(bm.unbonds)
+	state.Bindings = copyBindings(bm.bindings)
+	state.Slashed = copySlashed(bm.slashed)
+	state.Burned = bm.burned
+	bm.lock.Unlock()
+	state.TransactionsTotal = atomic.LoadUint64(&bm.transactionsTotal)
+	state.BlocksTotal = atomic.LoadUint64(&bm.blocksTotal)
+	state.VDFCount = atomic.LoadUint64(&bm.vdfCount)
+	bm.ledger.exportState(state)
+	return state
+}
+
+// ImportState replaces account state with the given one
+func (bm *Accounts) ImportState(state *State) {
+	bm.lock.Lock()
+	bm.balances = make(map[string]int64, len(state.Balances))
+	for k, v := range state.Balances {
+		bm.balances[k] = v
+	}
+	bm.nonces = make(map[string]uint64, len(state.Nonces))
+	for k, v := range state.Nonces {
+		bm.nonces[k] = v
+	}
+	bm.multisig = copyMultisig(state.Multisig)
+	bm.escrows = copyEscrows(state.Escrows)
+	bm.assets = copyAssets(state.Assets)
+	bm.assetBalances = copyAssetBalances(state.AssetBalances)
+	bm.pendingFees = state.PendingFees
+	bm.feesEarned = copyFeesEarned(state.FeesEarned)
+	bm.blockFees = copyBlockFees(state.BlockFees)
+	bm.epochSeeds = copyEpochSeeds(state.EpochSeeds)
+	bm.epochStakes = copyEpochStakes(state.EpochStakes)
+	bm.stakes = copyStakes(state.Stakes)
+	bm.unbonds = copyUnbonds(state.Unbonds)
+	bm.bindings = copyBindings(state.Bindings)
+	bm.slashed = copySlashed(state.Slashed)
+	bm.burned = state.Burned
+	bm.proofTree = nil
+	bm.lock.Unlock()
+	atomic.StoreUint64(&bm.transactionsTotal, state.TransactionsTotal)
+	atomic.StoreUint64(&bm.blocksTotal, state.BlocksTotal)
+	atomic.StoreUint64(&bm.vdfCount, state.VDFCount)
+	bm.ledger.importState(state)
+}
+
 // CreateAccount creates new account with initial balance.
 // NOTE: Only for testing.
 func (bm *Accounts) CreateAccount(publicKey ed25519.PublicKey, amount int64) {
@@ -134,6 +401,14 @@ func (bm *Accounts) Balance(publicKey ed25519.PublicKey) int64 {
 	return bm.balances[string(publicKey)]
 }
 
+// Nonce returns the last nonce used by account with public key equals publicKey.
+// Next transaction with nonce of the account must carry Nonce()+1.
+func (bm *Accounts) Nonce(publicKey ed25519.PublicKey) uint64 {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	return bm.nonces[string(publicKey)]
+}
+
 // String method prints accounts balances.
 // NOTE: Only for testing.
 func (bm *Accounts) String() string {
@@ -165,6 +440,11 @@ func (bm *Accounts) BlocksTotal() uint64 {
 	return atomic.LoadUint64(&bm.blocksTotal)
 }
 
+// VDFCount returns total number of VDF iterations of processed blocks
+func (bm *Accounts) VDFCount() uint64 {
+	return atomic.LoadUint64(&bm.vdfCount)
+}
+
 // IncreaseBlocksTotal increases Block Count
 func (bm *Accounts) IncreaseBlocksTotal() {
 	atomic.AddUint64(&bm.blocksTotal, 1)
@@ -179,11 +459,13 @@ func (bm *Accounts) LastBlock() *block.Block {
 	return nil
 }
 
-// UpdateLastBlock updates last block in ledger and counts number of blocks
+// UpdateLastBlock updates last block in ledger and counts number of blocks and VDF iterations
 func (bm *Accounts) UpdateLastBlock(bl *block.Block) {
 	bm.ledger.UpdateLastBlock(bl)
 	atomic.AddUint64(&bm.blocksTotal, 1)
+	atomic.AddUint64(&bm.vdfCount, bl.Count)
 	bm.ledger.AddValidVDFValue(bl.Val)
+	bm.recordEpochSeed(bl)
 }
 
 // Clone method returns clone of Accounts struct
@@ -193,16 +475,54 @@ func (bm *Accounts) Clone() *Accounts {
 	for k, v := range bm.balances {
 		clone.balances[k] = v
 	}
+	clone.nonces = make(map[string]uint64)
+	for k, v := range bm.nonces {
+		clone.nonces[k] = v
+	}
+	clone.multisig = copyMultisig(bm.multisig)
+	clone.escrows = copyEscrows(bm.escrows)
+	clone.assets = copyAssets(bm.assets)
+	clone.assetBalances = copyAssetBalances(bm.assetBalances)
+	clone.feeCollector = bm.feeCollector
+	clone.pendingFees = bm.pendingFees
+	clone.feesEarned = copyFeesEarned(bm.feesEarned)
+	clone.blockFees = copyBlockFees(bm.blockFees)
+	clone.schedule = bm.schedule
+	clone.vdfScheme = bm.vdfScheme
+	clone.epochSeeds = copyEpochSeeds(bm.epochSeeds)
+	clone.epochStakes = copyEpochStakes(bm.epochStakes)
+	clone.stakes = copyStakes(bm.stakes)
+	clone.unbonds = copyUnbonds(bm.unbonds)
+	clone.bindings = copyBindings(bm.bindings)
+	clone.unstakeCooldown = bm.unstakeCooldown
+	clone.slashed = copySlashed(bm.slashed)
+	clone.burned = bm.burned
 	clone.ledger = bm.ledger.Clone()
+	clone.receipts = newReceipts()
 	clone.transactionsTotal = bm.transactionsTotal
 	clone.blocksTotal = bm.blocksTotal
+	clone.vdfCount = bm.vdfCount
 	return clone
 }
 
-// Equals compares two Accounts and returns true if they have same accounts with same balances
+// Equals compares two Accounts and returns true if they have same accounts with same balances and nonces
 func (bm *Accounts) Equals(bm2 *Accounts) bool {
 	return bm.transactionsTotal == bm2.transactionsTotal && bm.blocksTotal == bm2.blocksTotal &&
-		bm.ledger.Equals(bm2.ledger) && reflect.DeepEqual(bm.balances, bm2.balances)
+		bm.ledger.Equals(bm2.ledger) && reflect.DeepEqual(bm.balances, bm2.balances) &&
+		len(bm.nonces) == len(bm2.nonces) && (len(bm.nonces) == 0 || reflect.DeepEqual(bm.nonces, bm2.nonces)) &&
+		len(bm.multisig) == len(bm2.multisig) && (len(bm.multisig) == 0 || reflect.DeepEqual(bm.multisig, bm2.multisig)) &&
+		len(bm.escrows) == len(bm2.escrows) && (len(bm.escrows) == 0 || reflect.DeepEqual(bm.escrows, bm2.escrows)) &&
+		len(bm.assets) == len(bm2.assets) && (len(bm.assets) == 0 || reflect.DeepEqual(bm.assets, bm2.assets)) &&
+		len(bm.assetBalances) == len(bm2.assetBalances) && (len(bm.assetBalances) == 0 || reflect.DeepEqual(bm.assetBalances, bm2.assetBalances)) &&
+		len(bm.feesEarned) == len(bm2.feesEarned) && (len(bm.feesEarned) == 0 || reflect.DeepEqual(bm.feesEarned, bm2.feesEarned)) &&
+		len(bm.epochSeeds) == len(bm2.epochSeeds) && (len(bm.epochSeeds) == 0 || reflect.DeepEqual(bm.epochSeeds, bm2.epochSeeds)) &&
+		len(bm.epochStakes) == len(bm2.epochStakes) && (len(bm.epochStakes) == 0 || reflect.DeepEqual(bm.epochStakes, bm2.epochStakes)) &&
+		len(bm.stakes) == len(bm2.stakes) && (len(bm.stakes) == 0 || reflect.DeepEqual(bm.stakes, bm2.stakes)) &&
+		len(bm.unbonds) == len(bm2.unbonds) && (len(bm.unbonds) == 0 || reflect.DeepEqual(bm.unbonds, bm2.unbonds)) &&
+		len(bm.bindings) == len(bm2.bindings) && (len(bm.bindings) == 0 || reflect.DeepEqual(bm.bindings, bm2.bindings)) &&
+		len(bm.slashed) == len(bm2.slashed) && (len(bm.slashed) == 0 || reflect.DeepEqual(bm.slashed, bm2.slashed)) &&
+		bm.burned == bm2.burned &&
+		bm.vdfCount == bm2.vdfCount && bm.pendingFees == bm2.pendingFees
 }
 
 // RandomKeys returns account keys, which are used as default account list on web monitoring tool
@@ -238,12 +558,18 @@ func RandomTransactions(bm *Accounts, numTransactions int, keyPairs []block.KeyP
 	trans := new(block.Transactions)
 	trans.Ts = make([]block.Transaction, numTransactions)
 	var numValidTransactions uint64
+	// signatures are deterministic, so the same random transfer can be generated twice
+	signatures := make(map[string]bool)
 	for j := 0; j < numTransactions; j++ {
 		fromIndex := rand.Intn(numAccounts)
 		toIndex := rand.Intn(numAccounts)
 		amount := rand.Int63n(100) + 1
 		trans.Ts[j] = block.NewTransaction(&keyPairs[fromIndex], keyPairs[toIndex].Public, amount, 0, vdfValue)
+		if signatures[string(trans.Ts[j].Signature)] {
+			continue
+		}
 		if bmClone.Balance(keyPairs[fromIndex].Public) >= amount {
+			signatures[string(trans.Ts[j].Signature)] = true
 			numValidTransactions++
 			bmClone.balances[string(trans.Ts[j].From)] -= amount
 		}
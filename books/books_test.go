@@ -333,3 +333,79 @@ func TestRandomTransactionsBlocks(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessTransactionsNonce(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	to := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 100)
+	// vdf value is not registered in the ledger, nonce transactions do not need it
+	vdf := block.VDF([]byte("unknown"))
+	tr1 := block.NewTransactionWithNonce(&from, to.Public, 10, 0, vdf, 1)
+	tr2 := block.NewTransactionWithNonce(&from, to.Public, 500, 0, vdf, 2)
+	tr3 := block.NewTransactionWithNonce(&from, to.Public, 20, 0, vdf, 3)
+	tr4 := block.NewTransactionWithNonce(&from, to.Public, 20, 0, vdf, 2)
+	res := bm.ProcessTransactions(block.Transactions{Ts: []block.Transaction{tr1, tr1, tr2, tr3, tr4}})
+	if len(res.Ts) != 2 || res.Ts[0].Nonce != 1 || res.Ts[1].Nonce != 2 {
+		t.Errorf("expected transactions with nonce 1 and 2 to be applied, got %v", res.Ts)
+	}
+	if bm.Balance(from.Public) != 70 || bm.Balance(to.Public) != 30 || bm.Nonce(from.Public) != 2 {
+		t.Errorf("unexpected state: balances %v %v, nonce %v", bm.Balance(from.Public), bm.Balance(to.Public), bm.Nonce(from.Public))
+	}
+	if r := bm.TransactionReceipt(tr1.Signature); r.Status != StatusPending {
+		t.Errorf("replayed transaction should keep pending receipt, got %v", r)
+	}
+	if r := bm.TransactionReceipt(tr2.Signature); r.Reason != ReasonInsufficientFunds {
+		t.Errorf("transaction should be rejected with insufficient funds, got %v", r)
+	}
+	if r := bm.TransactionReceipt(tr3.Signature); r.Reason != ReasonInvalidNonce {
+		t.Errorf("transaction should be rejected with invalid nonce, got %v", r)
+	}
+
+	bm2 := NewBookManager()
+	bm2.ImportState(bm.ExportState())
+	if !bm.Equals(bm2) || bm2.Nonce(from.Public) != 2 {
+		t.Errorf("nonces should be part of the exported state")
+	}
+	proof := bm.AccountProof(from.Public)
+	if proof.Nonce != 2 || !proof.Verify() {
+		t.Errorf("account proof should cover nonce: %v", proof)
+	}
+}
+
+func TestProcessTransactionsBatch(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 100)
+	vdf := block.VDF([]byte("batch"))
+	bm.AddValidVDFValue(vdf)
+	outputs := make([]block.Output, 5)
+	for i := range outputs {
+		kp := block.NewKeyPair()
+		outputs[i] = block.Output{To: kp.Public, Token: 10}
+	}
+	tr1 := block.NewBatchTransaction(&from, outputs, 1, vdf)
+	tr2 := block.NewBatchTransaction(&from, outputs, 0, vdf)
+	res := bm.ProcessTransactions(block.Transactions{Ts: []block.Transaction{tr1, tr2}})
+	if len(res.Ts) != 1 || bm.Balance(from.Public) != 49 {
+		t.Errorf("only first batch should be applied, balance %v", bm.Balance(from.Public))
+	}
+	for _, o := range outputs {
+		if bm.Balance(o.To) != 10 {
+			t.Errorf("output should receive 10 tokens, got %v", bm.Balance(o.To))
+		}
+	}
+	if r := bm.TransactionReceipt(tr2.Signature); r.Reason != ReasonInsufficientFunds {
+		t.Errorf("second batch should be rejected as a whole, got %v", r)
+	}
+
+	outputs[2].Token = -10
+	tr3 := block.NewBatchTransaction(&from, outputs, 0, vdf)
+	res = bm.ProcessTransactions(block.Transactions{Ts: []block.Transaction{tr3}})
+	if len(res.Ts) != 0 || bm.Balance(from.Public) != 49 {
+		t.Errorf("batch with negative output should be rejected")
+	}
+	if r := bm.TransactionReceipt(tr3.Signature); r.Reason != ReasonNegativeTokens {
+		t.Errorf("batch with negative output should be rejected, got %v", r)
+	}
+}
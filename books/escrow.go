@@ -0,0 +1,96 @@
+package books
+
+import (
+	"bytes"
+	"errors"
+	"sync/atomic"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+var (
+	// errEscrowNotFound is returned when released or canceled escrow does not exist
+	errEscrowNotFound = errors.New("escrow not found")
+	// errEscrowLocked is returned when escrow condition does not allow release or cancel yet
+	errEscrowLocked = errors.New("escrow is locked")
+)
+
+// Escrow holds tokens of TypeEscrow transaction until they are released to
+// the recipient or returned to the sender
+type Escrow struct {
+	From      []byte
+	To        []byte
+	Token     int64
+	Condition block.EscrowCondition
+}
+
+// escrowClock returns block height and total VDF count escrow conditions are checked against.
+// Producer registers blocks after their transactions are processed, so it sees
+// the clock earlier than replicas. Conditions only become true as the clock
+// advances, so replicas accept every release and cancel accepted by producer.
+func (bm *Accounts) escrowClock() (uint64, uint64) {
+	height := uint64(0)
+	if last := bm.ledger.LastBlock(); last != nil {
+		height = last.Number
+	}
+	return height, atomic.LoadUint64(&bm.vdfCount)
+}
+
+// checkEscrow checks that escrow transaction can be applied before tokens are withdrawn.
+// Must be called under bm.lock
+func (bm *Accounts) checkEscrow(tran *block.Transaction) error {
+	if tran.Type != block.TypeEscrowRelease && tran.Type != block.TypeEscrowCancel {
+		return nil
+	}
+	escrow, ok := bm.escrows[string(tran.Escrow)]
+	if !ok {
+		return errEscrowNotFound
+	}
+	height, count := bm.escrowClock()
+	if tran.Type == block.TypeEscrowCancel {
+		if !bytes.Equal(tran.From, escrow.From) || !escrow.Condition.Cancelable(height) {
+			return errEscrowLocked
+		}
+		return nil
+	}
+	witnessed := len(escrow.Condition.Witness) != 0 && bytes.Equal(tran.From, escrow.Condition.Witness)
+	if !witnessed && !escrow.Condition.Releasable(height, count) {
+		return errEscrowLocked
+	}
+	return nil
+}
+
+// applyEscrow creates, releases or cancels escrow after tokens of the
+// transaction are withdrawn. Released tokens are credited at once, they are
+// already withdrawn from the escrow sender. Must be called under bm.lock
+func (bm *Accounts) applyEscrow(tran *block.Transaction) {
+	switch tran.Type {
+	case block.TypeEscrow:
+		bm.escrows[string(tran.Signature)] = Escrow{From: tran.From, To: tran.To, Token: tran.Token - tran.Fee,
+			Condition: tran.Condition}
+	case block.TypeEscrowRelease:
+		escrow := bm.escrows[string(tran.Escrow)]
+		delete(bm.escrows, string(tran.Escrow))
+		bm.balances[string(escrow.To)] += escrow.Token
+	case block.TypeEscrowCancel:
+		escrow := bm.escrows[string(tran.Escrow)]
+		delete(bm.escrows, string(tran.Escrow))
+		bm.balances[string(escrow.From)] += escrow.Token
+	}
+}
+
+// EscrowOf returns escrow created by transaction with the signature
+func (bm *Accounts) EscrowOf(signature []byte) (Escrow, bool) {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	escrow, ok := bm.escrows[string(signature)]
+	return escrow, ok
+}
+
+func copyEscrows(m map[string]Escrow) map[string]Escrow {
+	res := make(map[string]Escrow, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
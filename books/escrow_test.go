@@ -0,0 +1,117 @@
+package books
+
+import (
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+func processOne(bm *Accounts, tran block.Transaction) Reason {
+	bm.ProcessTransactions(block.Transactions{Ts: []block.Transaction{tran}})
+	return bm.TransactionReceipt(tran.Signature).Reason
+}
+
+func nextBlock(bm *Accounts, count uint64) {
+	number := uint64(0)
+	if last := bm.LastBlock(); last != nil {
+		number = last.Number
+	}
+	bm.UpdateLastBlock(&block.Block{Number: number + 1, Count: count, Val: block.VDF([]byte{byte(number)}),
+		Transactions: &block.Transactions{}})
+}
+
+func TestEscrowReleaseByHeight(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	to := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 100)
+	bm.CreateAccount(to.Public, 0)
+	vdf := block.VDF([]byte("escrow"))
+	bm.AddValidVDFValue(vdf)
+	escrow := block.NewEscrow(&from, to.Public, 50, 1, block.EscrowCondition{ReleaseHeight: 2}, vdf)
+	if r := processOne(bm, escrow); r != ReasonNone || bm.Balance(from.Public) != 50 {
+		t.Errorf("escrow should withdraw tokens from the sender, got %v", r)
+	}
+	if e, ok := bm.EscrowOf(escrow.Signature); !ok || e.Token != 49 {
+		t.Errorf("escrow should hold tokens minus fee, got %v", e)
+	}
+
+	nextBlock(bm, 10)
+	release := block.NewEscrowRelease(&to, escrow.Signature, 0, vdf)
+	if r := processOne(bm, release); r != ReasonEscrowLocked || bm.Balance(to.Public) != 0 {
+		t.Errorf("escrow should be locked before release height, got %v", r)
+	}
+	cancel := block.NewEscrowCancel(&from, escrow.Signature, 0, vdf)
+	if r := processOne(bm, cancel); r != ReasonEscrowLocked {
+		t.Errorf("escrow without cancel height should not be canceled, got %v", r)
+	}
+
+	nextBlock(bm, 10)
+	release = block.NewEscrowRelease(&to, escrow.Signature, 0, block.VDF(vdf))
+	bm.AddValidVDFValue(release.ValidVDFValue)
+	if r := processOne(bm, release); r != ReasonNone || bm.Balance(to.Public) != 49 {
+		t.Errorf("escrow should be released at release height, got %v", r)
+	}
+	again := block.NewEscrowRelease(&from, escrow.Signature, 0, vdf)
+	if r := processOne(bm, again); r != ReasonEscrowNotFound {
+		t.Errorf("escrow should be released only once, got %v", r)
+	}
+}
+
+func TestEscrowReleaseByCountAndWitness(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	to := block.NewKeyPair()
+	witness := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 100)
+	bm.CreateAccount(witness.Public, 0)
+	vdf := block.VDF([]byte("escrow"))
+	bm.AddValidVDFValue(vdf)
+
+	byCount := block.NewEscrow(&from, to.Public, 10, 0, block.EscrowCondition{ReleaseCount: 15}, vdf)
+	byWitness := block.NewEscrow(&from, to.Public, 20, 0, block.EscrowCondition{Witness: witness.Public}, vdf)
+	bm.ProcessTransactions(block.Transactions{Ts: []block.Transaction{byCount, byWitness}})
+
+	nextBlock(bm, 10)
+	if r := processOne(bm, block.NewEscrowRelease(&from, byCount.Signature, 0, vdf)); r != ReasonEscrowLocked {
+		t.Errorf("escrow should be locked before release count, got %v", r)
+	}
+	if r := processOne(bm, block.NewEscrowRelease(&from, byWitness.Signature, 0, vdf)); r != ReasonEscrowLocked {
+		t.Errorf("escrow with witness should be released by the witness only, got %v", r)
+	}
+	if r := processOne(bm, block.NewEscrowRelease(&witness, byWitness.Signature, 0, vdf)); r != ReasonNone || bm.Balance(to.Public) != 20 {
+		t.Errorf("witness should release escrow, got %v", r)
+	}
+	nextBlock(bm, 5)
+	if r := processOne(bm, block.NewEscrowRelease(&witness, byCount.Signature, 0, vdf)); r != ReasonNone || bm.Balance(to.Public) != 30 {
+		t.Errorf("escrow should be released at release count, got %v", r)
+	}
+}
+
+func TestEscrowCancel(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	to := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 100)
+	bm.CreateAccount(to.Public, 0)
+	vdf := block.VDF([]byte("escrow"))
+	bm.AddValidVDFValue(vdf)
+	escrow := block.NewEscrow(&from, to.Public, 50, 0, block.EscrowCondition{ReleaseHeight: 10, CancelHeight: 1}, vdf)
+	processOne(bm, escrow)
+	if r := processOne(bm, block.NewEscrowCancel(&from, escrow.Signature, 0, vdf)); r != ReasonEscrowLocked {
+		t.Errorf("escrow should not be canceled before cancel height, got %v", r)
+	}
+	nextBlock(bm, 1)
+	if r := processOne(bm, block.NewEscrowCancel(&to, escrow.Signature, 0, vdf)); r != ReasonEscrowLocked {
+		t.Errorf("only the sender can cancel escrow, got %v", r)
+	}
+	if r := processOne(bm, block.NewEscrowCancel(&from, escrow.Signature, 1, vdf)); r != ReasonNone || bm.Balance(from.Public) != 99 {
+		t.Errorf("sender should get escrow back, got %v, balance %v", r, bm.Balance(from.Public))
+	}
+
+	restored := NewBookManager()
+	restored.ImportState(bm.ExportState())
+	if restored.VDFCount() != 1 {
+		t.Errorf("state should keep VDF count")
+	}
+}
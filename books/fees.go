@@ -0,0 +1,131 @@
+package books
+
+import (
+	"sort"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"golang.org/x/crypto/ed25519"
+)
+
+// maxBlockFees is the number of recent blocks whose collected fees are kept
+const maxBlockFees = 1024 * 16
+
+// BlockFees stores fees collected from transactions of a single block
+type BlockFees struct {
+	Height    uint64
+	Collector []byte
+	Fee       int64
+}
+
+// SetFeeCollector sets account which is credited with fees of processed blocks,
+// it is the producer of the blocks. Fees stay pending while there is no collector.
+func (bm *Accounts) SetFeeCollector(publicKey ed25519.PublicKey) {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	bm.feeCollector = append([]byte(nil), publicKey...)
+}
+
+// FeeCollector returns account credited with fees
+func (bm *Accounts) FeeCollector() ed25519.PublicKey {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	return bm.feeCollector
+}
+
+// CollectFees credits fees of the block transactions to the fee collector.
+// Fees are withdrawn from senders together with the transactions and are
+// pending until their block is processed, so total supply does not change.
+// With leader schedule fees are credited to the scheduled producer of the block.
+func (bm *Accounts) CollectFees(trans *block.Transactions, height uint64) {
+	fee := int64(0)
+	for i := range trans.Ts {
+		fee += trans.Ts[i].Fee
+	}
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	collector := bm.feeCollector
+	if bm.schedule != nil {
+		collector = bm.leader(height)
+	}
+	if fee == 0 || len(collector) == 0 {
+		return
+	}
+	bm.pendingFees -= fee
+	bm.balances[string(collector)] += fee
+	bm.feesEarned[string(collector)] += fee
+	fees := bm.blockFees[height]
+	fees.Height = height
+	fees.Collector = collector
+	fees.Fee += fee
+	bm.blockFees[height] = fees
+	if height > maxBlockFees {
+		delete(bm.blockFees, height-maxBlockFees)
+	}
+}
+
+// PendingFees returns fees withdrawn from senders which are not collected yet
+func (bm *Accounts) PendingFees() int64 {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	return bm.pendingFees
+}
+
+// FeesEarned returns total fees credited to the account as fee collector
+func (bm *Accounts) FeesEarned(publicKey ed25519.PublicKey) int64 {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	return bm.feesEarned[string(publicKey)]
+}
+
+// BlockFeesRange returns fees collected from blocks with height between from and to inclusive.
+// Only recent maxBlockFees blocks are kept, blocks without fees are omitted.
+func (bm *Accounts) BlockFeesRange(from uint64, to uint64) []BlockFees {
+	bm.lock.Lock()
+	res := make([]BlockFees, 0)
+	for height, fees := range bm.blockFees {
+		if from <= height && height <= to {
+			res = append(res, fees)
+		}
+	}
+	bm.lock.Unlock()
+	sort.Slice(res, func(i, j int) bool { return res[i].Height < res[j].Height })
+	return res
+}
+
+// TotalSupply returns number of native tokens in the system: balances, escrowed,
+// staked and unbonding tokens and pending fees. Transactions other than slash do
+// not change it, slash decreases it by burned tokens.
+func (bm *Accounts) TotalSupply() int64 {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	supply := bm.pendingFees
+	for _, balance := range bm.balances {
+		supply += balance
+	}
+	for _, escrow := range bm.escrows {
+		supply += escrow.Token
+	}
+	for _, token := range bm.stakes {
+		supply += token
+	}
+	for _, unbond := range bm.unbonds {
+		supply += unbond.Token
+	}
+	return supply
+}
+
+func copyFeesEarned(m map[string]int64) map[string]int64 {
+	res := make(map[string]int64, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
+func copyBlockFees(m map[uint64]BlockFees) map[uint64]BlockFees {
+	res := make(map[uint64]BlockFees, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,70 @@
+package books
+
+import (
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+func TestCollectFees(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	to := block.NewKeyPair()
+	producer := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 1000)
+	vdf := block.VDF([]byte("fees"))
+	bm.AddValidVDFValue(vdf)
+	supply := bm.TotalSupply()
+
+	outputs := []block.Output{block.Output{To: to.Public, Token: 10}}
+	trans := block.Transactions{Ts: []block.Transaction{
+		block.NewTransaction(&from, to.Public, 100, 3, vdf),
+		block.NewBatchTransaction(&from, outputs, 2, vdf),
+		block.NewEscrow(&from, to.Public, 50, 5, block.EscrowCondition{ReleaseHeight: 10}, vdf),
+	}}
+	res := bm.ProcessTransactions(trans)
+	if len(res.Ts) != 3 || bm.PendingFees() != 10 || bm.TotalSupply() != supply {
+		t.Errorf("fees should be pending and supply conserved, pending %v, supply %v", bm.PendingFees(), bm.TotalSupply())
+	}
+	bm.CollectFees(&res, 1)
+	if bm.PendingFees() != 10 {
+		t.Errorf("fees should stay pending without fee collector")
+	}
+
+	bm.SetFeeCollector(producer.Public)
+	bm.CollectFees(&res, 1)
+	if bm.PendingFees() != 0 || bm.Balance(producer.Public) != 10 || bm.TotalSupply() != supply {
+		t.Errorf("fees should be credited to the producer, balance %v", bm.Balance(producer.Public))
+	}
+	if bm.FeesEarned(producer.Public) != 10 {
+		t.Errorf("producer should earn 10, got %v", bm.FeesEarned(producer.Public))
+	}
+	fees := bm.BlockFeesRange(0, 5)
+	if len(fees) != 1 || fees[0].Height != 1 || fees[0].Fee != 10 || len(bm.BlockFeesRange(2, 5)) != 0 {
+		t.Errorf("block fees should be recorded per block, got %v", fees)
+	}
+}
+
+func TestProcessBlocksCollectFees(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	producer := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 100)
+	bm.SetFeeCollector(producer.Public)
+	vdf := block.VDF([]byte("blocks"))
+	bm.AddValidVDFValue(vdf)
+	supply := bm.TotalSupply()
+	for i := uint64(1); i <= 3; i++ {
+		trans := block.Transactions{Ts: []block.Transaction{block.NewTransactionWithNonce(&from, from.Public, 10, 1, vdf, i)}}
+		bm.ProcessBlocks([]block.Block{block.Block{Number: i, Val: block.VDF(vdf), Transactions: &trans}})
+	}
+	if bm.Balance(producer.Public) != 3 || bm.TotalSupply() != supply || len(bm.BlockFeesRange(2, 3)) != 2 {
+		t.Errorf("fees of every block should be collected, producer balance %v", bm.Balance(producer.Public))
+	}
+
+	restored := NewBookManager()
+	restored.ImportState(bm.ExportState())
+	if restored.FeesEarned(producer.Public) != 3 || len(restored.BlockFeesRange(1, 3)) != 3 {
+		t.Errorf("state should keep collected fees")
+	}
+}
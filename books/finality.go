@@ -0,0 +1,162 @@
+package books
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"golang.org/x/crypto/ed25519"
+)
+
+var (
+	// errInvalidVote is returned when vote signature is not valid
+	errInvalidVote = errors.New("invalid vote signature")
+
+	// errUnknownVoter is returned when voter has no voting power
+	errUnknownVoter = errors.New("voter has no voting power")
+
+	// errDuplicateVote is returned when voter already voted for the block number
+	errDuplicateVote = errors.New("duplicate vote")
+
+	// errNoFinality is returned when votes are added without finality tracker
+	errNoFinality = errors.New("finality is not tracked")
+
+	// errEquivocation is returned when voter already voted for another block with the same number
+	errEquivocation = errors.New("conflicting vote")
+
+	// errVoteTooHigh is returned when vote is for a block too far above the last block
+	errVoteTooHigh = errors.New("vote is too far above the last block")
+)
+
+// voteWindow is how many blocks above the last block votes are accepted for,
+// it bounds votes kept for blocks which are not final yet
+const voteWindow = 128
+
+// VoterSet defines voting power of the voters
+type VoterSet interface {
+	Power(voter ed25519.PublicKey) int64
+	TotalPower() int64
+}
+
+// Finality collects votes of signers and tracks the highest final block. The
+// block is final when voters with more than 2/3 of the total voting power voted
+// for its number and hash, blocks below the final one are final too.
+// Conflicting votes of a voter are kept as evidence, see TakeEvidence.
+type Finality struct {
+	voters   VoterSet
+	votes    map[uint64]map[string]block.Vote
+	power    map[uint64]map[string]int64
+	height   uint64
+	val      block.VDFValue
+	evidence []block.Evidence
+	lock     sync.Mutex
+}
+
+// NewFinality creates finality tracker over the voter set
+func NewFinality(voters VoterSet) *Finality {
+	return &Finality{voters: voters, votes: make(map[uint64]map[string]block.Vote),
+		power: make(map[uint64]map[string]int64)}
+}
+
+// AddVote verifies and records the vote, it returns true if the vote made its block final
+func (f *Finality) AddVote(vote *block.Vote) (bool, error) {
+	if !vote.Verify() {
+		return false, errInvalidVote
+	}
+	power := f.voters.Power(vote.Voter)
+	if power <= 0 {
+		return false, errUnknownVoter
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if vote.Number <= f.height {
+		return false, nil
+	}
+	if f.votes[vote.Number] == nil {
+		f.votes[vote.Number] = make(map[string]block.Vote)
+		f.power[vote.Number] = make(map[string]int64)
+	}
+	if prev, ok := f.votes[vote.Number][string(vote.Voter)]; ok {
+		if bytes.Equal(prev.Val, vote.Val) {
+			return false, errDuplicateVote
+		}
+		f.evidence = append(f.evidence, block.NewEvidence(prev, *vote))
+		return false, errEquivocation
+	}
+	f.votes[vote.Number][string(vote.Voter)] = *vote
+	f.power[vote.Number][string(vote.Val)] += power
+	if 3*f.power[vote.Number][string(vote.Val)] <= 2*f.voters.TotalPower() {
+		return false, nil
+	}
+	f.height = vote.Number
+	f.val = vote.Val
+	for number := range f.votes {
+		if number <= f.height {
+			delete(f.votes, number)
+			delete(f.power, number)
+		}
+	}
+	return true, nil
+}
+
+// Finalized returns number and hash of the highest final block
+func (f *Finality) Finalized() (uint64, block.VDFValue) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.height, f.val
+}
+
+// Votes returns votes collected for the block number which is not final yet
+func (f *Finality) Votes(number uint64) []block.Vote {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	res := make([]block.Vote, 0, len(f.votes[number]))
+	for _, vote := range f.votes[number] {
+		res = append(res, vote)
+	}
+	return res
+}
+
+// TakeEvidence returns evidence of conflicting votes found since the last call
+func (f *Finality) TakeEvidence() []block.Evidence {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	res := f.evidence
+	f.evidence = nil
+	return res
+}
+
+// SetVoters tracks finality of blocks with votes of the voter set
+func (bm *Accounts) SetVoters(voters VoterSet) {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	bm.finality = NewFinality(voters)
+}
+
+// AddVote records vote of a signer, see Finality.AddVote. Votes for blocks
+// more than voteWindow blocks above the last block are rejected.
+func (bm *Accounts) AddVote(vote *block.Vote) (bool, error) {
+	bm.lock.Lock()
+	finality := bm.finality
+	bm.lock.Unlock()
+	if finality == nil {
+		return false, errNoFinality
+	}
+	if vote.Number > bm.height()+voteWindow {
+		return false, errVoteTooHigh
+	}
+	return finality.AddVote(vote)
+}
+
+// FinalizedHeight returns number of the highest final block, zero when finality is not tracked
+func (bm *Accounts) FinalizedHeight() uint64 {
+	bm.lock.Lock()
+	finality := bm.finality
+	bm.lock.Unlock()
+	if finality == nil {
+		return 0
+	}
+	height, _ := finality.Finalized()
+	return height
+}
@@ -0,0 +1,101 @@
+package books
+
+import (
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"golang.org/x/crypto/ed25519"
+)
+
+// stakeVoters gives every voter its own voting power
+type stakeVoters map[string]int64
+
+func (v stakeVoters) Power(voter ed25519.PublicKey) int64 {
+	return v[string(voter)]
+}
+
+func (v stakeVoters) TotalPower() int64 {
+	total := int64(0)
+	for _, power := range v {
+		total += power
+	}
+	return total
+}
+
+func TestFinality(t *testing.T) {
+	keys := make([]block.KeyPair, 4)
+	voters := make(stakeVoters)
+	for i := range keys {
+		keys[i] = block.NewKeyPair()
+		voters[string(keys[i].Public)] = 1
+	}
+	finality := NewFinality(voters)
+	val := block.VDF([]byte("final"))
+	other := block.VDF([]byte("fork"))
+
+	for i := 0; i < 2; i++ {
+		vote := block.NewVote(&keys[i], 3, val)
+		if final, err := finality.AddVote(&vote); final || err != nil {
+			t.Errorf("two of four votes should not make the block final: %v", err)
+		}
+	}
+	vote := block.NewVote(&keys[0], 3, val)
+	if _, err := finality.AddVote(&vote); err != errDuplicateVote {
+		t.Errorf("expected errDuplicateVote, got %v", err)
+	}
+	vote = block.NewVote(&keys[2], 3, other)
+	if final, _ := finality.AddVote(&vote); final {
+		t.Errorf("votes for different vdf values should not be summed")
+	}
+	if len(finality.Votes(3)) != 3 {
+		t.Errorf("votes of not final block should be kept")
+	}
+	stranger := block.NewKeyPair()
+	vote = block.NewVote(&stranger, 4, val)
+	if _, err := finality.AddVote(&vote); err != errUnknownVoter {
+		t.Errorf("expected errUnknownVoter, got %v", err)
+	}
+	vote.Number = 5
+	if _, err := finality.AddVote(&vote); err != errInvalidVote {
+		t.Errorf("expected errInvalidVote, got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		vote := block.NewVote(&keys[i], 6, val)
+		final, err := finality.AddVote(&vote)
+		if err != nil || final != (i == 2) {
+			t.Errorf("block should be final after the third vote, vote %v: %v %v", i, final, err)
+		}
+	}
+	if height, finalVal := finality.Finalized(); height != 6 || string(finalVal) != string(val) {
+		t.Errorf("expected finalized height 6, got %v", height)
+	}
+	if len(finality.Votes(3)) != 0 {
+		t.Errorf("votes below the final block should be dropped")
+	}
+	vote = block.NewVote(&keys[3], 4, val)
+	if final, err := finality.AddVote(&vote); final || err != nil {
+		t.Errorf("votes below the final block should be ignored: %v", err)
+	}
+}
+
+func TestFinalityStake(t *testing.T) {
+	big := block.NewKeyPair()
+	small := block.NewKeyPair()
+	bm := NewBookManager()
+	if bm.FinalizedHeight() != 0 {
+		t.Errorf("finalized height should be zero without finality tracker")
+	}
+	vote := block.NewVote(&big, 2, block.VDF([]byte("stake")))
+	if _, err := bm.AddVote(&vote); err != errNoFinality {
+		t.Errorf("expected errNoFinality, got %v", err)
+	}
+	bm.SetVoters(stakeVoters{string(big.Public): 7, string(small.Public): 3})
+	far := block.NewVote(&small, voteWindow+1, block.VDF([]byte("far")))
+	if _, err := bm.AddVote(&far); err != errVoteTooHigh {
+		t.Errorf("expected errVoteTooHigh, got %v", err)
+	}
+	if final, err := bm.AddVote(&vote); !final || err != nil || bm.FinalizedHeight() != 2 {
+		t.Errorf("voter with more than 2/3 of stake should finalize the block: %v", err)
+	}
+}
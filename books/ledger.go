@@ -80,6 +80,14 @@ func (l *Ledger) addSignature(signature ed25519.PublicKey, vdfVal block.VDFValue
 	return nil
 }
 
+// hasVDFValue returns true if vdf value is still in the ledger window
+func (l *Ledger) hasVDFValue(vdfVal block.VDFValue) bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	_, ok := l.signatures[string(vdfVal)]
+	return ok
+}
+
 func (l *Ledger) contains(vdfVal block.VDFValue) bool {
 	for _, val := range l.values {
 		if bytes.Equal(vdfVal, val) {
@@ -122,6 +130,13 @@ func (l *Ledger) UpdateLastBlock(bl *block.Block) {
 	}
 }
 
+// replaceLastBlock registers the block as the last one even if its number is not greater
+func (l *Ledger) replaceLastBlock(bl *block.Block) {
+	l.blockMutex.Lock()
+	defer l.blockMutex.Unlock()
+	l.lastBlock = bl
+}
+
 // LastBlock returns last block registered in the ledger
 func (l *Ledger) LastBlock() *block.Block {
 	l.blockMutex.Lock()
@@ -158,3 +173,63 @@ func (l *Ledger) Equals(l2 *Ledger) bool {
 		l.index == l2.index && l.full == l2.full &&
 		((l.lastBlock == nil && l2.lastBlock == nil) || (l.lastBlock.Number == l2.lastBlock.Number))
 }
+
+// exportState copies ledger data which should be persisted into state
+func (l *Ledger) exportState(state *State) {
+	l.mutex.RLock()
+	state.LedgerValues = make([]block.VDFValue, len(l.values))
+	copy(state.LedgerValues, l.values)
+	state.LedgerSignatures = make(map[string]map[string]bool, len(l.signatures))
+	for k, v := range l.signatures {
+		state.LedgerSignatures[k] = make(map[string]bool, len(v))
+		for sign, val := range v {
+			state.LedgerSignatures[k][sign] = val
+		}
+	}
+	state.LedgerIndex = l.index
+	state.LedgerFull = l.full
+	l.mutex.RUnlock()
+
+	l.blockMutex.Lock()
+	if l.lastBlock != nil {
+		state.HasLastBlock = true
+		state.LastBlockNumber = l.lastBlock.Number
+		state.LastBlockCount = l.lastBlock.Count
+		state.LastBlockVal = l.lastBlock.Val
+		state.LastBlockHeader = l.lastBlock.Header
+	}
+	l.blockMutex.Unlock()
+}
+
+// importState restores ledger from persisted state
+func (l *Ledger) importState(state *State) {
+	l.mutex.Lock()
+	l.values = make([]block.VDFValue, maxSize)
+	for i, val := range state.LedgerValues {
+		if i == maxSize {
+			break
+		}
+		// decoder turns nil slices into empty ones, keep unused slots nil
+		if len(val) > 0 {
+			l.values[i] = val
+		}
+	}
+	l.signatures = make(map[string]map[string]bool, len(state.LedgerSignatures))
+	for k, v := range state.LedgerSignatures {
+		l.signatures[k] = make(map[string]bool, len(v))
+		for sign, val := range v {
+			l.signatures[k][sign] = val
+		}
+	}
+	l.index = state.LedgerIndex
+	l.full = state.LedgerFull
+	l.mutex.Unlock()
+
+	l.blockMutex.Lock()
+	l.lastBlock = nil
+	if state.HasLastBlock {
+		l.lastBlock = &block.Block{Number: state.LastBlockNumber, Count: state.LastBlockCount,
+			Val: state.LastBlockVal, Header: state.LastBlockHeader, Transactions: &block.Transactions{}}
+	}
+	l.blockMutex.Unlock()
+}
@@ -0,0 +1,329 @@
+package books
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/log"
+	"github.com/Ansiblock/Ansiblock/network"
+)
+
+const (
+	// mempoolBatchSize is the maximal number of transactions taken from mempool at once
+	mempoolBatchSize = 1024 * 4
+	// mempoolPollInterval is how long mempool processing waits when mempool is empty
+	mempoolPollInterval = 10 * time.Millisecond
+	// maxHoldBacks is how many blocks a temporarily failing transaction is retried for
+	maxHoldBacks = 16
+	// mempoolHeartbeat is how often an empty batch is produced while there are no
+	// transactions, so that signers see the producer alive
+	mempoolHeartbeat = 1 * time.Second
+)
+
+// mempoolEntry is a transaction waiting in the mempool. Held back transactions
+// are not taken again until a new block is registered.
+type mempoolEntry struct {
+	tran      block.Transaction
+	sequence  uint64
+	holdBacks int
+	heldAt    uint64
+	index     int
+}
+
+// mempoolQueue orders entries by decreasing fee, earlier arrived first among equal fees
+type mempoolQueue []*mempoolEntry
+
+func (q mempoolQueue) Len() int { return len(q) }
+func (q mempoolQueue) Less(i, j int) bool {
+	if q[i].tran.Fee != q[j].tran.Fee {
+		return q[i].tran.Fee > q[j].tran.Fee
+	}
+	return q[i].sequence < q[j].sequence
+}
+func (q mempoolQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+func (q *mempoolQueue) Push(x interface{}) {
+	entry := x.(*mempoolEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+func (q *mempoolQueue) Pop() interface{} {
+	old := *q
+	entry := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return entry
+}
+
+// Mempool buffers verified transactions before the producer processes them.
+// Transactions are taken in order of decreasing fee, duplicates are dropped and
+// transactions whose vdf value left the ledger window are evicted. Transactions
+// which fail temporarily (insufficient funds, future nonce) are held back and
+// retried after following blocks.
+type Mempool struct {
+	bm       *Accounts
+	capacity int
+	queue    mempoolQueue
+	entries  map[string]*mempoolEntry
+	sequence uint64
+	closed   bool
+	lock     sync.Mutex
+	notFull  *sync.Cond
+}
+
+// NewMempool creates mempool which holds at most capacity transactions
+func NewMempool(bm *Accounts, capacity int) *Mempool {
+	m := &Mempool{bm: bm, capacity: capacity, entries: make(map[string]*mempoolEntry)}
+	m.notFull = sync.NewCond(&m.lock)
+	return m
+}
+
+// Add adds transactions to the mempool and returns number of added ones.
+// Duplicates, stale transactions and transactions above capacity are dropped.
+func (m *Mempool) Add(trans []block.Transaction) int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	added := 0
+	for i := range trans {
+		if m.add(&mempoolEntry{tran: trans[i]}) {
+			added++
+		}
+	}
+	if added < len(trans) {
+		log.Warn(fmt.Sprintf("Mempool: %v transactions dropped", len(trans)-added))
+	}
+	return added
+}
+
+// add pushes entry into the queue, must be called under m.lock
+func (m *Mempool) add(entry *mempoolEntry) bool {
+	key := string(entry.tran.Signature)
+	if _, ok := m.entries[key]; ok || m.closed || len(m.queue) >= m.capacity || !m.bm.ledger.hasVDFValue(entry.tran.ValidVDFValue) {
+		return false
+	}
+	m.sequence++
+	entry.sequence = m.sequence
+	heap.Push(&m.queue, entry)
+	m.entries[key] = entry
+	return true
+}
+
+// Take removes and returns at most max transactions with the highest fee
+func (m *Mempool) Take(max int) []block.Transaction {
+	return transactionsOf(m.take(max))
+}
+
+// take removes and returns at most max entries with the highest fee, held back
+// entries are skipped until a new block is registered
+func (m *Mempool) take(max int) []*mempoolEntry {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.evictStale()
+	height := m.bm.BlocksTotal()
+	res := make([]*mempoolEntry, 0, max)
+	held := make([]*mempoolEntry, 0)
+	for len(res) < max && len(m.queue) > 0 {
+		entry := heap.Pop(&m.queue).(*mempoolEntry)
+		if entry.holdBacks > 0 && entry.heldAt == height {
+			held = append(held, entry)
+			continue
+		}
+		delete(m.entries, string(entry.tran.Signature))
+		res = append(res, entry)
+	}
+	for _, entry := range held {
+		heap.Push(&m.queue, entry)
+	}
+	m.notFull.Broadcast()
+	return res
+}
+
+// retryable returns rejected entries which failed temporarily
+func (m *Mempool) retryable(entries []*mempoolEntry, accepted []block.Transaction) []*mempoolEntry {
+	applied := make(map[string]bool, len(accepted))
+	for i := range accepted {
+		applied[string(accepted[i].Signature)] = true
+	}
+	res := make([]*mempoolEntry, 0)
+	for _, entry := range entries {
+		if !applied[string(entry.tran.Signature)] && m.bm.temporaryFailure(&entry.tran) {
+			res = append(res, entry)
+		}
+	}
+	return res
+}
+
+// holdBack returns temporarily failing entries into the mempool, they are
+// retried after the next block and dropped after maxHoldBacks blocks
+func (m *Mempool) holdBack(entries []*mempoolEntry) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	height := m.bm.BlocksTotal()
+	for _, entry := range entries {
+		entry.holdBacks++
+		entry.heldAt = height
+		if entry.holdBacks <= maxHoldBacks {
+			m.add(entry)
+		}
+	}
+}
+
+func transactionsOf(entries []*mempoolEntry) []block.Transaction {
+	res := make([]block.Transaction, len(entries))
+	for i, entry := range entries {
+		res[i] = entry.tran
+	}
+	return res
+}
+
+// evictStale removes transactions whose vdf value left the ledger window, must be called under m.lock
+func (m *Mempool) evictStale() {
+	queue := m.queue[:0]
+	for _, entry := range m.queue {
+		if m.bm.ledger.hasVDFValue(entry.tran.ValidVDFValue) {
+			queue = append(queue, entry)
+		} else {
+			delete(m.entries, string(entry.tran.Signature))
+		}
+	}
+	for i := len(queue); i < len(m.queue); i++ {
+		m.queue[i] = nil
+	}
+	m.queue = queue
+	for i := range m.queue {
+		m.queue[i].index = i
+	}
+	heap.Init(&m.queue)
+}
+
+// waitForRoom blocks until the mempool is not full or is closed
+func (m *Mempool) waitForRoom() {
+	m.lock.Lock()
+	for len(m.queue) >= m.capacity && !m.closed {
+		m.notFull.Wait()
+	}
+	m.lock.Unlock()
+}
+
+// close marks that no more transactions will be added
+func (m *Mempool) close() {
+	m.lock.Lock()
+	m.closed = true
+	m.lock.Unlock()
+}
+
+// Stop closes the mempool and drops waiting transactions, so that
+// MempoolTransactionGenerator closes its output without processing them
+func (m *Mempool) Stop() {
+	m.lock.Lock()
+	m.closed = true
+	m.queue = m.queue[:0]
+	m.entries = make(map[string]*mempoolEntry)
+	m.notFull.Broadcast()
+	m.lock.Unlock()
+}
+
+func (m *Mempool) drained() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.closed && len(m.queue) == 0
+}
+
+// Len returns number of transactions in the mempool
+func (m *Mempool) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.queue)
+}
+
+// Capacity returns maximal number of transactions in the mempool
+func (m *Mempool) Capacity() int {
+	return m.capacity
+}
+
+// Transactions returns copy of the mempool contents in the order they will be taken
+func (m *Mempool) Transactions() []block.Transaction {
+	m.lock.Lock()
+	queue := make(mempoolQueue, len(m.queue))
+	for i, entry := range m.queue {
+		queue[i] = &mempoolEntry{tran: entry.tran, sequence: entry.sequence, index: i}
+	}
+	m.lock.Unlock()
+	res := make([]block.Transaction, 0, len(queue))
+	for len(queue) > 0 {
+		res = append(res, heap.Pop(&queue).(*mempoolEntry).tran)
+	}
+	return res
+}
+
+// temporaryFailure returns true if rejected transaction can succeed later
+func (bm *Accounts) temporaryFailure(tran *block.Transaction) bool {
+	switch bm.TransactionReceipt(tran.Signature).Reason {
+	case ReasonInsufficientFunds:
+		return true
+	case ReasonInvalidNonce:
+		return tran.Nonce > bm.Nonce(tran.From)
+	}
+	return false
+}
+
+// MempoolTransactionGenerator works as TransactionGenerator, but transactions
+// pass through the mempool before they are processed. Reading of packets stops
+// while the mempool is full, so backpressure reaches PacketGenerator. Empty
+// batch is produced every mempoolHeartbeat while the mempool is empty.
+func MempoolTransactionGenerator(bm *Accounts, mempool *Mempool, packetReceiver <-chan *network.Packets) <-chan *block.Transactions {
+	out := make(chan *block.Transactions, cap(packetReceiver))
+	go MempoolIntake(mempool, packetReceiver)
+	go func() {
+		idle := time.Now()
+		for {
+			entries := mempool.take(mempoolBatchSize)
+			if len(entries) == 0 {
+				if mempool.drained() {
+					close(out)
+					return
+				}
+				if time.Since(idle) >= mempoolHeartbeat {
+					out <- new(block.Transactions)
+					idle = time.Now()
+				}
+				time.Sleep(mempoolPollInterval)
+				continue
+			}
+			res := bm.ProcessTransactions(block.Transactions{Ts: transactionsOf(entries)})
+			if len(res.Ts) < len(entries) {
+				mempool.holdBack(mempool.retryable(entries, res.Ts))
+			}
+			if len(res.Ts) > 0 {
+				idle = time.Now()
+				out <- &res
+				log.Info(fmt.Sprintf("MempoolTransactionGenerator: %v transactions has been successfully processed", len(res.Ts)))
+			}
+		}
+	}()
+	return out
+}
+
+// MempoolIntake adds transactions of the received packets to the mempool. Reading
+// of packets waits while the mempool is full and the mempool is closed when
+// the packet receiver is closed.
+func MempoolIntake(mempool *Mempool, packetReceiver <-chan *network.Packets) {
+	for {
+		mempool.waitForRoom()
+		packets, ok := <-packetReceiver
+		if !ok {
+			log.Error("mempool's packet receiver failed, closing mempool")
+			mempool.close()
+			return
+		}
+		var transactions block.Transactions
+		transactions.FromPackets(packets)
+		added := mempool.Add(filter(transactions).Ts)
+		log.Info(fmt.Sprintf("MempoolIntake: %v transactions added to mempool", added))
+	}
+}
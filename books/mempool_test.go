@@ -0,0 +1,187 @@
+package books
+
+import (
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/network"
+)
+
+func TestMempoolOrder(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	to := block.NewKeyPair()
+	vdf := block.VDF([]byte("mempool"))
+	bm.AddValidVDFValue(vdf)
+	mempool := NewMempool(bm, 10)
+
+	trans := []block.Transaction{
+		block.NewTransaction(&from, to.Public, 10, 1, vdf),
+		block.NewTransaction(&from, to.Public, 10, 5, vdf),
+		block.NewTransaction(&from, to.Public, 11, 1, vdf),
+		block.NewTransaction(&from, to.Public, 10, 3, vdf),
+	}
+	if added := mempool.Add(trans); added != 4 || mempool.Len() != 4 {
+		t.Errorf("all transactions should be added, added %v", added)
+	}
+	if added := mempool.Add(trans[1:2]); added != 0 || mempool.Len() != 4 {
+		t.Errorf("duplicate transaction should be dropped")
+	}
+	expected := []block.Transaction{trans[1], trans[3], trans[0], trans[2]}
+	contents := mempool.Transactions()
+	if len(contents) != 4 || mempool.Len() != 4 {
+		t.Errorf("Transactions should not remove transactions from mempool")
+	}
+	for i := range expected {
+		if !contents[i].Equals(expected[i]) {
+			t.Errorf("transaction %v should have fee %v, got %v", i, expected[i].Fee, contents[i].Fee)
+		}
+	}
+	taken := mempool.Take(3)
+	if len(taken) != 3 || !taken[0].Equals(trans[1]) || !taken[2].Equals(trans[0]) || mempool.Len() != 1 {
+		t.Errorf("Take should return transactions with the highest fee first")
+	}
+	if taken = mempool.Take(3); len(taken) != 1 || !taken[0].Equals(trans[2]) || mempool.Len() != 0 {
+		t.Errorf("Take should return the rest of transactions")
+	}
+}
+
+func TestMempoolCapacity(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	vdf := block.VDF([]byte("capacity"))
+	bm.AddValidVDFValue(vdf)
+	mempool := NewMempool(bm, 2)
+	trans := []block.Transaction{
+		block.NewTransaction(&from, from.Public, 1, 0, vdf),
+		block.NewTransaction(&from, from.Public, 2, 0, vdf),
+		block.NewTransaction(&from, from.Public, 3, 0, vdf),
+	}
+	if added := mempool.Add(trans); added != 2 || mempool.Len() != mempool.Capacity() {
+		t.Errorf("mempool should not grow over its capacity, added %v", added)
+	}
+
+	done := make(chan bool)
+	go func() {
+		mempool.waitForRoom()
+		done <- true
+	}()
+	select {
+	case <-done:
+		t.Errorf("full mempool should block intake")
+	default:
+	}
+	mempool.Take(1)
+	<-done
+}
+
+func TestMempoolEvictStale(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	stale := block.VDF([]byte("stale"))
+	bm.AddValidVDFValue(stale)
+	mempool := NewMempool(bm, 10)
+	if added := mempool.Add([]block.Transaction{block.NewTransaction(&from, from.Public, 1, 0, []byte("unknown"))}); added != 0 {
+		t.Errorf("transaction with unknown vdf value should be dropped")
+	}
+	mempool.Add([]block.Transaction{block.NewTransaction(&from, from.Public, 1, 0, stale)})
+
+	val := stale
+	for i := 0; i < maxSize; i++ {
+		val = block.VDF(val)
+		bm.AddValidVDFValue(val)
+	}
+	fresh := block.NewTransaction(&from, from.Public, 2, 0, val)
+	mempool.Add([]block.Transaction{fresh})
+	if taken := mempool.Take(10); len(taken) != 1 || !taken[0].Equals(fresh) || mempool.Len() != 0 {
+		t.Errorf("stale transaction should be evicted, got %v transactions", len(taken))
+	}
+}
+
+func TestMempoolHoldBack(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	to := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 10)
+	vdf := block.VDF([]byte("hold"))
+	bm.AddValidVDFValue(vdf)
+	mempool := NewMempool(bm, 10)
+
+	second := block.NewTransactionWithNonce(&from, to.Public, 5, 2, vdf, 2)
+	first := block.NewTransactionWithNonce(&from, to.Public, 5, 1, vdf, 1)
+	poor := block.NewTransaction(&from, to.Public, 100, 0, vdf)
+	mempool.Add([]block.Transaction{second, first, poor})
+
+	process := func() []block.Transaction {
+		entries := mempool.take(10)
+		res := bm.ProcessTransactions(block.Transactions{Ts: transactionsOf(entries)})
+		mempool.holdBack(mempool.retryable(entries, res.Ts))
+		return res.Ts
+	}
+	if res := process(); len(res) != 1 || !res[0].Equals(first) {
+		t.Errorf("only transaction with the next nonce should be accepted, accepted %v", len(res))
+	}
+	if mempool.Len() != 2 || len(mempool.Take(10)) != 0 {
+		t.Errorf("future nonce and insufficient funds should wait for the next block, held back %v", mempool.Len())
+	}
+
+	nextBlock(bm, 1)
+	if res := process(); len(res) != 1 || !res[0].Equals(second) || bm.Balance(to.Public) != 7 {
+		t.Errorf("held back transaction should be accepted after the next block, balance %v", bm.Balance(to.Public))
+	}
+	if contents := mempool.Transactions(); len(contents) != 1 || !contents[0].Equals(poor) {
+		t.Errorf("transaction without funds should stay held back")
+	}
+	for i := 1; i < maxHoldBacks; i++ {
+		nextBlock(bm, 1)
+		process()
+	}
+	if mempool.Len() != 0 {
+		t.Errorf("transaction should be dropped after %v hold backs", maxHoldBacks)
+	}
+}
+
+func TestMempoolTransactionGenerator(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 100)
+	vdf := block.VDF([]byte("generator"))
+	bm.AddValidVDFValue(vdf)
+	mempool := NewMempool(bm, 10)
+
+	trans := block.Transactions{Ts: []block.Transaction{
+		block.NewTransaction(&from, from.Public, 10, 1, vdf),
+		block.NewTransaction(&from, from.Public, 10, 2, vdf),
+	}}
+	packets := make(chan *network.Packets, 1)
+	out := MempoolTransactionGenerator(bm, mempool, packets)
+	packets <- trans.ToPackets(nil)
+	close(packets)
+	res := <-out
+	for range out {
+	}
+	if len(res.Ts) != 2 || res.Ts[0].Fee != 2 || bm.TransactionsTotal() != 2 {
+		t.Errorf("transactions should be processed in order of fee, got %v", res.Ts)
+	}
+}
+
+func TestMempoolStop(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 100)
+	vdf := block.VDF([]byte("stop"))
+	bm.AddValidVDFValue(vdf)
+	mempool := NewMempool(bm, 10)
+	mempool.Add([]block.Transaction{block.NewTransaction(&from, from.Public, 10, 1, vdf)})
+
+	mempool.Stop()
+	out := MempoolTransactionGenerator(bm, mempool, make(chan *network.Packets))
+	for range out {
+	}
+	if bm.TransactionsTotal() != 0 {
+		t.Errorf("waiting transactions should be dropped, %v processed", bm.TransactionsTotal())
+	}
+	if mempool.Add([]block.Transaction{block.NewTransaction(&from, from.Public, 10, 2, vdf)}) != 0 {
+		t.Errorf("stopped mempool should not accept transactions")
+	}
+}
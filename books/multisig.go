@@ -0,0 +1,94 @@
+package books
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"golang.org/x/crypto/ed25519"
+)
+
+var (
+	// errMultisigThreshold is returned when multisig account transaction is not signed by enough signers
+	errMultisigThreshold = errors.New("multisig threshold is not reached")
+	// errUnexpectedCosignatures is returned when cosigned transaction is sent from single signature account
+	errUnexpectedCosignatures = errors.New("cosigned transaction from single signature account")
+)
+
+// Multisig is a signer set of multisig account, at least Threshold of
+// Signers must sign each transaction of the account
+type Multisig struct {
+	Threshold byte
+	Signers   [][]byte
+}
+
+// authorize checks that transaction is signed according to the sender account
+// signer set. Signatures themselves are verified by SignatureVerification.
+// Must be called under bm.lock
+func (bm *Accounts) authorize(tran *block.Transaction) error {
+	multisig, ok := bm.multisig[string(tran.From)]
+	if !ok {
+		if tran.Version == block.TransactionV2 {
+			return errUnexpectedCosignatures
+		}
+		return nil
+	}
+	if tran.Version != block.TransactionV2 {
+		return errMultisigThreshold
+	}
+	signed := 0
+	for i, c := range tran.Cosignatures {
+		if multisig.isSigner(c.PublicKey) && !cosignedBefore(tran.Cosignatures[:i], c.PublicKey) {
+			signed++
+		}
+	}
+	if signed < int(multisig.Threshold) {
+		return errMultisigThreshold
+	}
+	return nil
+}
+
+func (m *Multisig) isSigner(publicKey ed25519.PublicKey) bool {
+	for _, signer := range m.Signers {
+		if bytes.Equal(signer, publicKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func cosignedBefore(cosignatures []block.Cosignature, publicKey ed25519.PublicKey) bool {
+	for _, c := range cosignatures {
+		if bytes.Equal(c.PublicKey, publicKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// registerMultisig replaces signer set of the sender account, it takes effect
+// for the next transaction.
+// Must be called under bm.lock
+func (bm *Accounts) registerMultisig(tran *block.Transaction) {
+	multisig := Multisig{Threshold: tran.Threshold, Signers: make([][]byte, len(tran.Signers))}
+	for i := range tran.Signers {
+		multisig.Signers[i] = append([]byte(nil), tran.Signers[i]...)
+	}
+	bm.multisig[string(tran.From)] = multisig
+}
+
+// MultisigAccount returns signer set of the account, false is returned for single signature accounts
+func (bm *Accounts) MultisigAccount(publicKey ed25519.PublicKey) (Multisig, bool) {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	multisig, ok := bm.multisig[string(publicKey)]
+	return multisig, ok
+}
+
+func copyMultisig(m map[string]Multisig) map[string]Multisig {
+	res := make(map[string]Multisig, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
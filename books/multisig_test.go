@@ -0,0 +1,97 @@
+package books
+
+import (
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestProcessTransactionsMultisig(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	to := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 100)
+	vdf := block.VDF([]byte("multisig"))
+	bm.AddValidVDFValue(vdf)
+	kps := []block.KeyPair{block.NewKeyPair(), block.NewKeyPair(), block.NewKeyPair()}
+	signers := []ed25519.PublicKey{kps[0].Public, kps[1].Public, kps[2].Public}
+
+	reg := block.NewMultisigRegistration(&from, 2, signers, 1, vdf)
+	res := bm.ProcessTransactions(block.Transactions{Ts: []block.Transaction{reg}})
+	if len(res.Ts) != 1 || bm.Balance(from.Public) != 99 {
+		t.Errorf("multisig registration should be applied, balance %v", bm.Balance(from.Public))
+	}
+	if m, ok := bm.MultisigAccount(from.Public); !ok || m.Threshold != 2 || len(m.Signers) != 3 {
+		t.Errorf("account should become multisig, got %v", m)
+	}
+
+	single := block.NewTransactionWithMemo(&from, to.Public, 10, 0, vdf, []byte("single"))
+	one := block.NewTransactionWithMemo(&from, to.Public, 10, 0, vdf, []byte("one"))
+	one.Cosign(&kps[0])
+	twice := block.NewTransactionWithMemo(&from, to.Public, 10, 0, vdf, []byte("twice"))
+	twice.Cosign(&kps[0])
+	twice.Cosign(&kps[0])
+	stranger := block.NewKeyPair()
+	foreign := block.NewTransactionWithMemo(&from, to.Public, 10, 0, vdf, []byte("foreign"))
+	foreign.Cosign(&kps[1])
+	foreign.Cosign(&stranger)
+	res = bm.ProcessTransactions(block.Transactions{Ts: []block.Transaction{single, one, twice, foreign}})
+	if len(res.Ts) != 0 || bm.Balance(from.Public) != 99 {
+		t.Errorf("transactions below threshold should be rejected")
+	}
+	for _, tr := range []block.Transaction{single, one, twice, foreign} {
+		if r := bm.TransactionReceipt(tr.Signature); r.Reason != ReasonMultisigThreshold {
+			t.Errorf("expected ReasonMultisigThreshold, got %v", r)
+		}
+	}
+
+	good := block.NewTransactionWithMemo(&from, to.Public, 10, 0, vdf, []byte("good"))
+	good.Cosign(&kps[2])
+	good.Cosign(&kps[0])
+	res = bm.ProcessTransactions(block.Transactions{Ts: []block.Transaction{good}})
+	if len(res.Ts) != 1 || bm.Balance(from.Public) != 89 || bm.Balance(to.Public) != 10 {
+		t.Errorf("transaction reaching threshold should be applied")
+	}
+
+	reordered := good
+	reordered.Cosignatures = []block.Cosignature{good.Cosignatures[1], good.Cosignatures[0]}
+	reordered.Signature = reordered.Cosignatures[0].Signature
+	other := block.NewTransactionWithMemo(&from, to.Public, 10, 0, vdf, []byte("good"))
+	other.Cosign(&kps[1])
+	other.Cosign(&kps[2])
+	for _, replay := range []block.Transaction{reordered, other} {
+		res = bm.ProcessTransactions(block.Transactions{Ts: []block.Transaction{replay}})
+		if !replay.VerifySignature() || len(res.Ts) != 0 || bm.Balance(from.Public) != 89 {
+			t.Errorf("transaction cosigned in other order or by other signers should not be replayed")
+		}
+	}
+
+	cosigned := block.NewTransactionWithMemo(&to, from.Public, 5, 0, vdf, []byte("cosigned"))
+	cosigned.Cosign(&to)
+	res = bm.ProcessTransactions(block.Transactions{Ts: []block.Transaction{cosigned}})
+	if r := bm.TransactionReceipt(cosigned.Signature); len(res.Ts) != 0 || r.Reason != ReasonUnexpectedCosignatures {
+		t.Errorf("cosigned transaction from single signature account should be rejected, got %v", r)
+	}
+}
+
+func TestMultisigState(t *testing.T) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 100)
+	vdf := block.VDF([]byte("state"))
+	bm.AddValidVDFValue(vdf)
+	signer := block.NewKeyPair()
+	reg := block.NewMultisigRegistration(&from, 1, []ed25519.PublicKey{signer.Public}, 0, vdf)
+	bm.ProcessTransactions(block.Transactions{Ts: []block.Transaction{reg}})
+
+	clone := bm.Clone()
+	if !clone.Equals(bm) {
+		t.Errorf("clone should keep multisig accounts")
+	}
+	restored := NewBookManager()
+	restored.ImportState(bm.ExportState())
+	if _, ok := restored.MultisigAccount(from.Public); !ok {
+		t.Errorf("state should keep multisig accounts")
+	}
+}
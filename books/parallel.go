@@ -0,0 +1,167 @@
+package books
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/log"
+	"go.uber.org/zap"
+)
+
+// parallelThreshold is the minimal number of transactions whose withdraws are applied by several threads
+const parallelThreshold = 1000
+
+// senderState is the sender account seen by a thread while it applies withdraws of its group
+type senderState struct {
+	balance int64
+	nonce   uint64
+	found   bool
+	changed bool
+}
+
+// parallelizable returns true if withdraws of transactions can be applied by several threads.
+// Withdraw of transfer and batch transfer changes only the sender account, other
+// transaction types change shared state (multisig, escrows, assets) and are applied serially.
+func parallelizable(trans []block.Transaction) bool {
+	if len(trans) < parallelThreshold {
+		return false
+	}
+	for i := range trans {
+		if trans[i].Type != block.TypeTransfer && trans[i].Type != block.TypeBatchTransfer {
+			return false
+		}
+	}
+	return true
+}
+
+// schedule partitions transactions into groups which do not conflict with each other.
+// Transactions conflict if they have the same sender or the same signature. Groups
+// are ordered by their first transaction and keep the order of their transactions.
+func schedule(trans []block.Transaction) [][]int {
+	parent := make([]int, len(trans))
+	owners := make(map[string]int, len(trans))
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	union := func(key string, i int) {
+		owner, ok := owners[key]
+		if !ok {
+			owners[key] = i
+			return
+		}
+		ri, ro := find(i), find(owner)
+		if ri < ro {
+			parent[ro] = ri
+		} else if ro < ri {
+			parent[ri] = ro
+		}
+	}
+	for i := range trans {
+		parent[i] = i
+		union("from:"+string(trans[i].From), i)
+		union("signature:"+string(trans[i].Signature), i)
+	}
+	groups := make([][]int, 0)
+	indexes := make(map[int]int)
+	for i := range trans {
+		root := find(i)
+		index, ok := indexes[root]
+		if !ok {
+			index = len(groups)
+			indexes[root] = index
+			groups = append(groups, nil)
+		}
+		groups[index] = append(groups[index], i)
+	}
+	return groups
+}
+
+// withdrawGroup applies withdraws of the group to copies of sender accounts and
+// stores outcome of every transaction in errs. It must be called under bm.lock,
+// the state is only read, so groups are applied concurrently.
+func (bm *Accounts) withdrawGroup(trans []block.Transaction, group []int, errs []error) map[string]*senderState {
+	senders := make(map[string]*senderState)
+	for _, i := range group {
+		tran := &trans[i]
+		if negativeTokens(tran) {
+			errs[i] = errNegativeTokens
+			continue
+		}
+		sender, ok := senders[string(tran.From)]
+		if !ok {
+			sender = new(senderState)
+			sender.balance, sender.found = bm.balances[string(tran.From)]
+			sender.nonce = bm.nonces[string(tran.From)]
+			senders[string(tran.From)] = sender
+		}
+		if !sender.found {
+			errs[i] = errAccountNotFound
+			continue
+		}
+		if errs[i] = bm.checkWithdraw(tran); errs[i] != nil {
+			continue
+		}
+		balance, nonce, err := bm.withdraw(tran, sender.balance, sender.nonce)
+		if errs[i] = err; err != nil {
+			continue
+		}
+		sender.balance, sender.nonce, sender.changed = balance, nonce, true
+	}
+	return senders
+}
+
+// processWithdrawsThreads applies withdraws with the same result as processWithdrawsSerial.
+// Transactions are scheduled into non conflicting groups, groups are applied
+// concurrently and then changed sender accounts are written back.
+func (bm *Accounts) processWithdrawsThreads(trans block.Transactions) block.Transactions {
+	groups := schedule(trans.Ts)
+	errs := make([]error, len(trans.Ts))
+	results := make([]map[string]*senderState, len(groups))
+	threads := runtime.NumCPU()
+
+	bm.lock.Lock()
+	var wg sync.WaitGroup
+	for t := 0; t < threads; t++ {
+		wg.Add(1)
+		go func(t int) {
+			defer wg.Done()
+			for g := t; g < len(groups); g += threads {
+				results[g] = bm.withdrawGroup(trans.Ts, groups[g], errs)
+			}
+		}(t)
+	}
+	wg.Wait()
+	for _, senders := range results {
+		for key, sender := range senders {
+			if sender.changed {
+				bm.balances[key] = sender.balance
+				if sender.nonce != bm.nonces[key] {
+					bm.nonces[key] = sender.nonce
+				}
+			}
+		}
+	}
+	res := make([]block.Transaction, 0, len(trans.Ts))
+	for i := range trans.Ts {
+		if errs[i] == nil {
+			res = append(res, trans.Ts[i])
+			bm.pendingFees += trans.Ts[i].Fee
+		}
+	}
+	atomic.AddUint64(&bm.transactionsTotal, uint64(len(res)))
+	bm.lock.Unlock()
+
+	for i := range trans.Ts {
+		bm.recordTransaction(&trans.Ts[i], errs[i])
+		if errs[i] != nil {
+			log.Error("failed to process transaction withdraw", zap.String(errs[i].Error(), trans.Ts[i].String()))
+		}
+	}
+	return block.Transactions{Ts: res}
+}
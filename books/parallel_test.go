@@ -0,0 +1,116 @@
+package books
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+// parallelBatch creates accounts and a batch of transfers between them with
+// duplicates, nonces out of order and transfers exceeding the balance
+func parallelBatch(bm *Accounts, accounts int, n int) block.Transactions {
+	r := rand.New(rand.NewSource(42))
+	vdf := block.VDF([]byte("parallel"))
+	bm.AddValidVDFValue(vdf)
+	keys := make([][]byte, accounts)
+	nonces := make([]uint64, accounts)
+	for i := range keys {
+		keys[i] = []byte("account" + strconv.Itoa(i))
+		bm.CreateAccount(keys[i], 1000)
+	}
+	trans := make([]block.Transaction, 0, n)
+	for i := 0; len(trans) < n; i++ {
+		from := r.Intn(accounts)
+		tran := block.Transaction{Type: block.TypeTransfer, From: keys[from], To: keys[r.Intn(accounts)],
+			Token: r.Int63n(300), ValidVDFValue: vdf, Signature: []byte("signature" + strconv.Itoa(i))}
+		tran.Fee = tran.Token / 10
+		switch r.Intn(10) {
+		case 0:
+			nonces[from]++
+			tran.Nonce = nonces[from] + uint64(r.Intn(2))
+		case 1:
+			tran.Type = block.TypeBatchTransfer
+			tran.Outputs = []block.Output{block.Output{To: keys[r.Intn(accounts)], Token: 5}, block.Output{To: keys[0], Token: 7}}
+		case 2:
+			if len(trans) > 0 {
+				tran = trans[r.Intn(len(trans))]
+			}
+		case 3:
+			tran.From = []byte("unknown")
+		}
+		trans = append(trans, tran)
+	}
+	return block.Transactions{Ts: trans}
+}
+
+func TestSchedule(t *testing.T) {
+	trans := []block.Transaction{
+		block.Transaction{From: []byte("a"), Signature: []byte("1")},
+		block.Transaction{From: []byte("b"), Signature: []byte("2")},
+		block.Transaction{From: []byte("c"), Signature: []byte("3")},
+		block.Transaction{From: []byte("a"), Signature: []byte("4")},
+		block.Transaction{From: []byte("d"), Signature: []byte("2")},
+	}
+	groups := schedule(trans)
+	if len(groups) != 3 || len(groups[0]) != 2 || groups[0][1] != 3 ||
+		len(groups[1]) != 2 || groups[1][0] != 1 || groups[1][1] != 4 || groups[2][0] != 2 {
+		t.Errorf("transactions with the same sender or signature should be in the same group, got %v", groups)
+	}
+}
+
+func TestProcessWithdrawsThreads(t *testing.T) {
+	bm := NewBookManager()
+	trans := parallelBatch(bm, 50, 5000)
+	if !parallelizable(trans.Ts) {
+		t.Fatalf("batch of transfers should be parallelizable")
+	}
+	bm2 := bm.Clone()
+	res := bm.processWithdrawsSerial(trans)
+	bm.processTransactionsDeposits(res)
+	res2 := bm2.ProcessTransactions(trans)
+	if len(res.Ts) == 0 || len(res.Ts) == len(trans.Ts) || !res.Equals(res2) {
+		t.Errorf("parallel withdraws should accept the same transactions in the same order, %v != %v", len(res.Ts), len(res2.Ts))
+	}
+	if !bm.Equals(bm2) || bm.PendingFees() != bm2.PendingFees() || bm.TransactionsTotal() != bm2.TransactionsTotal() {
+		t.Errorf("parallel withdraws should lead to the same state as serial ones")
+	}
+	for _, tran := range trans.Ts {
+		if bm.TransactionReceipt(tran.Signature) != bm2.TransactionReceipt(tran.Signature) {
+			t.Errorf("parallel withdraws should record the same receipts")
+			break
+		}
+	}
+}
+
+func TestParallelizable(t *testing.T) {
+	trans := block.CreateDummyTransactions(parallelThreshold)
+	if !parallelizable(trans.Ts) || parallelizable(trans.Ts[1:]) {
+		t.Errorf("only batches of at least %v transactions should be parallelizable", parallelThreshold)
+	}
+	trans.Ts[5].Type = block.TypeEscrow
+	if parallelizable(trans.Ts) {
+		t.Errorf("escrow changes shared state and should not be parallelizable")
+	}
+}
+
+func benchmarkWithdraws(b *testing.B, process func(bm *Accounts, trans block.Transactions) block.Transactions) {
+	trans := parallelBatch(NewBookManager(), 10000, 100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		bm := NewBookManager()
+		parallelBatch(bm, 10000, 0)
+		b.StartTimer()
+		process(bm, trans)
+	}
+}
+
+func BenchmarkProcessWithdrawsSerial(b *testing.B) {
+	benchmarkWithdraws(b, (*Accounts).processWithdrawsSerial)
+}
+
+func BenchmarkProcessWithdrawsThreads(b *testing.B) {
+	benchmarkWithdraws(b, (*Accounts).processWithdrawsThreads)
+}
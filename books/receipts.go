@@ -0,0 +1,248 @@
+package books
+
+import (
+	"sync"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+// maxReceipts is the number of transaction receipts kept in memory, older ones are forgotten
+const maxReceipts = 1024 * 256
+
+// Status of the transaction
+type Status = byte
+
+const (
+	// StatusUnknown means that transaction was never seen or its receipt is already forgotten
+	StatusUnknown Status = iota
+	// StatusPending means that transaction was applied but is not part of a block yet
+	StatusPending
+	// StatusConfirmed means that transaction is part of a block
+	StatusConfirmed
+	// StatusRejected means that transaction was not applied, see Reason
+	StatusRejected
+)
+
+// Reason of transaction rejection
+type Reason = byte
+
+const (
+	// ReasonNone is used for transactions which were not rejected
+	ReasonNone Reason = iota
+	// ReasonAccountNotFound is used when sender account does not exist
+	ReasonAccountNotFound
+	// ReasonInsufficientFunds is used when sender does not have enough tokens
+	ReasonInsufficientFunds
+	// ReasonNegativeTokens is used when transaction tries to transfer negative amount
+	ReasonNegativeTokens
+	// ReasonDuplicateSignature is used when transaction was already applied
+	ReasonDuplicateSignature
+	// ReasonVDFValueNotFound is used when transaction's vdf value is unknown or too old
+	ReasonVDFValueNotFound
+	// ReasonOther is used for errors without dedicated reason code
+	ReasonOther
+	// ReasonInvalidNonce is used when transaction nonce does not follow the account nonce
+	ReasonInvalidNonce
+	// ReasonMultisigThreshold is used when multisig account transaction lacks signatures
+	ReasonMultisigThreshold
+	// ReasonUnexpectedCosignatures is used when cosigned transaction is sent from single signature account
+	ReasonUnexpectedCosignatures
+	// ReasonEscrowNotFound is used when released or canceled escrow does not exist
+	ReasonEscrowNotFound
+	// ReasonEscrowLocked is used when escrow condition does not allow release or cancel yet
+	ReasonEscrowLocked
+	// ReasonAssetNotFound is used when transaction refers to unknown asset
+	ReasonAssetNotFound
+	// ReasonNotMintAuthority is used when asset is minted by somebody else than its mint authority
+	ReasonNotMintAuthority
+	// ReasonAssetSupplyOverflow is used when minting would overflow asset supply
+	ReasonAssetSupplyOverflow
+	// ReasonInsufficientStake is used when unstaked token exceeds token delegated to the validator
+	ReasonInsufficientStake
+	// ReasonInvalidEvidence is used when slash transaction carries invalid evidence
+	ReasonInvalidEvidence
+	// ReasonAlreadySlashed is used when the reported offence was already slashed
+	ReasonAlreadySlashed
+)
+
+var statusNames = map[Status]string{
+	StatusUnknown:   "unknown",
+	StatusPending:   "pending",
+	StatusConfirmed: "confirmed",
+	StatusRejected:  "rejected",
+}
+
+var reasonNames = map[Reason]string{
+	ReasonNone:               "",
+	ReasonAccountNotFound:    errAccountNotFound.Error(),
+	ReasonInsufficientFunds:  errInsufficientFunds.Error(),
+	ReasonNegativeTokens:     errNegativeTokens.Error(),
+	ReasonDuplicateSignature: errDuplicateSignatures.Error(),
+	ReasonVDFValueNotFound:   errVDFValueNotFound.Error(),
+	ReasonOther:              "other",
+	ReasonInvalidNonce:       errInvalidNonce.Error(),
+
+	ReasonMultisigThreshold:      errMultisigThreshold.Error(),
+	ReasonUnexpectedCosignatures: errUnexpectedCosignatures.Error(),
+	ReasonEscrowNotFound:         errEscrowNotFound.Error(),
+	ReasonEscrowLocked:           errEscrowLocked.Error(),
+	ReasonAssetNotFound:          errAssetNotFound.Error(),
+	ReasonNotMintAuthority:       errNotMintAuthority.Error(),
+	ReasonAssetSupplyOverflow:    errAssetSupplyOverflow.Error(),
+	ReasonInsufficientStake:      errInsufficientStake.Error(),
+	ReasonInvalidEvidence:        errInvalidEvidence.Error(),
+	ReasonAlreadySlashed:         errAlreadySlashed.Error(),
+}
+
+// StatusName returns human readable name of the status
+func StatusName(status Status) string {
+	return statusNames[status]
+}
+
+// ReasonName returns human readable description of the rejection reason
+func ReasonName(reason Reason) string {
+	return reasonNames[reason]
+}
+
+// reasonOf converts error returned by withdraw into reason code
+func reasonOf(err error) Reason {
+	switch err {
+	case nil:
+		return ReasonNone
+	case errAccountNotFound:
+		return ReasonAccountNotFound
+	case errInsufficientFunds:
+		return ReasonInsufficientFunds
+	case errNegativeTokens:
+		return ReasonNegativeTokens
+	case errDuplicateSignatures:
+		return ReasonDuplicateSignature
+	case errVDFValueNotFound:
+		return ReasonVDFValueNotFound
+	case errInvalidNonce:
+		return ReasonInvalidNonce
+	case errMultisigThreshold:
+		return ReasonMultisigThreshold
+	case errUnexpectedCosignatures:
+		return ReasonUnexpectedCosignatures
+	case errEscrowNotFound:
+		return ReasonEscrowNotFound
+	case errEscrowLocked:
+		return ReasonEscrowLocked
+	case errAssetNotFound:
+		return ReasonAssetNotFound
+	case errNotMintAuthority:
+		return ReasonNotMintAuthority
+	case errAssetSupplyOverflow:
+		return ReasonAssetSupplyOverflow
+	case errInsufficientStake:
+		return ReasonInsufficientStake
+	case errInvalidEvidence:
+		return ReasonInvalidEvidence
+	case errAlreadySlashed:
+		return ReasonAlreadySlashed
+	}
+	return ReasonOther
+}
+
+// Receipt stores outcome of the transaction. Height is the number of the block
+// which contains confirmed transaction, or the last block number when the
+// transaction was rejected.
+type Receipt struct {
+	Status Status
+	Reason Reason
+	Height uint64
+}
+
+// receipts is a bounded map from transaction signature to its receipt.
+// When it is full the oldest receipt is forgotten.
+type receipts struct {
+	records map[string]Receipt
+	order   []string
+	index   int
+	mutex   *sync.Mutex
+}
+
+func newReceipts() *receipts {
+	r := new(receipts)
+	r.records = make(map[string]Receipt)
+	r.order = make([]string, 0, 1024)
+	r.mutex = &sync.Mutex{}
+	return r
+}
+
+// set stores receipt for the signature. Rejection of a replayed transaction
+// never overrides the original pending or confirmed outcome.
+func (r *receipts) set(signature []byte, receipt Receipt) {
+	key := string(signature)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if old, ok := r.records[key]; ok {
+		if receipt.Status != StatusRejected || old.Status == StatusRejected || old.Status == StatusUnknown {
+			r.records[key] = receipt
+		}
+		return
+	}
+	if len(r.order) == maxReceipts {
+		delete(r.records, r.order[r.index])
+		r.order[r.index] = key
+		r.index = (r.index + 1) % maxReceipts
+	} else {
+		r.order = append(r.order, key)
+	}
+	r.records[key] = receipt
+}
+
+// forget marks receipt of the signature unknown, e.g. when its block is rolled back
+func (r *receipts) forget(signature []byte) {
+	key := string(signature)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.records[key]; ok {
+		r.records[key] = Receipt{}
+	}
+}
+
+// restore replaces receipt of the signature with the receipt it had before
+func (r *receipts) restore(signature []byte, receipt Receipt) {
+	key := string(signature)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.records[key]; ok {
+		r.records[key] = receipt
+	}
+}
+
+// get returns receipt of the signature
+func (r *receipts) get(signature []byte) Receipt {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.records[string(signature)]
+}
+
+// TransactionReceipt returns receipt of the transaction with given signature
+func (bm *Accounts) TransactionReceipt(signature []byte) Receipt {
+	return bm.receipts.get(signature)
+}
+
+// recordTransaction stores outcome of the transaction withdraw
+func (bm *Accounts) recordTransaction(tran *block.Transaction, err error) {
+	if err == nil {
+		bm.receipts.set(tran.Signature, Receipt{Status: StatusPending})
+		return
+	}
+	var height uint64
+	if last := bm.LastBlock(); last != nil {
+		height = last.Number
+	}
+	bm.receipts.set(tran.Signature, Receipt{Status: StatusRejected, Reason: reasonOf(err), Height: height})
+}
+
+// ConfirmTransactions marks transactions as included into block with given height
+func (bm *Accounts) ConfirmTransactions(trans *block.Transactions, height uint64) {
+	if trans != nil {
+		for i := range trans.Ts {
+			bm.receipts.set(trans.Ts[i].Signature, Receipt{Status: StatusConfirmed, Height: height})
+		}
+	}
+}
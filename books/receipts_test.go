@@ -0,0 +1,77 @@
+package books
+
+import (
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+func TestReceiptsProcessTransactions(t *testing.T) {
+	bm := NewBookManager()
+	bm.CreateAccount([]byte("acc1"), 10)
+	bm.CreateAccount([]byte("acc2"), 10)
+	vdf := []byte{1, 2, 3}
+	bm.AddValidVDFValue(vdf)
+	trans := block.Transactions{}
+	trans.Ts = make([]block.Transaction, 5)
+	trans.Ts[0] = block.Transaction{From: []byte("acc1"), To: []byte("acc2"), Token: 5, ValidVDFValue: vdf, Signature: []byte{1}}
+	trans.Ts[1] = block.Transaction{From: []byte("acc1"), To: []byte("acc2"), Token: 50, ValidVDFValue: vdf, Signature: []byte{2}}
+	trans.Ts[2] = block.Transaction{From: []byte("acc3"), To: []byte("acc2"), Token: 5, ValidVDFValue: vdf, Signature: []byte{3}}
+	trans.Ts[3] = block.Transaction{From: []byte("acc2"), To: []byte("acc1"), Token: 5, ValidVDFValue: []byte{9}, Signature: []byte{4}}
+	trans.Ts[4] = block.Transaction{From: []byte("acc1"), To: []byte("acc2"), Token: 5, ValidVDFValue: vdf, Signature: []byte{1}}
+
+	bm.ProcessTransactions(trans)
+	expected := []Receipt{
+		Receipt{Status: StatusPending},
+		Receipt{Status: StatusRejected, Reason: ReasonInsufficientFunds},
+		Receipt{Status: StatusRejected, Reason: ReasonAccountNotFound},
+		Receipt{Status: StatusRejected, Reason: ReasonVDFValueNotFound},
+	}
+	for i, r := range expected {
+		if bm.TransactionReceipt(trans.Ts[i].Signature) != r {
+			t.Errorf("transaction %v receipt %v, expected %v", i, bm.TransactionReceipt(trans.Ts[i].Signature), r)
+		}
+	}
+	if bm.TransactionReceipt([]byte{5}).Status != StatusUnknown {
+		t.Errorf("unknown transaction should have unknown status")
+	}
+}
+
+func TestReceiptsProcessBlocks(t *testing.T) {
+	bm := NewBookManager()
+	bm.CreateAccount([]byte("acc1"), 10)
+	vdf := []byte{1, 2, 3}
+	bm.AddValidVDFValue(vdf)
+	trans := block.Transactions{}
+	trans.Ts = make([]block.Transaction, 2)
+	trans.Ts[0] = block.Transaction{From: []byte("acc1"), To: []byte("acc2"), Token: 5, ValidVDFValue: vdf, Signature: []byte{1}}
+	trans.Ts[1] = block.Transaction{From: []byte("acc1"), To: []byte("acc2"), Token: 50, ValidVDFValue: vdf, Signature: []byte{2}}
+	blocks := []block.Block{block.Block{Number: 7, Val: vdf, Transactions: &trans}}
+
+	bm.ProcessBlocks(blocks)
+	if r := bm.TransactionReceipt([]byte{1}); r.Status != StatusConfirmed || r.Height != 7 {
+		t.Errorf("transaction should be confirmed at height 7, got %v", r)
+	}
+	if r := bm.TransactionReceipt([]byte{2}); r.Status != StatusRejected || r.Reason != ReasonInsufficientFunds || r.Height != 7 {
+		t.Errorf("transaction should be rejected at height 7, got %v", r)
+	}
+
+	// replayed transaction must not change the original outcome
+	bm.ProcessTransactions(block.Transactions{Ts: trans.Ts[:1]})
+	if r := bm.TransactionReceipt([]byte{1}); r.Status != StatusConfirmed {
+		t.Errorf("duplicate should not override receipt, got %v", r)
+	}
+}
+
+func TestReceiptsBounded(t *testing.T) {
+	r := newReceipts()
+	for i := 0; i < maxReceipts+10; i++ {
+		r.set([]byte{byte(i), byte(i >> 8), byte(i >> 16)}, Receipt{Status: StatusPending})
+	}
+	if len(r.records) != maxReceipts || len(r.order) != maxReceipts {
+		t.Errorf("receipts should be bounded, got %v", len(r.records))
+	}
+	if r.get([]byte{0, 0, 0}).Status != StatusUnknown || r.get([]byte{9, 0, 4}).Status != StatusPending {
+		t.Errorf("oldest receipts should be forgotten first")
+	}
+}
@@ -0,0 +1,151 @@
+package books
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/log"
+)
+
+var (
+	// errRollbackDisabled is returned when rollback is requested without checkpoints, see SetCheckpoints
+	errRollbackDisabled = errors.New("rollback is disabled")
+	// errRollbackTooDeep is returned when there is no checkpoint at or below the requested height
+	errRollbackTooDeep = errors.New("rollback height is below the oldest checkpoint")
+	// errRollbackHeight is returned when the requested height is above the last block
+	errRollbackHeight = errors.New("rollback height is above the last block")
+)
+
+// checkpoint is the account state after the block with given height
+type checkpoint struct {
+	height uint64
+	state  *State
+}
+
+// history keeps checkpoints of account state and blocks processed since the
+// oldest checkpoint. Rollback imports the closest checkpoint and replays the
+// blocks above it.
+type history struct {
+	interval    uint64
+	count       int
+	checkpoints []checkpoint
+	blocks      []block.Block
+	mutex       sync.Mutex
+}
+
+// SetCheckpoints enables rollback of processed blocks. Account state is saved
+// every interval blocks, count latest checkpoints are kept, so accounts can be
+// rolled back at least interval*(count-1) blocks. Zero interval disables rollback.
+func (bm *Accounts) SetCheckpoints(interval uint64, count int) {
+	if interval == 0 || count <= 0 {
+		bm.history = nil
+		return
+	}
+	bm.history = &history{interval: interval, count: count}
+	bm.history.checkpoints = []checkpoint{checkpoint{height: bm.height(), state: bm.ExportState()}}
+}
+
+// height returns number of the last block or zero
+func (bm *Accounts) height() uint64 {
+	if last := bm.LastBlock(); last != nil {
+		return last.Number
+	}
+	return 0
+}
+
+// recordBlock remembers processed block and saves checkpoint every interval blocks
+func (bm *Accounts) recordBlock(bl block.Block) {
+	bm.recordSettledBlock(bl, bm)
+}
+
+// recordSettledBlock works as recordBlock, but checkpoints are taken from
+// settled accounts, see Settle
+func (bm *Accounts) recordSettledBlock(bl block.Block, settled *Accounts) {
+	h := bm.history
+	if h == nil {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.blocks = append(h.blocks, bl)
+	if bl.Number < h.checkpoints[len(h.checkpoints)-1].height+h.interval {
+		return
+	}
+	h.checkpoints = append(h.checkpoints, checkpoint{height: bl.Number, state: settled.ExportState()})
+	if len(h.checkpoints) > h.count {
+		h.checkpoints = h.checkpoints[len(h.checkpoints)-h.count:]
+		oldest := h.checkpoints[0].height
+		i := 0
+		for i < len(h.blocks) && h.blocks[i].Number <= oldest {
+			i++
+		}
+		h.blocks = append([]block.Block(nil), h.blocks[i:]...)
+	}
+}
+
+// recordedHeight returns number of the last recorded block or checkpoint
+func (h *history) recordedHeight() uint64 {
+	height := h.checkpoints[len(h.checkpoints)-1].height
+	if len(h.blocks) > 0 && h.blocks[len(h.blocks)-1].Number > height {
+		height = h.blocks[len(h.blocks)-1].Number
+	}
+	return height
+}
+
+// RollbackTo returns accounts to the state after the block with given height.
+// Blocks above the height, including partially applied last block, are forgotten
+// and receipts of their transactions become unknown. The rolled back state is committed.
+func (bm *Accounts) RollbackTo(height uint64) error {
+	h := bm.history
+	if h == nil {
+		return errRollbackDisabled
+	}
+	if height > bm.height() {
+		return errRollbackHeight
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	c := len(h.checkpoints) - 1
+	for c >= 0 && h.checkpoints[c].height > height {
+		c--
+	}
+	if c < 0 {
+		return errRollbackTooDeep
+	}
+	log.Info(fmt.Sprintf("AccountManager: roll back from %v to %v", bm.height(), height))
+	if last := bm.LastBlock(); last != nil && last.Transactions != nil && last.Number > h.recordedHeight() {
+		// the last block was applied partially and was not recorded
+		for i := range last.Transactions.Ts {
+			bm.receipts.forget(last.Transactions.Ts[i].Signature)
+		}
+	}
+	bm.ImportState(h.checkpoints[c].state)
+	kept := make([]block.Block, 0, len(h.blocks))
+	for _, bl := range h.blocks {
+		switch {
+		case bl.Number <= h.checkpoints[c].height:
+			kept = append(kept, bl)
+		case bl.Number <= height:
+			bm.applyBlock(bl, false)
+			kept = append(kept, bl)
+		default:
+			for i := range bl.Transactions.Ts {
+				bm.receipts.forget(bl.Transactions.Ts[i].Signature)
+			}
+		}
+	}
+	h.blocks = kept
+	h.checkpoints = h.checkpoints[:c+1]
+	return bm.Commit()
+}
+
+// SwitchChain rolls accounts back to the given height and processes the
+// alternative chain of blocks above it
+func (bm *Accounts) SwitchChain(height uint64, blocks []block.Block) error {
+	if err := bm.RollbackTo(height); err != nil {
+		return err
+	}
+	return bm.ProcessBlocks(blocks)
+}
@@ -0,0 +1,106 @@
+package books
+
+import (
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+// rollbackChain creates accounts with genesis block and chain of n blocks with a transfer each
+func rollbackChain(from block.KeyPair, to []byte, n int) (*Accounts, []block.Block) {
+	bm := NewBookManager()
+	bm.CreateAccount(from.Public, 1000)
+	genesis := block.NewEmpty(block.VDF([]byte("rollback")), 0, 0)
+	genesis.Transactions = new(block.Transactions)
+	bm.ProcessBlocks([]block.Block{genesis})
+	blocks := make([]block.Block, n)
+	previous := genesis
+	for i := range blocks {
+		trans := block.Transactions{Ts: []block.Transaction{
+			block.NewTransactionWithNonce(&from, to, int64(10+i), 1, genesis.Val, uint64(i+1))}}
+		blocks[i] = sealed(block.New(previous.Val, previous.Number, 0, &trans), &previous)
+		previous = blocks[i]
+	}
+	return bm, blocks
+}
+
+func TestRollbackTo(t *testing.T) {
+	from := block.NewKeyPair()
+	to := block.NewKeyPair()
+	bm, blocks := rollbackChain(from, to.Public, 10)
+	expected, _ := rollbackChain(from, to.Public, 0)
+	if err := bm.RollbackTo(1); err != errRollbackDisabled {
+		t.Errorf("expected errRollbackDisabled, got %v", err)
+	}
+	bm.SetCheckpoints(3, 2)
+	for i := range blocks {
+		bm.ProcessBlocks(blocks[i : i+1])
+	}
+	expected.ProcessBlocks(blocks[:6])
+
+	if err := bm.RollbackTo(12); err != errRollbackHeight {
+		t.Errorf("expected errRollbackHeight, got %v", err)
+	}
+	if err := bm.RollbackTo(4); err != errRollbackTooDeep {
+		t.Errorf("expected errRollbackTooDeep, got %v", err)
+	}
+	if err := bm.RollbackTo(7); err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+	if !bm.Equals(expected) || bm.LastBlock().Number != 7 || bm.Nonce(from.Public) != 6 {
+		t.Errorf("rolled back accounts should be equal to accounts which processed first blocks only")
+	}
+	if bm.TransactionReceipt(blocks[7].Transactions.Ts[0].Signature).Status != StatusUnknown ||
+		bm.TransactionReceipt(blocks[5].Transactions.Ts[0].Signature).Status != StatusConfirmed {
+		t.Errorf("receipts of rolled back transactions should be unknown")
+	}
+
+	if err := bm.ProcessBlocks(blocks[6:]); err != nil || bm.LastBlock().Number != 11 || bm.Nonce(from.Public) != 10 {
+		t.Errorf("rolled back blocks should be processed again")
+	}
+}
+
+func TestRollbackToPartiallyApplied(t *testing.T) {
+	from := block.NewKeyPair()
+	to := block.NewKeyPair()
+	bm, blocks := rollbackChain(from, to.Public, 3)
+	bm.SetCheckpoints(2, 2)
+	bm.ProcessBlocks(blocks[:2])
+	bm.applyBlock(blocks[2], false)
+	if err := bm.RollbackTo(blocks[1].Number); err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+	if bm.TransactionReceipt(blocks[2].Transactions.Ts[0].Signature).Status != StatusUnknown ||
+		bm.TransactionReceipt(blocks[1].Transactions.Ts[0].Signature).Status != StatusConfirmed {
+		t.Errorf("receipts of partially applied block should be unknown")
+	}
+}
+
+func TestSwitchChain(t *testing.T) {
+	from := block.NewKeyPair()
+	to := block.NewKeyPair()
+	fork := block.NewKeyPair()
+	bm, blocks := rollbackChain(from, to.Public, 5)
+	expected, alternative := rollbackChain(from, fork.Public, 5)
+	bm.SetCheckpoints(2, 10)
+	bm.SetStrictVerification(true)
+	if err := bm.ProcessBlocks(blocks); err != nil {
+		t.Fatalf("chain should be processed: %v", err)
+	}
+	if err := bm.ProcessBlocks(alternative[2:3]); err == nil {
+		t.Errorf("fork block should not be accepted on top of the chain")
+	}
+
+	// alternative chain forks after the second block
+	chain := append([]block.Block(nil), blocks[:2]...)
+	for i := 2; i < len(alternative); i++ {
+		chain = append(chain, sealed(block.New(chain[i-1].Val, chain[i-1].Number, 0, alternative[i].Transactions), &chain[i-1]))
+	}
+	expected.ProcessBlocks(chain)
+	if err := bm.SwitchChain(3, chain[2:]); err != nil {
+		t.Fatalf("alternative chain should be processed: %v", err)
+	}
+	if !bm.Equals(expected) || bm.Balance(to.Public) != 19 || bm.Balance(fork.Public) != 36 {
+		t.Errorf("accounts should follow the alternative chain, balances %v and %v", bm.Balance(to.Public), bm.Balance(fork.Public))
+	}
+}
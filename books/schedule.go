@@ -0,0 +1,217 @@
+package books
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"golang.org/x/crypto/ed25519"
+)
+
+// LeaderSchedule assigns the producer role to nodes in slots of SlotLength
+// blocks. Slots are grouped into epochs of EpochLength slots, leaders of an
+// epoch are derived from the node set and the seed of the epoch, which is the
+// vdf value of the last block before the epoch. Nodes are weighted by their
+// stake as validators at the end of the previous epoch, when no node has stake
+// all nodes have equal weight. Every node which processed the same blocks
+// computes the same leaders.
+type LeaderSchedule struct {
+	nodes       []ed25519.PublicKey
+	slotLength  uint64
+	epochLength uint64
+}
+
+// NewLeaderSchedule creates schedule over the given nodes, nodes are sorted
+// by public key so that their order does not matter
+func NewLeaderSchedule(nodes []ed25519.PublicKey, slotLength uint64, epochLength uint64) *LeaderSchedule {
+	if slotLength == 0 {
+		slotLength = 1
+	}
+	if epochLength == 0 {
+		epochLength = 1
+	}
+	sorted := make([]ed25519.PublicKey, 0, len(nodes))
+	for _, node := range nodes {
+		sorted = append(sorted, append(ed25519.PublicKey(nil), node...))
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+	return &LeaderSchedule{nodes: sorted, slotLength: slotLength, epochLength: epochLength}
+}
+
+// Nodes returns sorted nodes of the schedule
+func (s *LeaderSchedule) Nodes() []ed25519.PublicKey {
+	return s.nodes
+}
+
+// Slot returns slot of the block height
+func (s *LeaderSchedule) Slot(height uint64) uint64 {
+	return height / s.slotLength
+}
+
+// SlotEnd returns height of the last block in the slot of the given height
+func (s *LeaderSchedule) SlotEnd(height uint64) uint64 {
+	return (s.Slot(height)+1)*s.slotLength - 1
+}
+
+// Epoch returns epoch of the block height
+func (s *LeaderSchedule) Epoch(height uint64) uint64 {
+	return s.Slot(height) / s.epochLength
+}
+
+// EpochStart returns height of the first block of the epoch
+func (s *LeaderSchedule) EpochStart(epoch uint64) uint64 {
+	return epoch * s.epochLength * s.slotLength
+}
+
+// Leader returns producer of the block height for the seed of its epoch,
+// it is nil when schedule has no nodes
+func (s *LeaderSchedule) Leader(seed block.VDFValue, height uint64) ed25519.PublicKey {
+	if len(s.nodes) == 0 {
+		return nil
+	}
+	data := make([]byte, len(seed)+8)
+	copy(data, seed)
+	binary.BigEndian.PutUint64(data[len(seed):], s.Slot(height))
+	hash := sha256.Sum256(data)
+	return s.nodes[binary.BigEndian.Uint64(hash[:8])%uint64(len(s.nodes))]
+}
+
+// WeightedLeader returns producer of the block height for the seed of its
+// epoch, probability of a node to be chosen is proportional to its stake.
+// It falls back to Leader when no node has stake.
+func (s *LeaderSchedule) WeightedLeader(seed block.VDFValue, height uint64, stakes map[string]int64) ed25519.PublicKey {
+	total := uint64(0)
+	for _, node := range s.nodes {
+		if stakes[string(node)] > 0 {
+			total += uint64(stakes[string(node)])
+		}
+	}
+	if total == 0 {
+		return s.Leader(seed, height)
+	}
+	data := make([]byte, len(seed)+8)
+	copy(data, seed)
+	binary.BigEndian.PutUint64(data[len(seed):], s.Slot(height))
+	hash := sha256.Sum256(data)
+	target := binary.BigEndian.Uint64(hash[:8]) % total
+	for _, node := range s.nodes {
+		if stakes[string(node)] <= 0 {
+			continue
+		}
+		if target < uint64(stakes[string(node)]) {
+			return node
+		}
+		target -= uint64(stakes[string(node)])
+	}
+	return nil
+}
+
+// SetLeaderSchedule sets schedule of block producers. Seeds of epochs are
+// recorded while blocks are processed, seed of the first epoch is empty.
+func (bm *Accounts) SetLeaderSchedule(schedule *LeaderSchedule) {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	bm.schedule = schedule
+	if bm.epochSeeds == nil {
+		bm.epochSeeds = make(map[uint64]block.VDFValue)
+	}
+	if bm.epochStakes == nil {
+		bm.epochStakes = make(map[uint64]map[string]int64)
+	}
+}
+
+// LeaderSchedule returns schedule of block producers, nil if there is none
+func (bm *Accounts) LeaderSchedule() *LeaderSchedule {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	return bm.schedule
+}
+
+// Leader returns producer of the block height. It is nil when there is no
+// schedule or the seed of the height's epoch is not known yet.
+func (bm *Accounts) Leader(height uint64) ed25519.PublicKey {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	return bm.leader(height)
+}
+
+func (bm *Accounts) leader(height uint64) ed25519.PublicKey {
+	if bm.schedule == nil {
+		return nil
+	}
+	epoch := bm.schedule.Epoch(height)
+	seed, ok := bm.epochSeeds[epoch]
+	if !ok && epoch != 0 {
+		return nil
+	}
+	return bm.schedule.WeightedLeader(seed, height, bm.epochStakes[epoch])
+}
+
+// recordEpochSeed keeps vdf value of the last block before an epoch as the
+// seed of the epoch, seeds of the current and the previous epoch are kept
+func (bm *Accounts) recordEpochSeed(bl *block.Block) {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	if bm.schedule == nil {
+		return
+	}
+	epoch := bm.schedule.Epoch(bl.Number + 1)
+	if bm.schedule.EpochStart(epoch) != bl.Number+1 {
+		return
+	}
+	bm.epochSeeds[epoch] = append(block.VDFValue(nil), bl.Val...)
+	for e := range bm.epochSeeds {
+		if e+1 < epoch {
+			delete(bm.epochSeeds, e)
+		}
+	}
+}
+
+// recordEpochStakes keeps stakes of validators the schedule nodes stand for
+// after the last block before an epoch as weights of the epoch, weights of the
+// current and the previous epoch are kept. Must be called under bm.lock
+func (bm *Accounts) recordEpochStakes(height uint64) {
+	if bm.schedule == nil {
+		return
+	}
+	epoch := bm.schedule.Epoch(height + 1)
+	if bm.schedule.EpochStart(epoch) != height+1 {
+		return
+	}
+	validators := bm.validatorStakes()
+	stakes := make(map[string]int64)
+	for _, node := range bm.schedule.Nodes() {
+		if stake := validators[bm.validatorOf(string(node))]; stake > 0 {
+			stakes[string(node)] = stake
+		}
+	}
+	bm.epochStakes[epoch] = stakes
+	for e := range bm.epochStakes {
+		if e+1 < epoch {
+			delete(bm.epochStakes, e)
+		}
+	}
+}
+
+func copyEpochStakes(m map[uint64]map[string]int64) map[uint64]map[string]int64 {
+	res := make(map[uint64]map[string]int64, len(m))
+	for k, v := range m {
+		res[k] = make(map[string]int64, len(v))
+		for node, stake := range v {
+			res[k][node] = stake
+		}
+	}
+	return res
+}
+
+func copyEpochSeeds(seeds map[uint64]block.VDFValue) map[uint64]block.VDFValue {
+	res := make(map[uint64]block.VDFValue, len(seeds))
+	for k, v := range seeds {
+		res[k] = append(block.VDFValue(nil), v...)
+	}
+	return res
+}
@@ -0,0 +1,91 @@
+package books
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"golang.org/x/crypto/ed25519"
+)
+
+func scheduleNodes(n int) []ed25519.PublicKey {
+	nodes := make([]ed25519.PublicKey, n)
+	for i := range nodes {
+		nodes[i] = block.NewKeyPair().Public
+	}
+	return nodes
+}
+
+func TestLeaderSchedule(t *testing.T) {
+	nodes := scheduleNodes(4)
+	schedule := NewLeaderSchedule(nodes, 10, 5)
+	reversed := NewLeaderSchedule([]ed25519.PublicKey{nodes[3], nodes[2], nodes[1], nodes[0]}, 10, 5)
+	seed := block.VDF([]byte("seed"))
+	leaders := make(map[string]int)
+	for height := uint64(0); height < 1000; height++ {
+		leader := schedule.Leader(seed, height)
+		if !bytes.Equal(leader, reversed.Leader(seed, height)) {
+			t.Fatalf("leader of %v should not depend on order of nodes", height)
+		}
+		if height%10 != 0 && !bytes.Equal(leader, schedule.Leader(seed, height-1)) {
+			t.Fatalf("leader should not change inside a slot, height %v", height)
+		}
+		leaders[string(leader)]++
+	}
+	if len(leaders) != len(nodes) {
+		t.Errorf("every node should lead some slot, got %v leaders", len(leaders))
+	}
+	if schedule.SlotEnd(23) != 29 || schedule.Epoch(49) != 0 || schedule.Epoch(50) != 1 || schedule.EpochStart(2) != 100 {
+		t.Errorf("unexpected slot boundaries")
+	}
+	same := 0
+	other := block.VDF([]byte("other"))
+	for height := uint64(0); height < 1000; height += 10 {
+		if bytes.Equal(schedule.Leader(seed, height), schedule.Leader(other, height)) {
+			same++
+		}
+	}
+	if same == 100 {
+		t.Errorf("leaders should depend on the seed")
+	}
+	if NewLeaderSchedule(nil, 10, 5).Leader(seed, 1) != nil {
+		t.Errorf("schedule without nodes should have no leader")
+	}
+}
+
+func TestAccountsLeader(t *testing.T) {
+	bm := NewBookManager()
+	if bm.Leader(1) != nil {
+		t.Errorf("accounts without schedule should have no leader")
+	}
+	schedule := NewLeaderSchedule(scheduleNodes(3), 2, 2)
+	bm.SetLeaderSchedule(schedule)
+	if !bytes.Equal(bm.Leader(3), schedule.Leader(nil, 3)) {
+		t.Errorf("first epoch should use empty seed")
+	}
+	if bm.Leader(4) != nil {
+		t.Errorf("leader of the next epoch should not be known before its seed")
+	}
+	val := block.VDF([]byte("epoch"))
+	for number := uint64(1); number <= 3; number++ {
+		bm.UpdateLastBlock(&block.Block{Number: number, Val: val, Transactions: &block.Transactions{}})
+	}
+	if !bytes.Equal(bm.Leader(4), schedule.Leader(val, 4)) || !bytes.Equal(bm.Leader(3), schedule.Leader(nil, 3)) {
+		t.Errorf("seed of the epoch should be vdf value of the last block before it")
+	}
+	restored := NewBookManager()
+	restored.SetLeaderSchedule(schedule)
+	restored.ImportState(bm.ExportState())
+	if !bytes.Equal(restored.Leader(4), bm.Leader(4)) || !restored.Equals(bm) {
+		t.Errorf("epoch seeds should be part of the state")
+	}
+
+	kp := block.NewKeyPair()
+	bm.CreateAccount(kp.Public, 100)
+	trans := block.Transactions{Ts: []block.Transaction{block.NewTransaction(&kp, kp.Public, 10, 3, val)}}
+	bm.ProcessTransactions(trans)
+	bm.CollectFees(&trans, 5)
+	if bm.FeesEarned(bm.Leader(5)) != 3 {
+		t.Errorf("fees should be credited to the scheduled producer")
+	}
+}
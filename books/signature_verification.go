@@ -11,6 +11,10 @@ func SignatureVerification(packetReceiver <-chan *network.Packets) <-chan *netwo
 	go func(out chan<- *network.Packets, packetReceiver <-chan *network.Packets) {
 		for {
 			packets := network.PacketBatch(packetReceiver)
+			if packets == nil {
+				close(out)
+				return
+			}
 			packets, _ = verifyPackets(packets)
 			// log.Info(fmt.Sprintf("SignatureVerification: %v packets verified", num))
 			for _, packet := range packets {
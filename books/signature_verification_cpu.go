@@ -3,8 +3,8 @@
 package books
 
 import (
+	"github.com/Ansiblock/Ansiblock/block"
 	"github.com/Ansiblock/Ansiblock/network"
-	"golang.org/x/crypto/ed25519"
 )
 
 // type empty struct{}
@@ -111,7 +111,7 @@ func verifyPackets(packets []*network.Packets) ([]*network.Packets, int) {
 		go func(packet *network.Packets) {
 			for j := range packet.Ps {
 				go func(pa *network.Packet) {
-					if !ed25519.Verify(pa.Data[64:96], pa.Data[64:176], pa.Data[:64]) {
+					if int(pa.Size) > len(pa.Data) || !block.VerifySignatures(pa.Data[:pa.Size]) {
 						pa.Size = 0
 						res <- 0
 					} else {
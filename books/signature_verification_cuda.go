@@ -32,6 +32,7 @@ import "C"
 import (
 	"unsafe"
 
+	"github.com/Ansiblock/Ansiblock/block"
 	"github.com/Ansiblock/Ansiblock/log"
 	"github.com/Ansiblock/Ansiblock/network"
 	"go.uber.org/zap"
@@ -41,8 +42,8 @@ const txOffset = 0
 const publicKeyOffset = 64
 const signatureOffset = 0
 const signedMessageOffset = 64
-const signedMessageLenOffset = 256
-const packetDataSize = 256
+const signedMessageLenOffset = network.PacketDataSize
+const packetDataSize = network.PacketDataSize
 
 func init() {
 	_, err := C.ed25519_set_verbose(true)
@@ -116,6 +117,13 @@ func verifyPackets(packets []*network.Packets) ([]*network.Packets, int) {
 	index := 0
 	for i := 0; i < len(packets); i++ {
 		for j := 0; j < len(packets[i].Ps); j++ {
+			// GPU verifies only the first signature, multisigned transactions are verified on CPU
+			if data := packets[i].Ps[j].Data[:packets[i].Ps[j].Size]; block.IsMultisigned(data) {
+				out[index] = 0
+				if block.VerifySignatures(data) {
+					out[index] = 1
+				}
+			}
 			if out[index] == 0 {
 				packets[i].Ps[j].Size = 0
 			} else {
@@ -81,6 +81,29 @@ func TestSigVerifyWithDemagedTransactions(t *testing.T) {
 
 }
 
+func TestSigVerifyCosigned(t *testing.T) {
+	var in = make(chan *network.Packets)
+	out := SignatureVerification(in)
+	from := block.NewKeyPair()
+	signer1 := block.NewKeyPair()
+	signer2 := block.NewKeyPair()
+	tr1 := block.NewTransactionWithMemo(&from, from.Public, 2, 0, block.VDF([]byte{1}), []byte("ok"))
+	tr1.Cosign(&signer1)
+	tr1.Cosign(&signer2)
+	tr2 := block.NewTransactionWithMemo(&from, from.Public, 2, 0, block.VDF([]byte{1}), []byte("bad"))
+	tr2.Cosign(&signer1)
+	tr2.Cosign(&signer2)
+	tr2.Cosignatures[1].Signature[0] ^= 1
+	trs1 := block.Transactions{Ts: []block.Transaction{tr1, tr2}}
+	in <- trs1.ToPackets(nil)
+	var trsOut block.Transactions
+	trsOut.FromPackets(<-out)
+	trsOut = filter(trsOut)
+	if len(trsOut.Ts) != 1 || !trsOut.Ts[0].Equals(tr1) {
+		t.Errorf("transaction with damaged cosignature was not filtered out %v", trsOut)
+	}
+}
+
 func TestSigVerifyWithTwoPackets(t *testing.T) {
 	var in = make(chan *network.Packets)
 	out := SignatureVerification(in)
@@ -0,0 +1,99 @@
+package books
+
+import (
+	"errors"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+// SlashPercent is the part of stake burned for equivocation of a node, stake of
+// the validator the node stands for is burned, see applyBindNode. Unbonding
+// tokens delegated to the validator are slashed too
+const SlashPercent = 50
+
+var (
+	// errInvalidEvidence is returned when slash transaction carries invalid evidence
+	errInvalidEvidence = errors.New("invalid evidence")
+	// errAlreadySlashed is returned when the offence was already slashed
+	errAlreadySlashed = errors.New("offence is already slashed")
+)
+
+// checkSlash checks that slash transaction reports valid evidence of a new offence.
+// Must be called under bm.lock
+func (bm *Accounts) checkSlash(tran *block.Transaction) error {
+	if tran.Type != block.TypeSlash {
+		return nil
+	}
+	if tran.Evidence == nil || !tran.Evidence.Verify() {
+		return errInvalidEvidence
+	}
+	if bm.slashed[string(tran.Evidence.ID())] {
+		return errAlreadySlashed
+	}
+	return nil
+}
+
+// applySlash burns SlashPercent of every stake and unbond delegated to the
+// validator which bound the offender node, or to the offender itself when it
+// is not bound. Must be called under bm.lock
+func (bm *Accounts) applySlash(tran *block.Transaction) {
+	bm.slashed[string(tran.Evidence.ID())] = true
+	offender := string(tran.Evidence.Offender())
+	if validator := bm.validatorOf(offender); validator != "" {
+		offender = validator
+	}
+	for key, token := range bm.stakes {
+		if key.Validator != offender {
+			continue
+		}
+		cut := token * SlashPercent / 100
+		bm.burned += cut
+		if token == cut {
+			delete(bm.stakes, key)
+		} else {
+			bm.stakes[key] = token - cut
+		}
+	}
+	for sig, unbond := range bm.unbonds {
+		if string(unbond.Validator) != offender {
+			continue
+		}
+		cut := unbond.Token * SlashPercent / 100
+		bm.burned += cut
+		unbond.Token -= cut
+		bm.unbonds[sig] = unbond
+	}
+}
+
+// Slashed returns true if the offence of the evidence was already slashed
+func (bm *Accounts) Slashed(evidence *block.Evidence) bool {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	return bm.slashed[string(evidence.ID())]
+}
+
+// Burned returns number of tokens burned by slashing
+func (bm *Accounts) Burned() int64 {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	return bm.burned
+}
+
+// TakeEvidence returns equivocations detected by finality since the last call
+func (bm *Accounts) TakeEvidence() []block.Evidence {
+	bm.lock.Lock()
+	finality := bm.finality
+	bm.lock.Unlock()
+	if finality == nil {
+		return nil
+	}
+	return finality.TakeEvidence()
+}
+
+func copySlashed(m map[string]bool) map[string]bool {
+	res := make(map[string]bool, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,91 @@
+package books
+
+import (
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+func TestFinalityEquivocation(t *testing.T) {
+	kp := block.NewKeyPair()
+	finality := NewFinality(stakeVoters{string(kp.Public): 1, "other": 1})
+	first := block.NewVote(&kp, 3, block.VDF([]byte("first")))
+	second := block.NewVote(&kp, 3, block.VDF([]byte("second")))
+	finality.AddVote(&first)
+	if _, err := finality.AddVote(&second); err != errEquivocation {
+		t.Errorf("expected errEquivocation, got %v", err)
+	}
+	evidence := finality.TakeEvidence()
+	if len(evidence) != 1 || !evidence[0].Verify() || len(finality.TakeEvidence()) != 0 {
+		t.Errorf("conflicting votes should be taken as evidence once, got %v", evidence)
+	}
+}
+
+func TestSlash(t *testing.T) {
+	bm := NewBookManager()
+	delegator := block.NewKeyPair()
+	offender := block.NewKeyPair()
+	reporter := block.NewKeyPair()
+	bm.CreateAccount(delegator.Public, 100)
+	vdf := block.VDF([]byte("slash"))
+	bm.AddValidVDFValue(vdf)
+	confirmOne(bm, block.NewStake(&delegator, offender.Public, 61, 1, vdf))
+	unstake := block.NewUnstake(&delegator, offender.Public, 20, 0, vdf)
+	confirmOne(bm, unstake)
+	supply := bm.TotalSupply()
+
+	evidence := block.NewEvidence(block.NewVote(&offender, 5, block.VDF([]byte{1})), block.NewVote(&offender, 5, block.VDF([]byte{2})))
+	if bm.Slashed(&evidence) {
+		t.Errorf("offence should not be slashed before it is reported")
+	}
+	slash := block.NewSlash(&reporter, evidence, 0, vdf)
+	if r := confirmOne(bm, slash); r != ReasonNone {
+		t.Errorf("slash should be accepted from reporter without account, got %v", r)
+	}
+	unbond, _ := bm.UnbondOf(unstake.Signature)
+	if bm.ValidatorStake(offender.Public) != 20 || unbond.Token != 10 || bm.Burned() != 30 || bm.TotalSupply() != supply-30 {
+		t.Errorf("half of stake and unbond should be burned, stake %v, unbond %v, burned %v",
+			bm.ValidatorStake(offender.Public), unbond.Token, bm.Burned())
+	}
+	if !bm.Slashed(&evidence) {
+		t.Errorf("offence should be slashed")
+	}
+	again := block.NewEvidence(evidence.First, block.NewVote(&offender, 5, block.VDF([]byte{3})))
+	if r := confirmOne(bm, block.NewSlash(&reporter, again, 0, vdf)); r != ReasonAlreadySlashed || bm.ValidatorStake(offender.Public) != 20 {
+		t.Errorf("offence should be slashed once, got %v", r)
+	}
+	invalid := block.NewEvidence(evidence.First, evidence.First)
+	if r := confirmOne(bm, block.NewSlash(&reporter, invalid, 0, vdf)); r != ReasonInvalidEvidence {
+		t.Errorf("expected ReasonInvalidEvidence, got %v", r)
+	}
+
+	restored := NewBookManager()
+	restored.ImportState(bm.ExportState())
+	if !restored.Equals(bm) || !restored.Slashed(&evidence) || restored.Burned() != 30 {
+		t.Errorf("slashing should be part of the state")
+	}
+}
+
+func TestSlashBoundNode(t *testing.T) {
+	bm := NewBookManager()
+	delegator := block.NewKeyPair()
+	validator := block.NewKeyPair()
+	node := block.NewKeyPair()
+	reporter := block.NewKeyPair()
+	bm.CreateAccount(delegator.Public, 100)
+	bm.CreateAccount(validator.Public, 10)
+	vdf := block.VDF([]byte("slash node"))
+	bm.AddValidVDFValue(vdf)
+	confirmOne(bm, block.NewStake(&delegator, validator.Public, 41, 1, vdf))
+	confirmOne(bm, block.NewStake(&delegator, node.Public, 21, 1, vdf))
+	confirmOne(bm, block.NewBindNode(&validator, node.Public, 1, vdf))
+
+	evidence := block.NewEvidence(block.NewVote(&node, 5, block.VDF([]byte{1})), block.NewVote(&node, 5, block.VDF([]byte{2})))
+	if r := confirmOne(bm, block.NewSlash(&reporter, evidence, 0, vdf)); r != ReasonNone {
+		t.Errorf("slash should be accepted, got %v", r)
+	}
+	if bm.ValidatorStake(validator.Public) != 20 || bm.ValidatorStake(node.Public) != 20 || bm.Burned() != 20 {
+		t.Errorf("stake of the validator which bound the node should be burned, validator %v, node %v",
+			bm.ValidatorStake(validator.Public), bm.ValidatorStake(node.Public))
+	}
+}
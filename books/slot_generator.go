@@ -0,0 +1,114 @@
+package books
+
+import (
+	"container/heap"
+	"fmt"
+	"time"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/log"
+)
+
+// SlotGenerator produces blocks of the producer's slot, from the block after
+// the last one up to the block with height end. Every block is made of the
+// transactions with the highest fee which fit into it, transactions which do
+// not fit stay in the mempool for the next block. Empty block is produced when
+// no transaction was accepted during tick, so the slot ends in time.
+// VDF scheme of the chain is iterated while the mempool is waited for and
+// every block carries its proof, proofs are computed concurrently with
+// iterations for the next block.
+// Output channel is closed after the last block of the slot.
+func SlotGenerator(bm *Accounts, mempool *Mempool, end uint64, tick time.Duration) <-chan block.Block {
+	out := make(chan block.Block)
+	proven := make(chan slotBlock, 1)
+	go func() {
+		defer close(out)
+		for p := range proven {
+			p.block.VDFProof = <-p.proof
+			out <- p.block
+		}
+	}()
+	go func() {
+		defer close(proven)
+		last := bm.LastBlock()
+		if last == nil {
+			log.Error("SlotGenerator: no last block to build the slot on")
+			return
+		}
+		scheme := bm.VDFScheme()
+		evaluator := scheme.NewEvaluator(last.Val)
+		number := last.Number
+		for number < end {
+			transactions := mempool.takeBlock(bm, tick, evaluator)
+			var nb block.Block
+			if transactions.Count() == 0 {
+				nb = block.NewEmpty(evaluator.Value(), number, evaluator.Iterations())
+				nb.Transactions = transactions
+			} else {
+				nb = block.New(evaluator.Value(), number, evaluator.Iterations(), transactions)
+			}
+			log.Info(fmt.Sprintf("SlotGenerator: block %v with %v transactions", nb.Number, transactions.Count()))
+			proven <- proveSlotBlock(nb, evaluator)
+			evaluator = scheme.NewEvaluator(nb.Val)
+			number = nb.Number
+		}
+	}()
+	return out
+}
+
+// slotBlock is a block of the slot waiting for its VDF proof
+type slotBlock struct {
+	block block.Block
+	proof <-chan []byte
+}
+
+// proveSlotBlock computes proof of the evaluator in the background, the
+// evaluator must not be stepped afterwards
+func proveSlotBlock(bl block.Block, evaluator block.VDFEvaluator) slotBlock {
+	proof := make(chan []byte, 1)
+	go func() {
+		proof <- evaluator.Proof()
+	}()
+	return slotBlock{block: bl, proof: proof}
+}
+
+// takeBlock processes transactions from the mempool until some of them are
+// accepted or tick passes, and returns accepted transactions of a single block.
+// The evaluator is stepped while the mempool is empty.
+func (m *Mempool) takeBlock(bm *Accounts, tick time.Duration, evaluator block.VDFEvaluator) *block.Transactions {
+	deadline := time.Now().Add(tick)
+	for {
+		entries := m.take(mempoolBatchSize)
+		if len(entries) > 0 {
+			fit := block.BlockFit(&block.Transactions{Ts: transactionsOf(entries)})
+			m.putBack(entries[fit:])
+			entries = entries[:fit]
+			res := bm.ProcessTransactions(block.Transactions{Ts: transactionsOf(entries)})
+			if len(res.Ts) < len(entries) {
+				m.holdBack(m.retryable(entries, res.Ts))
+			}
+			if len(res.Ts) > 0 {
+				return &res
+			}
+		}
+		if !time.Now().Before(deadline) {
+			return new(block.Transactions)
+		}
+		for poll := time.Now().Add(mempoolPollInterval); time.Now().Before(poll); {
+			evaluator.Step()
+		}
+	}
+}
+
+// putBack returns taken entries into the mempool keeping their order
+func (m *Mempool) putBack(entries []*mempoolEntry) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, entry := range entries {
+		key := string(entry.tran.Signature)
+		if _, ok := m.entries[key]; !ok {
+			heap.Push(&m.queue, entry)
+			m.entries[key] = entry
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package books
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+func TestSlotGenerator(t *testing.T) {
+	bm := NewBookManager()
+	kp := block.NewKeyPair()
+	bm.CreateAccount(kp.Public, 1000000)
+	val := block.VDF([]byte("slot"))
+	bm.UpdateLastBlock(&block.Block{Number: 4, Val: val, Transactions: &block.Transactions{}})
+	mempool := NewMempool(bm, 10000)
+	trans := make([]block.Transaction, 300)
+	for i := range trans {
+		trans[i] = block.NewTransaction(&kp, kp.Public, int64(i+1), 0, val)
+	}
+	mempool.Add(trans)
+	fit := block.BlockFit(&block.Transactions{Ts: trans})
+
+	blocks := make([]block.Block, 0)
+	for bl := range SlotGenerator(bm, mempool, 9, 20*time.Millisecond) {
+		bm.UpdateLastBlock(&bl)
+		blocks = append(blocks, bl)
+	}
+	if len(blocks) != 5 {
+		t.Fatalf("slot from 5 to 9 should have 5 blocks, got %v", len(blocks))
+	}
+	total := 0
+	previous := val
+	for i, bl := range blocks {
+		if bl.Number != uint64(5+i) || !bl.Verify(block.SHA256VDF{}, previous) {
+			t.Errorf("block %v should follow the previous one", bl.Number)
+		}
+		if int(bl.Transactions.Count()) > fit {
+			t.Errorf("block %v has more transactions than fit into a block", bl.Number)
+		}
+		previous = bl.Val
+		total += int(bl.Transactions.Count())
+	}
+	if int(blocks[0].Transactions.Count()) != fit || total != len(trans) || mempool.Len() != 0 {
+		t.Errorf("all transactions should be produced in full blocks, got %v of %v", total, len(trans))
+	}
+	if blocks[4].Transactions.Count() != 0 {
+		t.Errorf("slot should end with empty blocks when mempool is empty")
+	}
+	if blocks[4].Count == 0 {
+		t.Errorf("VDF should be iterated while the mempool is empty")
+	}
+}
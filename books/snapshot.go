@@ -0,0 +1,259 @@
+package books
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/merkle"
+	"github.com/Ansiblock/Ansiblock/utils"
+)
+
+// errSnapshotRoot is returned when snapshot content does not match its root
+var errSnapshotRoot = errors.New("snapshot does not match its root")
+
+// AccountEntry is a single account of a snapshot
+type AccountEntry struct {
+	PublicKey []byte
+	Balance   int64
+	Nonce     uint64
+}
+
+// Snapshot is a deterministic image of the state at a given block.
+// Accounts are sorted by public key. Root commits the state root, see
+// StateRoot, together with the block and counters of the snapshot.
+type Snapshot struct {
+	Height            uint64
+	Count             uint64
+	Val               block.VDFValue
+	Header            block.Header
+	TransactionsTotal uint64
+	BlocksTotal       uint64
+	VDFCount          uint64
+	Accounts          []AccountEntry
+	Multisig          map[string]Multisig
+	Escrows           map[string]Escrow
+	Assets            map[string]Asset
+	AssetBalances     map[AssetBalanceKey]int64
+	PendingFees       int64
+	FeesEarned        map[string]int64
+	EpochSeeds        map[uint64]block.VDFValue
+	EpochStakes       map[uint64]map[string]int64
+	Stakes            map[StakeKey]int64
+	Unbonds           map[string]Unbond
+	Bindings          map[string]string
+	Slashed           map[string]bool
+	Burned            int64
+	Root              []byte
+}
+
+// AccountLeaf returns Merkle leaf data of an account: public key followed by balance
+// and nonce. Nonce is omitted when it is zero, so accounts which never used
+// nonces keep their leaves.
+func AccountLeaf(publicKey []byte, balance int64, nonce uint64) []byte {
+	leaf := make([]byte, 0, len(publicKey)+16)
+	leaf = append(leaf, publicKey...)
+	leaf = append(leaf, utils.Uint64toByte(uint64(balance))...)
+	if nonce != 0 {
+		leaf = append(leaf, utils.Uint64toByte(nonce)...)
+	}
+	return leaf
+}
+
+func accountLeaves(accounts []AccountEntry) [][]byte {
+	leaves := make([][]byte, len(accounts))
+	for i, acc := range accounts {
+		leaves[i] = AccountLeaf(acc.PublicKey, acc.Balance, acc.Nonce)
+	}
+	return leaves
+}
+
+// sortedAccounts returns balances and nonces sorted by public key
+func (bm *Accounts) sortedAccounts() []AccountEntry {
+	bm.lock.Lock()
+	accounts := make([]AccountEntry, 0, len(bm.balances))
+	for k, v := range bm.balances {
+		accounts = append(accounts, AccountEntry{PublicKey: []byte(k), Balance: v, Nonce: bm.nonces[k]})
+	}
+	bm.lock.Unlock()
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i].PublicKey, accounts[j].PublicKey) < 0
+	})
+	return accounts
+}
+
+// StateRoot returns root of the whole state: its left child is Merkle root
+// over all accounts sorted by public key, the right one is hash of the rest
+// of the state
+func (bm *Accounts) StateRoot() []byte {
+	return bm.Snapshot().StateRoot()
+}
+
+// extras returns the state of the snapshot besides accounts
+func (s *Snapshot) extras() *stateExtras {
+	return &stateExtras{Multisig: s.Multisig, Escrows: s.Escrows, Assets: s.Assets,
+		AssetBalances: s.AssetBalances, PendingFees: s.PendingFees, FeesEarned: s.FeesEarned,
+		EpochSeeds: s.EpochSeeds, EpochStakes: s.EpochStakes, Stakes: s.Stakes, Unbonds: s.Unbonds,
+		Bindings: s.Bindings, Slashed: s.Slashed, Burned: s.Burned, VDFCount: s.VDFCount}
+}
+
+// StateRoot returns state root of the snapshot
+func (s *Snapshot) StateRoot() []byte {
+	return stateRoot(s.Accounts, s.extras())
+}
+
+// root returns root which commits the whole snapshot
+func (s *Snapshot) root() []byte {
+	meta := &snapshotMeta{Height: s.Height, Count: s.Count, Val: s.Val, Header: s.Header,
+		TransactionsTotal: s.TransactionsTotal, BlocksTotal: s.BlocksTotal}
+	return merkle.NodeHash(s.StateRoot(), canonicalHash(meta))
+}
+
+// Snapshot returns snapshot of the current state
+func (bm *Accounts) Snapshot() *Snapshot {
+	snapshot := new(Snapshot)
+	if last := bm.LastBlock(); last != nil {
+		snapshot.Height = last.Number
+		snapshot.Count = last.Count
+		snapshot.Val = last.Val
+		snapshot.Header = last.Header
+	}
+	snapshot.TransactionsTotal = bm.TransactionsTotal()
+	snapshot.BlocksTotal = bm.BlocksTotal()
+	snapshot.VDFCount = bm.VDFCount()
+	snapshot.Accounts = bm.sortedAccounts()
+	bm.lock.Lock()
+	snapshot.Multisig = copyMultisig(bm.multisig)
+	snapshot.Escrows = copyEscrows(bm.escrows)
+	snapshot.Assets = copyAssets(bm.assets)
+	snapshot.AssetBalances = copyAssetBalances(bm.assetBalances)
+	snapshot.PendingFees = bm.pendingFees
+	snapshot.FeesEarned = copyFeesEarned(bm.feesEarned)
+	snapshot.EpochSeeds = copyEpochSeeds(bm.epochSeeds)
+	snapshot.EpochStakes = copyEpochStakes(bm.epochStakes)
+	snapshot.Stakes = copyStakes(bm.stakes)
+	snapshot.Unbonds = copyUnbonds(bm.unbonds)
+	snapshot.Bindings = copyBindings(bm.bindings)
+	snapshot.Slashed = copySlashed(bm.slashed)
+	snapshot.Burned = bm.burned
+	bm.lock.Unlock()
+	snapshot.Root = snapshot.root()
+	return snapshot
+}
+
+// Verify checks that everything imported from the snapshot matches the snapshot root
+func (s *Snapshot) Verify() error {
+	if !bytes.Equal(s.root(), s.Root) {
+		return errSnapshotRoot
+	}
+	return nil
+}
+
+// Export writes snapshot to the file
+func (s *Snapshot) Export(path string) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// ImportSnapshot reads snapshot from the file and verifies its root
+func ImportSnapshot(path string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := new(Snapshot)
+	if err = gob.NewDecoder(bytes.NewReader(data)).Decode(snapshot); err != nil {
+		return nil, err
+	}
+	if err = snapshot.Verify(); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// NewBookManagerFromSnapshot creates Accounts with balances of the snapshot.
+// Last block of the snapshot becomes the only valid vdf value of the ledger,
+// so transactions signed against older values will be rejected.
+func NewBookManagerFromSnapshot(s *Snapshot) (*Accounts, error) {
+	if err := s.Verify(); err != nil {
+		return nil, err
+	}
+	bm := NewBookManager()
+	for _, acc := range s.Accounts {
+		bm.balances[string(acc.PublicKey)] = acc.Balance
+		if acc.Nonce != 0 {
+			bm.nonces[string(acc.PublicKey)] = acc.Nonce
+		}
+	}
+	bm.multisig = copyMultisig(s.Multisig)
+	bm.escrows = copyEscrows(s.Escrows)
+	bm.assets = copyAssets(s.Assets)
+	bm.assetBalances = copyAssetBalances(s.AssetBalances)
+	bm.pendingFees = s.PendingFees
+	bm.feesEarned = copyFeesEarned(s.FeesEarned)
+	bm.epochSeeds = copyEpochSeeds(s.EpochSeeds)
+	bm.epochStakes = copyEpochStakes(s.EpochStakes)
+	bm.stakes = copyStakes(s.Stakes)
+	bm.unbonds = copyUnbonds(s.Unbonds)
+	bm.bindings = copyBindings(s.Bindings)
+	bm.slashed = copySlashed(s.Slashed)
+	bm.burned = s.Burned
+	bm.transactionsTotal = s.TransactionsTotal
+	bm.blocksTotal = s.BlocksTotal
+	bm.vdfCount = s.VDFCount
+	if len(s.Val) > 0 {
+		bm.ledger.UpdateLastBlock(&block.Block{Number: s.Height, Count: s.Count, Val: s.Val, Header: s.Header,
+			Transactions: &block.Transactions{}})
+		bm.ledger.AddValidVDFValue(s.Val)
+	}
+	return bm, nil
+}
+
+// AccountProof is a balance of a single account together with proof of its
+// inclusion into the state root at the given height. Found is false when
+// account does not exist, in that case Proof is empty.
+type AccountProof struct {
+	PublicKey []byte
+	Balance   int64
+	Nonce     uint64
+	Height    uint64
+	Root      []byte
+	Proof     merkle.Proof
+	Found     bool
+}
+
+// AccountProof returns balance of the account with its Merkle inclusion proof
+// into the state root of the last anchored block
+func (bm *Accounts) AccountProof(publicKey []byte) *AccountProof {
+	tree := bm.proofStateTree()
+	res := &AccountProof{PublicKey: publicKey, Height: tree.height, Root: tree.root}
+	accounts := tree.accounts
+	index := sort.Search(len(accounts), func(i int) bool {
+		return bytes.Compare(accounts[i].PublicKey, publicKey) >= 0
+	})
+	if index < len(accounts) && bytes.Equal(accounts[index].PublicKey, publicKey) {
+		res.Balance = accounts[index].Balance
+		res.Nonce = accounts[index].Nonce
+		res.Proof, _ = tree.tree.Proof(index)
+		// accounts tree is the left child of the state root
+		res.Proof = append(res.Proof, merkle.Step{Hash: tree.extras, Left: false})
+		res.Found = true
+	}
+	return res
+}
+
+// Verify checks that account balance is included in the proof's root
+func (p *AccountProof) Verify() bool {
+	return p.Found && merkle.Verify(p.Root, AccountLeaf(p.PublicKey, p.Balance, p.Nonce), p.Proof)
+}
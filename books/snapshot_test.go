@@ -0,0 +1,145 @@
+package books
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/merkle"
+)
+
+func TestStateRootDeterministic(t *testing.T) {
+	bm := NewBookManager()
+	bm2 := NewBookManager()
+	for i := 0; i < 100; i++ {
+		bm.CreateAccount([]byte{byte(i)}, int64(i))
+		bm2.CreateAccount([]byte{byte(99 - i)}, int64(99-i))
+	}
+	if !bytes.Equal(bm.StateRoot(), bm2.StateRoot()) {
+		t.Errorf("StateRoot should not depend on insertion order")
+	}
+	bm2.CreateAccount([]byte{5}, 6)
+	if bytes.Equal(bm.StateRoot(), bm2.StateRoot()) {
+		t.Errorf("StateRoot should depend on balances")
+	}
+}
+
+func TestSnapshotRoot(t *testing.T) {
+	bm, _ := RandomAccounts(50)
+	bm.UpdateLastBlock(&block.Block{Number: 7, Count: 3, Val: block.VDF([]byte{7})})
+	s := bm.Snapshot()
+	if s.Height != 7 || !bytes.Equal(s.StateRoot(), bm.StateRoot()) || len(s.Accounts) != 50 {
+		t.Errorf("Snapshot height %v, accounts %v", s.Height, len(s.Accounts))
+	}
+	for i := 1; i < len(s.Accounts); i++ {
+		if bytes.Compare(s.Accounts[i-1].PublicKey, s.Accounts[i].PublicKey) >= 0 {
+			t.Errorf("Snapshot accounts should be sorted")
+		}
+	}
+	if s.Verify() != nil {
+		t.Errorf("Snapshot should verify")
+	}
+	s.Accounts[0].Balance++
+	if s.Verify() != errSnapshotRoot {
+		t.Errorf("modified snapshot should not verify")
+	}
+}
+
+func TestSnapshotCommitsState(t *testing.T) {
+	bm, keyPairs := RandomAccounts(10)
+	bm.UpdateLastBlock(&block.Block{Number: 7, Count: 3, Val: block.VDF([]byte{7})})
+	bm.stakes[StakeKey{Delegator: string(keyPairs[0].Public), Validator: string(keyPairs[1].Public)}] = 10
+	bm.multisig[string(keyPairs[2].Public)] = Multisig{Threshold: 1, Signers: [][]byte{keyPairs[3].Public}}
+	tamper := []func(s *Snapshot){
+		func(s *Snapshot) { s.Stakes[StakeKey{Delegator: "x", Validator: string(keyPairs[1].Public)}] = 1000 },
+		func(s *Snapshot) { s.Multisig[string(keyPairs[2].Public)] = Multisig{Threshold: 1} },
+		func(s *Snapshot) { s.Slashed[string(keyPairs[1].Public)] = true },
+		func(s *Snapshot) { s.PendingFees++ },
+		func(s *Snapshot) { s.VDFCount++ },
+		func(s *Snapshot) { s.Height++ },
+		func(s *Snapshot) { s.TransactionsTotal++ },
+	}
+	for i, f := range tamper {
+		s := bm.Snapshot()
+		if s.Verify() != nil {
+			t.Fatalf("snapshot should verify")
+		}
+		f(s)
+		if _, err := NewBookManagerFromSnapshot(s); err != errSnapshotRoot {
+			t.Errorf("tampered snapshot %v should not be imported, got %v", i, err)
+		}
+	}
+}
+
+func TestSnapshotAccountProof(t *testing.T) {
+	bm, keyPairs := RandomAccounts(10)
+	s := bm.Snapshot()
+	leaves := accountLeaves(s.Accounts)
+	for i, acc := range s.Accounts {
+		proof, _ := merkle.NewProof(leaves, i)
+		if !merkle.Verify(merkle.Root(leaves), AccountLeaf(acc.PublicKey, bm.Balance(acc.PublicKey), bm.Nonce(acc.PublicKey)), proof) {
+			t.Errorf("account proof failed")
+		}
+	}
+	if len(keyPairs) != len(s.Accounts) {
+		t.Errorf("Snapshot should contain all accounts")
+	}
+}
+
+func TestSnapshotExportImport(t *testing.T) {
+	path, cleanup := tempStorePath(t)
+	defer cleanup()
+	bm, keyPairs := RandomAccounts(10)
+	blocks := RandomTransactionsBlocks(bm, 10, 3, keyPairs)
+	for i := range blocks {
+		blocks[i].Number = uint64(i + 1)
+		blocks[i].Seal(&testProducer, nil)
+	}
+	bm.ProcessBlocks(blocks)
+	if err := bm.Snapshot().Export(path); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := ImportSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bm2, err := NewBookManagerFromSnapshot(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bm.StateRoot(), bm2.StateRoot()) || bm2.LastBlock().Number != 3 {
+		t.Errorf("imported accounts should match exported ones")
+	}
+	if !bytes.Equal(bm2.ValidVDFValue(), blocks[2].Val) || bm2.TransactionsTotal() != bm.TransactionsTotal() {
+		t.Errorf("imported snapshot should continue from the last block")
+	}
+	if !bytes.Equal(bm2.LastBlock().Hash(), blocks[2].Hash()) {
+		t.Errorf("imported snapshot should keep header of the last block")
+	}
+}
+
+func TestAccountProof(t *testing.T) {
+	bm, keyPairs := RandomAccounts(33)
+	bm.UpdateLastBlock(&block.Block{Number: 4, Val: block.VDF([]byte{4})})
+	for _, kp := range keyPairs {
+		p := bm.AccountProof(kp.Public)
+		if !p.Found || p.Balance != bm.Balance(kp.Public) || p.Height != 4 || !p.Verify() {
+			t.Errorf("AccountProof should verify")
+		}
+		if !bytes.Equal(p.Root, bm.StateRoot()) {
+			t.Errorf("AccountProof root should be state root")
+		}
+		p.Balance++
+		if p.Verify() {
+			t.Errorf("AccountProof with wrong balance should not verify")
+		}
+	}
+	p := bm.AccountProof(block.NewKeyPair().Public)
+	if p.Found || p.Verify() {
+		t.Errorf("AccountProof of unknown account should not be found")
+	}
+	if bm.proofStateTree() != bm.proofStateTree() {
+		t.Errorf("state tree should be built once per height")
+	}
+}
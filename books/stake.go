@@ -0,0 +1,267 @@
+package books
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"golang.org/x/crypto/ed25519"
+)
+
+// DefaultUnstakeCooldown is the number of blocks unstaked tokens stay locked
+const DefaultUnstakeCooldown = 100
+
+// errInsufficientStake is returned when unstaked token exceeds token delegated to the validator
+var errInsufficientStake = errors.New("insufficient stake")
+
+// StakeKey identifies token delegated by the delegator to the validator
+type StakeKey struct {
+	Delegator string
+	Validator string
+}
+
+// Unbond holds unstaked tokens until the cool-down passes. ReleaseHeight is
+// zero until the unstake transaction is included into a block.
+type Unbond struct {
+	Delegator     []byte
+	Validator     []byte
+	Token         int64
+	ReleaseHeight uint64
+}
+
+// Validator is a staking identity with token delegated to it
+type Validator struct {
+	PublicKey ed25519.PublicKey
+	Stake     int64
+}
+
+func stakeKey(delegator []byte, validator []byte) StakeKey {
+	return StakeKey{Delegator: string(delegator), Validator: string(validator)}
+}
+
+// SetUnstakeCooldown sets number of blocks unstaked tokens stay locked
+func (bm *Accounts) SetUnstakeCooldown(cooldown uint64) {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	if cooldown == 0 {
+		cooldown = 1
+	}
+	bm.unstakeCooldown = cooldown
+}
+
+// checkStake checks that unstaked token is delegated by the sender.
+// Must be called under bm.lock
+func (bm *Accounts) checkStake(tran *block.Transaction) error {
+	if tran.Type == block.TypeUnstake && bm.stakes[stakeKey(tran.From, tran.To)] < tran.Token {
+		return errInsufficientStake
+	}
+	return nil
+}
+
+// applyUnstake moves unstaked token from the stake to unbond.
+// Must be called under bm.lock
+func (bm *Accounts) applyUnstake(tran *block.Transaction) {
+	key := stakeKey(tran.From, tran.To)
+	bm.stakes[key] -= tran.Token
+	if bm.stakes[key] == 0 {
+		delete(bm.stakes, key)
+	}
+	bm.unbonds[string(tran.Signature)] = Unbond{Delegator: tran.From, Validator: tran.To, Token: tran.Token}
+}
+
+// applyStakeDeposit delegates staked token to the validator.
+// Must be called under bm.lock
+func (bm *Accounts) applyStakeDeposit(tran *block.Transaction) {
+	bm.stakes[stakeKey(tran.From, tran.To)] += tran.Token - tran.Fee
+}
+
+// applyBindNode binds the node to the sender validator. Validator stands for a
+// single node and node for a single validator, earlier bindings of both are
+// replaced. Must be called under bm.lock
+func (bm *Accounts) applyBindNode(tran *block.Transaction) {
+	for validator, node := range bm.bindings {
+		if node == string(tran.To) {
+			delete(bm.bindings, validator)
+		}
+	}
+	bm.bindings[string(tran.From)] = string(tran.To)
+}
+
+// validatorOf returns validator the node stands for: the validator which bound
+// the node or the node itself, unless the node is a validator bound to another
+// node. Empty string is returned in the latter case. Must be called under bm.lock
+func (bm *Accounts) validatorOf(node string) string {
+	for validator, bound := range bm.bindings {
+		if bound == node {
+			return validator
+		}
+	}
+	if _, ok := bm.bindings[node]; ok {
+		return ""
+	}
+	return node
+}
+
+// ValidatorOf returns validator the node stands for, nil if the node stands for none
+func (bm *Accounts) ValidatorOf(node ed25519.PublicKey) ed25519.PublicKey {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	if validator := bm.validatorOf(string(node)); validator != "" {
+		return ed25519.PublicKey(validator)
+	}
+	return nil
+}
+
+// NodeOf returns node the validator stands for, it is the validator itself
+// unless the validator bound another node
+func (bm *Accounts) NodeOf(validator ed25519.PublicKey) ed25519.PublicKey {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	if node, ok := bm.bindings[string(validator)]; ok {
+		return ed25519.PublicKey(node)
+	}
+	return validator
+}
+
+// StakedNodes returns nodes of validators with stake sorted by public key. The
+// node of a validator is the node it bound or the validator itself.
+func (bm *Accounts) StakedNodes() []ed25519.PublicKey {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	res := make([]ed25519.PublicKey, 0, len(bm.stakes))
+	for validator, stake := range bm.validatorStakes() {
+		if stake <= 0 {
+			continue
+		}
+		node := validator
+		if bound, ok := bm.bindings[validator]; ok {
+			node = bound
+		}
+		res = append(res, ed25519.PublicKey(node))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return bytes.Compare(res[i], res[j]) < 0
+	})
+	return res
+}
+
+// AdvanceStakes starts cool-down of unstakes included into the block with given
+// height and releases unbonds whose cool-down has passed. Both producer and
+// signers call it after transactions of the block are confirmed, so released
+// tokens can be spent from the next block on. Stakes of the leader schedule
+// nodes are recorded for the next epoch, see recordEpochStakes.
+func (bm *Accounts) AdvanceStakes(trans *block.Transactions, height uint64) {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	for sig, unbond := range bm.unbonds {
+		if unbond.ReleaseHeight != 0 && unbond.ReleaseHeight <= height {
+			bm.balances[string(unbond.Delegator)] += unbond.Token
+			delete(bm.unbonds, sig)
+		}
+	}
+	if trans != nil {
+		for i := range trans.Ts {
+			if trans.Ts[i].Type != block.TypeUnstake {
+				continue
+			}
+			if unbond, ok := bm.unbonds[string(trans.Ts[i].Signature)]; ok && unbond.ReleaseHeight == 0 {
+				unbond.ReleaseHeight = height + bm.unstakeCooldown
+				bm.unbonds[string(trans.Ts[i].Signature)] = unbond
+			}
+		}
+	}
+	bm.recordEpochStakes(height)
+}
+
+// validatorStakes returns token delegated to every validator.
+// Must be called under bm.lock
+func (bm *Accounts) validatorStakes() map[string]int64 {
+	res := make(map[string]int64)
+	for key, token := range bm.stakes {
+		res[key.Validator] += token
+	}
+	return res
+}
+
+// Validators returns validator set sorted by decreasing stake, validators with
+// equal stake are sorted by public key
+func (bm *Accounts) Validators() []Validator {
+	bm.lock.Lock()
+	stakes := bm.validatorStakes()
+	bm.lock.Unlock()
+	res := make([]Validator, 0, len(stakes))
+	for k, v := range stakes {
+		res = append(res, Validator{PublicKey: ed25519.PublicKey(k), Stake: v})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Stake != res[j].Stake {
+			return res[i].Stake > res[j].Stake
+		}
+		return bytes.Compare(res[i].PublicKey, res[j].PublicKey) < 0
+	})
+	return res
+}
+
+// ValidatorStake returns token delegated to the validator
+func (bm *Accounts) ValidatorStake(validator ed25519.PublicKey) int64 {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	stake := int64(0)
+	for key, token := range bm.stakes {
+		if key.Validator == string(validator) {
+			stake += token
+		}
+	}
+	return stake
+}
+
+// TotalStake returns token delegated to all validators
+func (bm *Accounts) TotalStake() int64 {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	total := int64(0)
+	for _, token := range bm.stakes {
+		total += token
+	}
+	return total
+}
+
+// StakeOf returns token delegated by the delegator to the validator
+func (bm *Accounts) StakeOf(delegator ed25519.PublicKey, validator ed25519.PublicKey) int64 {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	return bm.stakes[stakeKey(delegator, validator)]
+}
+
+// UnbondOf returns unbond created by unstake transaction with the signature
+func (bm *Accounts) UnbondOf(signature []byte) (Unbond, bool) {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	unbond, ok := bm.unbonds[string(signature)]
+	return unbond, ok
+}
+
+func copyStakes(m map[StakeKey]int64) map[StakeKey]int64 {
+	res := make(map[StakeKey]int64, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
+func copyUnbonds(m map[string]Unbond) map[string]Unbond {
+	res := make(map[string]Unbond, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
+func copyBindings(m map[string]string) map[string]string {
+	res := make(map[string]string, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
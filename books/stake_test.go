@@ -0,0 +1,159 @@
+package books
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+func confirmOne(bm *Accounts, tran block.Transaction) Reason {
+	res := bm.ProcessTransactions(block.Transactions{Ts: []block.Transaction{tran}})
+	nextBlock(bm, 1)
+	bm.ConfirmTransactions(&res, bm.LastBlock().Number)
+	bm.AdvanceStakes(&res, bm.LastBlock().Number)
+	return bm.TransactionReceipt(tran.Signature).Reason
+}
+
+func TestStake(t *testing.T) {
+	bm := NewBookManager()
+	bm.SetUnstakeCooldown(2)
+	delegator := block.NewKeyPair()
+	validator := block.NewKeyPair()
+	bm.CreateAccount(delegator.Public, 100)
+	vdf := block.VDF([]byte("stake"))
+	bm.AddValidVDFValue(vdf)
+	supply := bm.TotalSupply()
+
+	stake := block.NewStake(&delegator, validator.Public, 60, 1, vdf)
+	if r := confirmOne(bm, stake); r != ReasonNone || bm.Balance(delegator.Public) != 40 {
+		t.Errorf("stake should withdraw tokens from the delegator, got %v", r)
+	}
+	if bm.StakeOf(delegator.Public, validator.Public) != 59 || bm.ValidatorStake(validator.Public) != 59 || bm.TotalStake() != 59 {
+		t.Errorf("stake should delegate tokens minus fee, got %v", bm.StakeOf(delegator.Public, validator.Public))
+	}
+	tooMuch := block.NewUnstake(&delegator, validator.Public, 60, 1, vdf)
+	if r := confirmOne(bm, tooMuch); r != ReasonInsufficientStake || bm.Balance(delegator.Public) != 40 {
+		t.Errorf("unstake should not exceed stake, got %v", r)
+	}
+
+	unstake := block.NewUnstake(&delegator, validator.Public, 50, 1, vdf)
+	if r := confirmOne(bm, unstake); r != ReasonNone || bm.ValidatorStake(validator.Public) != 9 || bm.Balance(delegator.Public) != 39 {
+		t.Errorf("unstake should move tokens out of stake, got %v", r)
+	}
+	height := bm.LastBlock().Number
+	if u, ok := bm.UnbondOf(unstake.Signature); !ok || u.Token != 50 || u.ReleaseHeight != height+2 {
+		t.Errorf("unbond should be released after cool-down, got %v", u)
+	}
+	if bm.TotalSupply() != supply {
+		t.Errorf("staking should not change supply, %v != %v", bm.TotalSupply(), supply)
+	}
+
+	nextBlock(bm, 1)
+	bm.AdvanceStakes(&block.Transactions{}, height+1)
+	if bm.Balance(delegator.Public) != 39 {
+		t.Errorf("unbond should be locked during cool-down")
+	}
+	nextBlock(bm, 1)
+	bm.AdvanceStakes(&block.Transactions{}, height+2)
+	if _, ok := bm.UnbondOf(unstake.Signature); ok || bm.Balance(delegator.Public) != 89 || bm.TotalSupply() != supply {
+		t.Errorf("unbond should return tokens after cool-down, balance %v", bm.Balance(delegator.Public))
+	}
+
+	restored := NewBookManager()
+	restored.ImportState(bm.ExportState())
+	if !restored.Equals(bm) || restored.ValidatorStake(validator.Public) != 9 {
+		t.Errorf("stakes should be part of the state")
+	}
+}
+
+func TestValidators(t *testing.T) {
+	bm := NewBookManager()
+	vdf := block.VDF([]byte("validators"))
+	bm.AddValidVDFValue(vdf)
+	delegators := []block.KeyPair{block.NewKeyPair(), block.NewKeyPair()}
+	validators := []block.KeyPair{block.NewKeyPair(), block.NewKeyPair()}
+	for _, d := range delegators {
+		bm.CreateAccount(d.Public, 100)
+	}
+	trans := block.Transactions{Ts: []block.Transaction{
+		block.NewStake(&delegators[0], validators[0].Public, 11, 1, vdf),
+		block.NewStake(&delegators[0], validators[1].Public, 21, 1, vdf),
+		block.NewStake(&delegators[1], validators[0].Public, 21, 1, vdf),
+	}}
+	bm.ProcessTransactions(trans)
+	set := bm.Validators()
+	if len(set) != 2 || !bytes.Equal(set[0].PublicKey, validators[0].Public) || set[0].Stake != 30 || set[1].Stake != 20 {
+		t.Errorf("validators should be sorted by stake, got %v", set)
+	}
+}
+
+func TestBindNode(t *testing.T) {
+	bm := NewBookManager()
+	vdf := block.VDF([]byte("bind"))
+	bm.AddValidVDFValue(vdf)
+	validators := []block.KeyPair{block.NewKeyPair(), block.NewKeyPair()}
+	nodes := []block.KeyPair{block.NewKeyPair(), block.NewKeyPair()}
+	for _, v := range validators {
+		bm.CreateAccount(v.Public, 10)
+	}
+	if !bytes.Equal(bm.ValidatorOf(nodes[0].Public), nodes[0].Public) || !bytes.Equal(bm.NodeOf(validators[0].Public), validators[0].Public) {
+		t.Errorf("node without binding should stand for itself")
+	}
+
+	if r := confirmOne(bm, block.NewBindNode(&validators[0], nodes[0].Public, 1, vdf)); r != ReasonNone || bm.Balance(validators[0].Public) != 9 {
+		t.Errorf("binding should pay fee, got %v", r)
+	}
+	if !bytes.Equal(bm.ValidatorOf(nodes[0].Public), validators[0].Public) || !bytes.Equal(bm.NodeOf(validators[0].Public), nodes[0].Public) ||
+		bm.ValidatorOf(validators[0].Public) != nil {
+		t.Errorf("validator should stand for the bound node only")
+	}
+
+	confirmOne(bm, block.NewBindNode(&validators[0], nodes[1].Public, 1, vdf))
+	if !bytes.Equal(bm.ValidatorOf(nodes[1].Public), validators[0].Public) || !bytes.Equal(bm.ValidatorOf(nodes[0].Public), nodes[0].Public) {
+		t.Errorf("new binding should replace the node of the validator")
+	}
+	confirmOne(bm, block.NewBindNode(&validators[1], nodes[1].Public, 1, vdf))
+	if !bytes.Equal(bm.ValidatorOf(nodes[1].Public), validators[1].Public) || !bytes.Equal(bm.NodeOf(validators[0].Public), validators[0].Public) {
+		t.Errorf("node should stand for a single validator")
+	}
+
+	restored := NewBookManager()
+	restored.ImportState(bm.ExportState())
+	if !restored.Equals(bm) || !bytes.Equal(restored.ValidatorOf(nodes[1].Public), validators[1].Public) {
+		t.Errorf("bindings should be part of the state")
+	}
+}
+
+func TestWeightedLeader(t *testing.T) {
+	nodes := scheduleNodes(3)
+	schedule := NewLeaderSchedule(nodes, 1, 10)
+	seed := block.VDF([]byte("seed"))
+	stakes := map[string]int64{string(nodes[0]): 90, string(nodes[1]): 10}
+	leaders := make(map[string]int)
+	for height := uint64(0); height < 1000; height++ {
+		leaders[string(schedule.WeightedLeader(seed, height, stakes))]++
+	}
+	if leaders[string(nodes[2])] != 0 || leaders[string(nodes[0])] < 800 || leaders[string(nodes[1])] == 0 {
+		t.Errorf("leaders should be chosen by stake, got %v", leaders)
+	}
+	if !bytes.Equal(schedule.WeightedLeader(seed, 7, nil), schedule.Leader(seed, 7)) {
+		t.Errorf("nodes should have equal weight without stake")
+	}
+
+	bm := NewBookManager()
+	bm.SetLeaderSchedule(NewLeaderSchedule(nodes, 1, 2))
+	delegator := block.NewKeyPair()
+	bm.CreateAccount(delegator.Public, 100)
+	vdf := block.VDF([]byte("leader"))
+	bm.AddValidVDFValue(vdf)
+	confirmOne(bm, block.NewStake(&delegator, nodes[1], 51, 1, vdf))
+	if bm.LastBlock().Number != 1 {
+		t.Fatalf("unexpected height %v", bm.LastBlock().Number)
+	}
+	for height := uint64(2); height < 4; height++ {
+		if leader := bm.Leader(height); !bytes.Equal(leader, nodes[1]) {
+			t.Errorf("the only staked node should lead the next epoch, got %v", leader)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package books
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"reflect"
+	"sort"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/merkle"
+)
+
+// stateRootInterval is the number of blocks between blocks anchoring the
+// state, i.e. carrying root of the state after them in their header
+const stateRootInterval = 10
+
+// anchored reports whether block with given number carries the state root
+func anchored(number uint64) bool {
+	return number%stateRootInterval == 0
+}
+
+// stateExtras is the state besides balances and nonces. Its hash is the right
+// child of the state root, accounts tree is the left one.
+type stateExtras struct {
+	Multisig      map[string]Multisig
+	Escrows       map[string]Escrow
+	Assets        map[string]Asset
+	AssetBalances map[AssetBalanceKey]int64
+	PendingFees   int64
+	FeesEarned    map[string]int64
+	EpochSeeds    map[uint64]block.VDFValue
+	EpochStakes   map[uint64]map[string]int64
+	Stakes        map[StakeKey]int64
+	Unbonds       map[string]Unbond
+	Bindings      map[string]string
+	Slashed       map[string]bool
+	Burned        int64
+	VDFCount      uint64
+}
+
+// snapshotMeta is the part of snapshot which is not the state itself
+type snapshotMeta struct {
+	Height            uint64
+	Count             uint64
+	Val               block.VDFValue
+	Header            block.Header
+	TransactionsTotal uint64
+	BlocksTotal       uint64
+}
+
+// stateRoot returns root of the state: accounts tree and hash of the rest of the state
+func stateRoot(accounts []AccountEntry, extras *stateExtras) []byte {
+	return merkle.NodeHash(merkle.Root(accountLeaves(accounts)), canonicalHash(extras))
+}
+
+// stateTree is the state after the block with given height prepared for
+// account proofs: accounts sorted by public key with their Merkle tree and
+// hash of the rest of the state. Anchored trees have root in the block header.
+type stateTree struct {
+	height   uint64
+	anchored bool
+	accounts []AccountEntry
+	tree     *merkle.Tree
+	extras   []byte
+	root     []byte
+}
+
+// newStateTree builds tree of the current state
+func (bm *Accounts) newStateTree(height uint64, anchored bool) *stateTree {
+	s := bm.Snapshot()
+	t := &stateTree{height: height, anchored: anchored, accounts: s.Accounts}
+	t.tree = merkle.NewTree(accountLeaves(s.Accounts))
+	t.extras = canonicalHash(s.extras())
+	t.root = merkle.NodeHash(t.tree.Root(), t.extras)
+	return t
+}
+
+func (bm *Accounts) setStateTree(tree *stateTree) {
+	bm.lock.Lock()
+	bm.proofTree = tree
+	bm.lock.Unlock()
+}
+
+// proofStateTree returns tree of the last anchored block. Until the first
+// anchored block the tree is built for the last block, so it is built at
+// most once per block however many proofs are requested.
+func (bm *Accounts) proofStateTree() *stateTree {
+	var height uint64
+	if last := bm.LastBlock(); last != nil {
+		height = last.Number
+	}
+	bm.lock.Lock()
+	tree := bm.proofTree
+	bm.lock.Unlock()
+	if tree == nil || (!tree.anchored && tree.height != height) {
+		tree = bm.newStateTree(height, false)
+		bm.setStateTree(tree)
+	}
+	return tree
+}
+
+// anchorState builds tree of the state after the anchored block, in strict
+// mode the state root of the block header is checked against it
+func (bm *Accounts) anchorState(bl *block.Block, strict bool) error {
+	if !anchored(bl.Number) {
+		return nil
+	}
+	tree := bm.newStateTree(bl.Number, true)
+	if strict && !bytes.Equal(tree.root, bl.StateRoot) {
+		return &BlockError{Number: bl.Number, Divergence: DivergenceStateRoot, Transaction: -1}
+	}
+	bm.setStateTree(tree)
+	return nil
+}
+
+// Settle applies produced block to the settled accounts and sets state root
+// of the anchored block before it is sealed. The producer processes
+// transactions before blocks containing them are sealed, so its own state is
+// ahead of its last block. Settled accounts are a clone of the producer's
+// accounts taken before production and follow produced blocks only.
+// Trees of anchored blocks are passed to the producer for account proofs.
+func (bm *Accounts) Settle(bl *block.Block, producer *Accounts) {
+	bm.applyBlock(*bl, false)
+	if !anchored(bl.Number) {
+		return
+	}
+	tree := bm.newStateTree(bl.Number, true)
+	bl.StateRoot = tree.root
+	producer.setStateTree(tree)
+}
+
+// Sealed registers settled block after it was sealed. Settled accounts keep
+// the sealed block as the last one and the producer records it for rollback
+// with checkpoints of the settled state, see SetCheckpoints.
+func (bm *Accounts) Sealed(bl *block.Block, producer *Accounts) {
+	bm.ledger.replaceLastBlock(bl)
+	producer.recordSettledBlock(*bl, bm)
+}
+
+// Unsettle replaces state of the producer, which stopped production, with
+// the settled state. Transactions processed after the last sealed block are
+// dropped and their receipts are forgotten.
+func (bm *Accounts) Unsettle(producer *Accounts, trans []block.Transaction) {
+	producer.ImportState(bm.ExportState())
+	for i := range trans {
+		producer.receipts.forget(trans[i].Signature)
+	}
+}
+
+// canonicalHash returns hash of deterministic encoding of v
+func canonicalHash(v interface{}) []byte {
+	var buf bytes.Buffer
+	writeCanonical(&buf, reflect.ValueOf(v))
+	res := sha256.Sum256(buf.Bytes())
+	return res[:]
+}
+
+// writeCanonical writes deterministic encoding of v. Variable sized values
+// are prefixed by their length and map entries are sorted by their encoding,
+// so equal values are always encoded the same way.
+func writeCanonical(buf *bytes.Buffer, v reflect.Value) {
+	var num [8]byte
+	writeUint := func(u uint64) {
+		binary.BigEndian.PutUint64(num[:], u)
+		buf.Write(num[:])
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		writeUint(v.Uint())
+	case reflect.String:
+		writeUint(uint64(v.Len()))
+		buf.WriteString(v.String())
+	case reflect.Slice, reflect.Array:
+		writeUint(uint64(v.Len()))
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			buf.Write(v.Bytes())
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			writeCanonical(buf, v.Index(i))
+		}
+	case reflect.Map:
+		entries := make([][]byte, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			var entry bytes.Buffer
+			writeCanonical(&entry, key)
+			writeCanonical(&entry, v.MapIndex(key))
+			entries = append(entries, entry.Bytes())
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return bytes.Compare(entries[i], entries[j]) < 0
+		})
+		writeUint(uint64(len(entries)))
+		for _, entry := range entries {
+			buf.Write(entry)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			writeCanonical(buf, v.Field(i))
+		}
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteByte(0)
+			return
+		}
+		buf.WriteByte(1)
+		writeCanonical(buf, v.Elem())
+	default:
+		panic("books: unsupported kind in state: " + v.Kind().String())
+	}
+}
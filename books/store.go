@@ -0,0 +1,331 @@
+package books
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+const (
+	// recordHeaderSize is the size of record length plus crc32 checksum
+	recordHeaderSize = 8
+	// compactEvery defines after how many saved records the log file is rewritten
+	compactEvery = 100
+)
+
+var (
+	// ErrNoState is returned when store does not contain any committed state
+	ErrNoState = errors.New("no committed state")
+	// errCorruptedRecord is returned when record checksum does not match
+	errCorruptedRecord = errors.New("corrupted state record")
+	// errMissingBase is returned when the log starts with a delta record
+	errMissingBase = errors.New("state delta without base record")
+)
+
+// State is a copy of Accounts data which should survive node restarts.
+// It contains balances, counters and the recent VDF window of the ledger
+// together with signatures registered for each VDF value.
+type State struct {
+	Balances          map[string]int64
+	Nonces            map[string]uint64
+	Multisig          map[string]Multisig
+	Escrows           map[string]Escrow
+	Assets            map[string]Asset
+	AssetBalances     map[AssetBalanceKey]int64
+	PendingFees       int64
+	FeesEarned        map[string]int64
+	BlockFees         map[uint64]BlockFees
+	EpochSeeds        map[uint64]block.VDFValue
+	EpochStakes       map[uint64]map[string]int64
+	Stakes            map[StakeKey]int64
+	Unbonds           map[string]Unbond
+	Bindings          map[string]string
+	Slashed           map[string]bool
+	Burned            int64
+	TransactionsTotal uint64
+	BlocksTotal       uint64
+	VDFCount          uint64
+	LedgerValues      []block.VDFValue
+	LedgerIndex       int
+	LedgerFull        bool
+	LedgerSignatures  map[string]map[string]bool
+	LastBlockNumber   uint64
+	LastBlockCount    uint64
+	LastBlockVal      block.VDFValue
+	LastBlockHeader   block.Header
+	HasLastBlock      bool
+}
+
+// Store is responsible for persisting committed account state
+type Store interface {
+	// Save commits state, after successful return state should survive crash
+	Save(state *State) error
+	// Load returns last committed state
+	Load() (*State, error)
+	// Close releases resources held by the store
+	Close() error
+}
+
+// FileStore is a log-structured Store. The first commit and every compaction
+// write the whole state, other commits append only its changes since the
+// previous one, see stateDelta. Every record is prefixed by its length and
+// crc32 checksum. On load records are replayed up to the last complete one,
+// so partially written tail (e.g. after crash) is ignored.
+type FileStore struct {
+	path    string
+	file    *os.File
+	mutex   *sync.Mutex
+	records int
+	// last is the state written by the last record, deltas are taken against it
+	last *State
+}
+
+// record is a single entry of the log: either whole state or delta
+type record struct {
+	Full  *State
+	Delta *stateDelta
+}
+
+// stateDelta holds changes of the state. Maps of Changed contain only
+// added or changed entries and maps of Removed contain deleted keys,
+// LedgerValues holds changed slots of the ledger window. Other fields of
+// Changed are copied as is.
+type stateDelta struct {
+	Changed      State
+	Removed      State
+	LedgerValues map[int]block.VDFValue
+}
+
+// ledgerValuesField is the State field which is stored slot by slot in deltas
+const ledgerValuesField = "LedgerValues"
+
+// diffState returns changes which turn old state into cur
+func diffState(old, cur *State) *stateDelta {
+	delta := new(stateDelta)
+	o, c := reflect.ValueOf(old).Elem(), reflect.ValueOf(cur).Elem()
+	changed, removed := reflect.ValueOf(&delta.Changed).Elem(), reflect.ValueOf(&delta.Removed).Elem()
+	for i := 0; i < c.NumField(); i++ {
+		oldField, curField := o.Field(i), c.Field(i)
+		switch {
+		case curField.Kind() == reflect.Map:
+			changedMap := reflect.MakeMap(curField.Type())
+			for _, key := range curField.MapKeys() {
+				oldValue := oldField.MapIndex(key)
+				if !oldValue.IsValid() || !reflect.DeepEqual(oldValue.Interface(), curField.MapIndex(key).Interface()) {
+					changedMap.SetMapIndex(key, curField.MapIndex(key))
+				}
+			}
+			removedMap := reflect.MakeMap(curField.Type())
+			for _, key := range oldField.MapKeys() {
+				if !curField.MapIndex(key).IsValid() {
+					removedMap.SetMapIndex(key, reflect.Zero(curField.Type().Elem()))
+				}
+			}
+			changed.Field(i).Set(changedMap)
+			removed.Field(i).Set(removedMap)
+		case c.Type().Field(i).Name == ledgerValuesField:
+			delta.LedgerValues = make(map[int]block.VDFValue)
+			for slot, val := range cur.LedgerValues {
+				if slot >= len(old.LedgerValues) || !bytes.Equal(old.LedgerValues[slot], val) {
+					delta.LedgerValues[slot] = val
+				}
+			}
+		default:
+			changed.Field(i).Set(curField)
+		}
+	}
+	return delta
+}
+
+// apply applies delta to the state
+func (delta *stateDelta) apply(state *State) {
+	s := reflect.ValueOf(state).Elem()
+	changed, removed := reflect.ValueOf(&delta.Changed).Elem(), reflect.ValueOf(&delta.Removed).Elem()
+	for i := 0; i < s.NumField(); i++ {
+		field := s.Field(i)
+		switch {
+		case field.Kind() == reflect.Map:
+			if field.IsNil() {
+				field.Set(reflect.MakeMap(field.Type()))
+			}
+			for _, key := range removed.Field(i).MapKeys() {
+				field.SetMapIndex(key, reflect.Value{})
+			}
+			for _, key := range changed.Field(i).MapKeys() {
+				field.SetMapIndex(key, changed.Field(i).MapIndex(key))
+			}
+		case s.Type().Field(i).Name == ledgerValuesField:
+			for slot, val := range delta.LedgerValues {
+				for len(state.LedgerValues) <= slot {
+					state.LedgerValues = append(state.LedgerValues, nil)
+				}
+				state.LedgerValues[slot] = val
+			}
+		default:
+			field.Set(changed.Field(i))
+		}
+	}
+}
+
+// NewFileStore opens (or creates) state log at path
+func NewFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{path: path, file: file, mutex: &sync.Mutex{}}, nil
+}
+
+func encodeRecord(rec *record) ([]byte, error) {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(rec); err != nil {
+		return nil, err
+	}
+	data := make([]byte, recordHeaderSize, recordHeaderSize+payload.Len())
+	binary.BigEndian.PutUint32(data[0:4], uint32(payload.Len()))
+	binary.BigEndian.PutUint32(data[4:8], crc32.ChecksumIEEE(payload.Bytes()))
+	return append(data, payload.Bytes()...), nil
+}
+
+// readRecord reads next record from reader. io.EOF is returned when there is
+// no more complete records in the log.
+func readRecord(reader io.Reader) ([]byte, error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, io.EOF
+	}
+	size := binary.BigEndian.Uint32(header[0:4])
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, io.EOF
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, errCorruptedRecord
+	}
+	return payload, nil
+}
+
+// Save appends changes of the state since the last record to the log and
+// syncs the file. The whole state is written only if the log has no base
+// record yet or when it is compacted.
+func (fs *FileStore) Save(state *State) error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	if fs.last == nil || fs.records+1 >= compactEvery {
+		return fs.compact(state)
+	}
+	data, err := encodeRecord(&record{Delta: diffState(fs.last, state)})
+	if err != nil {
+		return err
+	}
+	if _, err = fs.file.Write(data); err != nil {
+		return err
+	}
+	if err = fs.file.Sync(); err != nil {
+		return err
+	}
+	fs.records++
+	fs.last = state
+	return nil
+}
+
+// compact rewrites the log so that it contains only the whole state.
+// New log is written next to the old one and atomically renamed.
+func (fs *FileStore) compact(state *State) error {
+	data, err := encodeRecord(&record{Full: state})
+	if err != nil {
+		return err
+	}
+	tmpPath := fs.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+	if err = os.Rename(tmpPath, fs.path); err != nil {
+		return err
+	}
+	fs.file.Close()
+	fs.file, err = os.OpenFile(fs.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	fs.records = 1
+	fs.last = state
+	return err
+}
+
+// Load replays the log up to the last complete record and returns the state.
+// Incomplete tail is left by crash in the middle of write, it is cut off so
+// that next records are appended after the last complete one. Record with
+// wrong checksum means the log is corrupted.
+func (fs *FileStore) Load() (*State, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	file, err := os.Open(fs.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	var state *State
+	records := 0
+	end := int64(0)
+	for {
+		payload, err := readRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rec := new(record)
+		if err = gob.NewDecoder(bytes.NewReader(payload)).Decode(rec); err != nil {
+			return nil, err
+		}
+		switch {
+		case rec.Full != nil:
+			state = rec.Full
+		case rec.Delta != nil && state != nil:
+			rec.Delta.apply(state)
+		default:
+			return nil, errMissingBase
+		}
+		records++
+		end += int64(recordHeaderSize + len(payload))
+	}
+	if info, err := file.Stat(); err != nil {
+		return nil, err
+	} else if info.Size() > end {
+		if err = fs.file.Truncate(end); err != nil {
+			return nil, err
+		}
+	}
+	if state == nil {
+		return nil, ErrNoState
+	}
+	fs.records = records
+	fs.last = state
+	return state, nil
+}
+
+// Close closes the log file
+func (fs *FileStore) Close() error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	return fs.file.Close()
+}
@@ -0,0 +1,239 @@
+package books
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+func tempStorePath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "books-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "node.state"), func() { os.RemoveAll(dir) }
+}
+
+func TestExportImportState(t *testing.T) {
+	bm, keyPairs := RandomAccounts(10)
+	blocks := RandomTransactionsBlocks(bm, 10, 5, keyPairs)
+	for i := range blocks {
+		blocks[i].Number = uint64(i + 1)
+		blocks[i].Seal(&testProducer, nil)
+	}
+	bm.ProcessBlocks(blocks)
+
+	bm2 := NewBookManager()
+	bm2.ImportState(bm.ExportState())
+	if !bm.Equals(bm2) {
+		t.Errorf("ImportState should restore exported state")
+	}
+	if bm2.LastBlock().Number != 5 {
+		t.Errorf("ImportState last block number %v, expected 5", bm2.LastBlock().Number)
+	}
+	if !bytes.Equal(bm2.LastBlock().Hash(), blocks[4].Hash()) {
+		t.Errorf("ImportState should restore header of the last block")
+	}
+}
+
+func TestFileStoreSaveLoad(t *testing.T) {
+	path, cleanup := tempStorePath(t)
+	defer cleanup()
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadBookManager(store); err != ErrNoState {
+		t.Errorf("LoadBookManager on empty store should fail with ErrNoState, got %v", err)
+	}
+
+	bm, keyPairs := RandomAccounts(10)
+	bm.SetStore(store)
+	blocks := RandomTransactionsBlocks(bm, 10, 3, keyPairs)
+	for i := range blocks {
+		blocks[i].Number = uint64(i + 1)
+	}
+	if err = bm.ProcessBlocks(blocks); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	loaded, err := LoadBookManager(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bm.Equals(loaded) {
+		t.Errorf("loaded accounts differ from committed ones")
+	}
+	if loaded.BlocksTotal() != 3 || loaded.LastBlock().Number != 3 {
+		t.Errorf("loaded blocks total %v, last block %v", loaded.BlocksTotal(), loaded.LastBlock().Number)
+	}
+}
+
+func TestFileStoreTruncatedTail(t *testing.T) {
+	path, cleanup := tempStorePath(t)
+	defer cleanup()
+	store, _ := NewFileStore(path)
+	bm := NewBookManager()
+	bm.SetStore(store)
+	bm.CreateAccount([]byte("acc1"), 100)
+	bm.Commit()
+	info, _ := os.Stat(path)
+	bm.CreateAccount([]byte("acc1"), 50)
+	bm.Commit()
+	store.Close()
+
+	// simulate crash in the middle of the second write
+	os.Truncate(path, info.Size()+5)
+	store, _ = NewFileStore(path)
+	defer store.Close()
+	loaded, err := LoadBookManager(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Balance([]byte("acc1")) != 100 {
+		t.Errorf("expected balance of the last complete record, got %v", loaded.Balance([]byte("acc1")))
+	}
+}
+
+func TestFileStoreTruncatedTailSave(t *testing.T) {
+	path, cleanup := tempStorePath(t)
+	defer cleanup()
+	store, _ := NewFileStore(path)
+	bm := NewBookManager()
+	bm.SetStore(store)
+	bm.CreateAccount([]byte("acc1"), 100)
+	bm.Commit()
+	info, _ := os.Stat(path)
+	bm.CreateAccount([]byte("acc1"), 50)
+	bm.Commit()
+	store.Close()
+
+	// crash in the middle of the second write, restart and commit again
+	os.Truncate(path, info.Size()+5)
+	store, _ = NewFileStore(path)
+	loaded, err := LoadBookManager(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.CreateAccount([]byte("acc2"), 20)
+	if err = loaded.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, _ = NewFileStore(path)
+	defer store.Close()
+	loaded, err = LoadBookManager(store)
+	if err != nil {
+		t.Fatalf("record saved after incomplete tail should be loaded, got %v", err)
+	}
+	if loaded.Balance([]byte("acc1")) != 100 || loaded.Balance([]byte("acc2")) != 20 {
+		t.Errorf("expected state of the last commit, got %v and %v", loaded.Balance([]byte("acc1")), loaded.Balance([]byte("acc2")))
+	}
+}
+
+func TestFileStoreCorruptedRecord(t *testing.T) {
+	path, cleanup := tempStorePath(t)
+	defer cleanup()
+	store, _ := NewFileStore(path)
+	bm := NewBookManager()
+	bm.SetStore(store)
+	bm.CreateAccount([]byte("acc1"), 100)
+	bm.Commit()
+	bm.CreateAccount([]byte("acc1"), 50)
+	bm.Commit()
+	store.Close()
+
+	data, _ := ioutil.ReadFile(path)
+	data[len(data)-1] ^= 0xFF
+	ioutil.WriteFile(path, data, 0600)
+	store, _ = NewFileStore(path)
+	defer store.Close()
+	if _, err := LoadBookManager(store); err != errCorruptedRecord {
+		t.Errorf("corrupted record should fail the load, got %v", err)
+	}
+	if s, _ := os.Stat(path); s.Size() != int64(len(data)) {
+		t.Errorf("Load should not modify the log")
+	}
+}
+
+func TestFileStoreDeltas(t *testing.T) {
+	path, cleanup := tempStorePath(t)
+	defer cleanup()
+	store, _ := NewFileStore(path)
+	bm, keyPairs := RandomAccounts(1000)
+	bm.SetStore(store)
+	bm.Commit()
+	full, _ := os.Stat(path)
+	blocks := RandomTransactionsBlocks(bm, 10, 1, keyPairs)
+	blocks[0].Number = 1
+	if err := bm.ProcessBlocks(blocks); err != nil {
+		t.Fatal(err)
+	}
+	bm.lock.Lock()
+	delete(bm.balances, string(keyPairs[999].Public))
+	bm.lock.Unlock()
+	bm.Commit()
+	store.Close()
+	info, _ := os.Stat(path)
+	if info.Size()-full.Size() > full.Size()/2 {
+		t.Errorf("commits should append changes only: full record %v, log %v", full.Size(), info.Size())
+	}
+
+	store, _ = NewFileStore(path)
+	defer store.Close()
+	loaded, err := LoadBookManager(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bm.Equals(loaded) || loaded.LastBlock().Number != 1 {
+		t.Errorf("replayed deltas should restore committed state")
+	}
+	if _, ok := loaded.balances[string(keyPairs[999].Public)]; ok {
+		t.Errorf("removed account should stay removed after replay")
+	}
+	loaded.CreateAccount([]byte("acc1"), 7)
+	if err = loaded.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := LoadBookManager(store)
+	if err != nil || again.Balance([]byte("acc1")) != 7 || !again.Equals(loaded) {
+		t.Errorf("delta after reload should be taken against the loaded state")
+	}
+}
+
+func TestFileStoreCompaction(t *testing.T) {
+	path, cleanup := tempStorePath(t)
+	defer cleanup()
+	store, _ := NewFileStore(path)
+	defer store.Close()
+	bm := NewBookManager()
+	bm.SetStore(store)
+	for i := 0; i < compactEvery+1; i++ {
+		bm.CreateAccount([]byte("acc1"), int64(i))
+		bm.UpdateLastBlock(&block.Block{Number: uint64(i), Val: []byte{byte(i)}})
+		if err := bm.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.records != 2 {
+		t.Errorf("store should be compacted, %v records", store.records)
+	}
+	loaded, err := LoadBookManager(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Balance([]byte("acc1")) != compactEvery || loaded.LastBlock().Number != compactEvery {
+		t.Errorf("unexpected state after compaction: balance %v", loaded.Balance([]byte("acc1")))
+	}
+}
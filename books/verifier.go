@@ -0,0 +1,165 @@
+package books
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+// Divergence is the kind of mismatch found by strict verification of blocks
+type Divergence = byte
+
+const (
+	// DivergenceNone is used for blocks which were verified
+	DivergenceNone Divergence = iota
+	// DivergenceNumber means that block number does not follow the last block
+	DivergenceNumber
+	// DivergenceVDF means that block vdf value does not follow vdf value of the last block
+	DivergenceVDF
+	// DivergenceSignature means that block contains transaction with invalid signature
+	DivergenceSignature
+	// DivergenceTransaction means that block contains transaction which is invalid or rejected by replay
+	DivergenceTransaction
+	// DivergenceHeader means that block header does not link to the last block or to block transactions
+	DivergenceHeader
+	// DivergenceStateRoot means that state root of anchored block does not match the state after the block
+	DivergenceStateRoot
+)
+
+var divergenceNames = map[Divergence]string{
+	DivergenceNone:        "",
+	DivergenceNumber:      "block number does not follow the last block",
+	DivergenceVDF:         "vdf value does not follow the last block",
+	DivergenceSignature:   "invalid transaction signature",
+	DivergenceTransaction: "transaction rejected by replay",
+	DivergenceHeader:      "block header does not match the last block or transactions",
+	DivergenceStateRoot:   "state root does not match the state after the block",
+}
+
+// DivergenceName returns human readable description of the divergence
+func DivergenceName(divergence Divergence) string {
+	return divergenceNames[divergence]
+}
+
+// BlockError is returned by ProcessBlocks in strict mode for the first block which
+// diverges from the replayed chain. Transaction is index of the offending
+// transaction in the block or -1, Reason is why replay rejected the transaction.
+type BlockError struct {
+	Number      uint64
+	Divergence  Divergence
+	Transaction int
+	Reason      Reason
+}
+
+func (e *BlockError) Error() string {
+	if e.Transaction < 0 {
+		return fmt.Sprintf("block %v diverges: %v", e.Number, DivergenceName(e.Divergence))
+	}
+	msg := fmt.Sprintf("block %v diverges: %v at transaction %v", e.Number, DivergenceName(e.Divergence), e.Transaction)
+	if e.Reason != ReasonNone {
+		msg += ": " + ReasonName(e.Reason)
+	}
+	return msg
+}
+
+// SetStrictVerification turns strict verification of processed blocks on or off.
+// In strict mode ProcessBlocks checks that every block follows the last block by
+// number, hash and vdf value, re-checks transactions root and signatures,
+// requires every transaction to be accepted by replay and state root of
+// anchored blocks to match the replayed state. The first divergent block is reported
+// as *BlockError and accounts are restored to the state before the batch.
+func (bm *Accounts) SetStrictVerification(strict bool) {
+	bm.strict = strict
+}
+
+// SetVDFScheme selects VDF scheme of the chain which verifies proofs of blocks.
+// It is configured from genesis, iterated SHA-256 is used by default.
+func (bm *Accounts) SetVDFScheme(scheme block.VDFScheme) {
+	bm.vdfScheme = scheme
+}
+
+// VDFScheme returns VDF scheme of the chain, producers generate blocks with it
+func (bm *Accounts) VDFScheme() block.VDFScheme {
+	return bm.vdfScheme
+}
+
+// verifyBlock checks block against the last block before it is replayed.
+// The first block of empty ledger is trusted.
+func (bm *Accounts) verifyBlock(bl *block.Block) error {
+	last := bm.LastBlock()
+	if last != nil && bl.Number != last.Number+1 {
+		return &BlockError{Number: bl.Number, Divergence: DivergenceNumber, Transaction: -1}
+	}
+	if last != nil && !bytes.Equal(bl.PrevHash, last.Hash()) {
+		return &BlockError{Number: bl.Number, Divergence: DivergenceHeader, Transaction: -1}
+	}
+	if !bytes.Equal(bl.TxRoot, block.TransactionsRoot(bl.Transactions)) {
+		return &BlockError{Number: bl.Number, Divergence: DivergenceHeader, Transaction: -1}
+	}
+	if i := bl.Transactions.FirstInvalidSignature(); i >= 0 {
+		return &BlockError{Number: bl.Number, Divergence: DivergenceSignature, Transaction: i}
+	}
+	for i := range bl.Transactions.Ts {
+		if !bl.Transactions.Ts[i].Verify() {
+			return &BlockError{Number: bl.Number, Divergence: DivergenceTransaction, Transaction: i, Reason: ReasonOther}
+		}
+	}
+	if last != nil && !bl.Verify(bm.vdfScheme, last.Val) {
+		return &BlockError{Number: bl.Number, Divergence: DivergenceVDF, Transaction: -1}
+	}
+	return nil
+}
+
+// rejectedTransaction returns error for the first transaction of the block which is not accepted by replay
+func (bm *Accounts) rejectedTransaction(bl *block.Block, accepted []block.Transaction) error {
+	j := 0
+	for i := range bl.Transactions.Ts {
+		tran := &bl.Transactions.Ts[i]
+		if j < len(accepted) && bytes.Equal(accepted[j].Signature, tran.Signature) {
+			j++
+			continue
+		}
+		return &BlockError{Number: bl.Number, Divergence: DivergenceTransaction, Transaction: i,
+			Reason: bm.TransactionReceipt(tran.Signature).Reason}
+	}
+	return nil
+}
+
+// saveBatch returns function which restores accounts, their history and
+// receipts of transactions of the blocks to the current state
+func (bm *Accounts) saveBatch(blocks []block.Block) func() {
+	state := bm.ExportState()
+	bm.lock.Lock()
+	tree := bm.proofTree
+	bm.lock.Unlock()
+	receipts := make(map[string]Receipt)
+	for i := range blocks {
+		for j := range blocks[i].Transactions.Ts {
+			signature := blocks[i].Transactions.Ts[j].Signature
+			receipts[string(signature)] = bm.receipts.get(signature)
+		}
+	}
+	h := bm.history
+	var checkpoints []checkpoint
+	var recorded []block.Block
+	if h != nil {
+		h.mutex.Lock()
+		checkpoints = append(checkpoints, h.checkpoints...)
+		recorded = append(recorded, h.blocks...)
+		h.mutex.Unlock()
+	}
+	return func() {
+		bm.ImportState(state)
+		bm.setStateTree(tree)
+		for signature, receipt := range receipts {
+			bm.receipts.restore([]byte(signature), receipt)
+		}
+		if h != nil {
+			h.mutex.Lock()
+			h.checkpoints = checkpoints
+			h.blocks = recorded
+			h.mutex.Unlock()
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package books
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/block"
+)
+
+// testProducer signs blocks processed in strict mode
+var testProducer = block.NewKeyPair()
+
+// sealed returns the block linked to prev and signed by testProducer
+func sealed(bl block.Block, prev *block.Block) block.Block {
+	bl.Seal(&testProducer, prev)
+	return bl
+}
+
+// strictAccounts returns accounts in strict mode with funded sender and the genesis block
+func strictAccounts() (*Accounts, block.KeyPair, block.Block) {
+	bm := NewBookManager()
+	from := block.NewKeyPair()
+	bm.CreateAccount(from.Public, 100)
+	genesis := block.NewEmpty(block.VDF([]byte("verifier")), 0, 0)
+	genesis.Transactions = new(block.Transactions)
+	bm.ProcessBlocks([]block.Block{genesis})
+	bm.SetStrictVerification(true)
+	return bm, from, genesis
+}
+
+func TestProcessBlocksStrict(t *testing.T) {
+	bm, from, genesis := strictAccounts()
+	to := block.NewKeyPair()
+	trans := block.Transactions{Ts: []block.Transaction{
+		block.NewTransaction(&from, to.Public, 10, 1, genesis.Val),
+		block.NewTransactionWithNonce(&from, to.Public, 20, 1, genesis.Val, 1),
+	}}
+	first := sealed(block.New(genesis.Val, genesis.Number, 0, &trans), &genesis)
+	second := block.NewEmpty(first.Val, first.Number, 0)
+	second.Transactions = new(block.Transactions)
+	second = sealed(second, &first)
+	if err := bm.ProcessBlocks([]block.Block{first, second}); err != nil {
+		t.Fatalf("valid blocks should be processed, got %v", err)
+	}
+	if bm.Balance(to.Public) != 28 || bm.LastBlock().Number != second.Number {
+		t.Errorf("blocks should be replayed, balance %v", bm.Balance(to.Public))
+	}
+}
+
+func TestProcessBlocksVDFScheme(t *testing.T) {
+	bm, _, genesis := strictAccounts()
+	modulus, err := block.GenerateVDFModulus(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme, err := block.NewWesolowskiVDF(modulus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bm.SetVDFScheme(scheme)
+	if bm.Clone().VDFScheme() != block.VDFScheme(scheme) {
+		t.Errorf("clone should verify blocks with the same scheme")
+	}
+	sha := sealed(block.NextBlock(block.SHA256VDF{}, genesis.Val, genesis.Number, 10, &block.Transactions{}), &genesis)
+	if err, ok := bm.ProcessBlocks([]block.Block{sha}).(*BlockError); !ok || err.Divergence != DivergenceVDF {
+		t.Errorf("block of other vdf scheme should diverge, got %v", err)
+	}
+	next := sealed(block.NextBlock(scheme, genesis.Val, genesis.Number, 10, &block.Transactions{}), &genesis)
+	if err := bm.ProcessBlocks([]block.Block{next}); err != nil {
+		t.Errorf("block of the chain scheme should be processed, got %v", err)
+	}
+}
+
+func TestProcessBlocksStrictDivergence(t *testing.T) {
+	bm, from, genesis := strictAccounts()
+	to := block.NewKeyPair()
+	valid := block.Transactions{Ts: []block.Transaction{block.NewTransaction(&from, to.Public, 10, 1, genesis.Val)}}
+
+	skipped := block.New(genesis.Val, genesis.Number+1, 0, &valid)
+	err, ok := bm.ProcessBlocks([]block.Block{skipped}).(*BlockError)
+	if !ok || err.Divergence != DivergenceNumber || err.Number != genesis.Number+2 || err.Transaction != -1 {
+		t.Errorf("block with skipped number should diverge, got %v", err)
+	}
+
+	unlinked := block.New(genesis.Val, genesis.Number, 0, &valid)
+	if err, ok := bm.ProcessBlocks([]block.Block{unlinked}).(*BlockError); !ok || err.Divergence != DivergenceHeader {
+		t.Errorf("block which does not link to the last block should diverge, got %v", err)
+	}
+	changed := sealed(block.New(genesis.Val, genesis.Number, 0, &valid), &genesis)
+	changed.Transactions = &block.Transactions{}
+	if err, ok := bm.ProcessBlocks([]block.Block{changed}).(*BlockError); !ok || err.Divergence != DivergenceHeader {
+		t.Errorf("block whose transactions do not match the root should diverge, got %v", err)
+	}
+
+	forged := sealed(block.New(block.VDF([]byte("forged")), genesis.Number, 0, &valid), &genesis)
+	if err, ok := bm.ProcessBlocks([]block.Block{forged}).(*BlockError); !ok || err.Divergence != DivergenceVDF {
+		t.Errorf("block which does not follow vdf chain should diverge, got %v", err)
+	}
+
+	tampered := block.Transactions{Ts: []block.Transaction{valid.Ts[0], block.NewTransaction(&from, to.Public, 5, 1, genesis.Val)}}
+	tampered.Ts[1].Token = 50
+	bl := sealed(block.New(genesis.Val, genesis.Number, 0, &tampered), &genesis)
+	if err, ok := bm.ProcessBlocks([]block.Block{bl}).(*BlockError); !ok || err.Divergence != DivergenceSignature || err.Transaction != 1 {
+		t.Errorf("block with invalid signature should diverge, got %v", err)
+	}
+
+	overspent := block.Transactions{Ts: []block.Transaction{valid.Ts[0], block.NewTransaction(&from, to.Public, 95, 1, genesis.Val)}}
+	bl = sealed(block.New(genesis.Val, genesis.Number, 0, &overspent), &genesis)
+	err, ok = bm.ProcessBlocks([]block.Block{bl}).(*BlockError)
+	if !ok || err.Divergence != DivergenceTransaction || err.Transaction != 1 || err.Reason != ReasonInsufficientFunds {
+		t.Errorf("block with transaction rejected by replay should diverge, got %v", err)
+	}
+	if err.Error() != "block 2 diverges: transaction rejected by replay at transaction 1: insufficient funds" {
+		t.Errorf("unexpected error message %q", err.Error())
+	}
+
+	bm.SetStrictVerification(false)
+	if err := bm.ProcessBlocks([]block.Block{skipped}); err != nil {
+		t.Errorf("blocks should not be verified without strict mode, got %v", err)
+	}
+}
+
+func TestProcessBlocksStrictRestore(t *testing.T) {
+	bm, from, genesis := strictAccounts()
+	to := block.NewKeyPair()
+	trans := block.Transactions{Ts: []block.Transaction{block.NewTransaction(&from, to.Public, 10, 1, genesis.Val)}}
+	first := sealed(block.New(genesis.Val, genesis.Number, 0, &trans), &genesis)
+	overspent := block.Transactions{Ts: []block.Transaction{block.NewTransactionWithNonce(&from, to.Public, 95, 1, genesis.Val, 1)}}
+	second := sealed(block.New(first.Val, first.Number, 0, &overspent), &first)
+	before := bm.Clone()
+
+	if _, ok := bm.ProcessBlocks([]block.Block{first, second}).(*BlockError); !ok {
+		t.Fatalf("batch with overspending block should diverge")
+	}
+	if !bm.Equals(before) || bm.Balance(from.Public) != 100 || bm.Balance(to.Public) != 0 {
+		t.Errorf("balances should be restored after divergence, got %v", bm.Balance(to.Public))
+	}
+	if bm.LastBlock().Number != genesis.Number || !bytes.Equal(bm.LastBlock().Hash(), genesis.Hash()) {
+		t.Errorf("last block should be restored after divergence, got %v", bm.LastBlock().Number)
+	}
+	if r := bm.TransactionReceipt(trans.Ts[0].Signature); r.Status == StatusConfirmed {
+		t.Errorf("transaction of the discarded batch should not be confirmed")
+	}
+	if err := bm.ProcessBlocks([]block.Block{first}); err != nil || bm.Balance(to.Public) != 9 {
+		t.Errorf("valid block should be processed after divergence, got %v", err)
+	}
+}
+
+func TestProcessBlocksStrictStateRoot(t *testing.T) {
+	bm, from, genesis := strictAccounts()
+	to := block.NewKeyPair()
+	producer, settled := bm.Clone(), bm.Clone()
+	blocks := make([]block.Block, 0, stateRootInterval)
+	prev := genesis
+	for amount := int64(1); prev.Number < stateRootInterval; amount++ {
+		trans := producer.ProcessTransactions(block.Transactions{Ts: []block.Transaction{
+			block.NewTransaction(&from, to.Public, amount, 1, genesis.Val)}})
+		bl := block.New(prev.Val, prev.Number, 0, &trans)
+		settled.Settle(&bl, producer)
+		bl = sealed(bl, &prev)
+		if anchored(bl.Number) == (bl.StateRoot == nil) {
+			t.Fatalf("only anchored blocks should carry state root, block %v", bl.Number)
+		}
+		blocks = append(blocks, bl)
+		prev = bl
+	}
+	replica := bm.Clone()
+	replica.SetStrictVerification(true)
+	if err := bm.ProcessBlocks(blocks); err != nil {
+		t.Fatalf("settled blocks should be processed, got %v", err)
+	}
+	last := blocks[len(blocks)-1]
+	if !bytes.Equal(bm.StateRoot(), last.StateRoot) {
+		t.Errorf("state root should commit the state after the block")
+	}
+	p := producer.AccountProof(to.Public)
+	if p.Height != last.Number || !bytes.Equal(p.Root, last.StateRoot) || !p.Verify() {
+		t.Errorf("producer should prove accounts against the anchored root, got %v", p)
+	}
+
+	forged := blocks[len(blocks)-1]
+	forged.StateRoot = block.TransactionsRoot(forged.Transactions)
+	forged = sealed(forged, &blocks[len(blocks)-2])
+	blocks[len(blocks)-1] = forged
+	if err, ok := replica.ProcessBlocks(blocks).(*BlockError); !ok || err.Divergence != DivergenceStateRoot || err.Number != forged.Number {
+		t.Errorf("block with wrong state root should diverge, got %v", err)
+	}
+}
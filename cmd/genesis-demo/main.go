@@ -46,6 +46,8 @@ func main() {
 	var demoMint mint.Mint
 	err := json.Unmarshal([]byte(data), &demoMint)
 	check(err)
+	scheme, err := demoMint.VDFScheme()
+	check(err)
 
 	keyPairs := block.KeyPairs(numAccounts)
 	mintKeyPair := demoMint.KeyPair
@@ -62,7 +64,7 @@ func main() {
 	var validVDFValues []block.VDFValue
 	lastNumber := uint64(0)
 	for i := 0; i < maxBlockVals; i++ {
-		b := block.NextBlock(validVDFValue, lastNumber, 1, &block.Transactions{})
+		b := block.NextBlock(scheme, validVDFValue, lastNumber, 1, &block.Transactions{})
 		lastNumber++
 		validVDFValue = b.Val
 		validVDFValues = append(validVDFValues, validVDFValue)
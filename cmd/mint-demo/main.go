@@ -7,20 +7,32 @@ import (
 	"os"
 	"strconv"
 
+	"github.com/Ansiblock/Ansiblock/block"
 	"github.com/Ansiblock/Ansiblock/mint"
 )
 
 // Creates a new mint and whites to stdout in JSON format
 // Output of this program should be fed to genesis-demo program
+// Optional second argument selects VDF scheme of the chain: sha256 or wesolowski
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Println("you need to pass number of tokens as an argument")
+	if len(os.Args) != 2 && len(os.Args) != 3 {
+		fmt.Println("you need to pass number of tokens and optionally vdf scheme as arguments")
 		os.Exit(1)
 	}
 
 	numTokens, err := strconv.Atoi(os.Args[1])
 	check(err)
 	newmint := mint.NewMint(int64(numTokens))
+	if len(os.Args) == 3 {
+		newmint.VDF = os.Args[2]
+		if newmint.VDF == block.WesolowskiVDFName {
+			// factors of the modulus are not kept, so nobody can shortcut the VDF
+			newmint.VDFModulus, err = block.GenerateVDFModulus(block.MaxVDFModulusBits)
+			check(err)
+		}
+		_, err = newmint.VDFScheme()
+		check(err)
+	}
 
 	data, err := json.Marshal(newmint)
 	if err != nil {
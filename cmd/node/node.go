@@ -5,6 +5,7 @@ import (
 
 	"github.com/Ansiblock/Ansiblock/pipelines"
 	"github.com/Ansiblock/Ansiblock/replication"
+	"golang.org/x/crypto/ed25519"
 
 	"github.com/Ansiblock/Ansiblock/log"
 )
@@ -17,12 +18,18 @@ func main() {
 }
 
 func runNode() {
-	producer := replication.NewProducerNode("producer", "Zeus")
-	producer.Data.Producer = producer.Data.Self
-	log.Info(fmt.Sprintf("Producer transactions: %v\nProducer messages: %v\n", producer.Sockets.Transaction.LocalAddr().String(), producer.Sockets.Messages.LocalAddr().String()))
-	go pipelines.ProducerNode(producer)
-	go pipelines.SignerNode(producer, "Hera")
-	go pipelines.ServerNode(producer, "Server")
-	go pipelines.SignerNode(producer, "Athena")
-	pipelines.SignerNode(producer, "Demeter")
+	entry := replication.NewProducerNode("producer", "Zeus")
+	entry.Data.Producer = entry.Data.Self
+	log.Info(fmt.Sprintf("Producer transactions: %v\nProducer messages: %v\n", entry.Sockets.Transaction.LocalAddr().String(), entry.Sockets.Messages.LocalAddr().String()))
+	nodes := []replication.Node{entry, replication.NewNode("signer", "Hera"), replication.NewNode("signer", "Athena"), replication.NewNode("signer", "Demeter")}
+	keys := make([]ed25519.PublicKey, len(nodes))
+	for i, node := range nodes {
+		keys[i] = node.Data.Self
+	}
+	// producer role rotates over the nodes, server follows the leader of every slot
+	for _, node := range nodes[1:] {
+		go pipelines.RotatingNode(entry, node, keys, pipelines.RotationSlotLength, pipelines.RotationEpochLength)
+	}
+	go pipelines.RotatingServerNode(entry, "Server", keys, pipelines.RotationSlotLength, pipelines.RotationEpochLength)
+	pipelines.RotatingNode(entry, entry, keys, pipelines.RotationSlotLength, pipelines.RotationEpochLength)
 }
@@ -0,0 +1,134 @@
+// Package merkle implements binary Merkle trees used for state and transaction commitments.
+// Leaves and inner nodes are hashed with different prefixes, so an inner node can never
+// be presented as a leaf. When a level has an odd number of nodes the last one is
+// promoted to the next level unchanged.
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+// HashSize is the size of tree node hashes in bytes
+const HashSize = sha256.Size
+
+const (
+	leafPrefix = byte(0)
+	nodePrefix = byte(1)
+)
+
+var errIndexOutOfRange = errors.New("leaf index out of range")
+
+// Step is one level of an inclusion proof. Hash is the sibling node and
+// Left reports whether the sibling is on the left side.
+type Step struct {
+	Hash []byte
+	Left bool
+}
+
+// Proof is a list of steps from the leaf to the root
+type Proof []Step
+
+// LeafHash returns hash of the leaf data
+func LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// NodeHash returns hash of the inner node with given children
+func NodeHash(left []byte, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+func leafHashes(leaves [][]byte) [][]byte {
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = LeafHash(leaf)
+	}
+	return level
+}
+
+func nextLevel(level [][]byte) [][]byte {
+	next := make([][]byte, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			next = append(next, level[i])
+		} else {
+			next = append(next, NodeHash(level[i], level[i+1]))
+		}
+	}
+	return next
+}
+
+// Tree keeps all levels of Merkle tree, so that proofs of its leaves are
+// returned without rehashing the leaves
+type Tree struct {
+	levels [][][]byte
+}
+
+// NewTree builds Merkle tree over the leaves
+func NewTree(leaves [][]byte) *Tree {
+	tree := &Tree{levels: [][][]byte{leafHashes(leaves)}}
+	for level := tree.levels[0]; len(level) > 1; {
+		level = nextLevel(level)
+		tree.levels = append(tree.levels, level)
+	}
+	return tree
+}
+
+// Root returns root of the tree. Root of the empty tree is hash of empty data.
+func (t *Tree) Root() []byte {
+	top := t.levels[len(t.levels)-1]
+	if len(top) == 0 {
+		res := sha256.Sum256(nil)
+		return res[:]
+	}
+	return top[0]
+}
+
+// Proof returns inclusion proof for the leaf with given index
+func (t *Tree) Proof(index int) (Proof, error) {
+	if index < 0 || index >= len(t.levels[0]) {
+		return nil, errIndexOutOfRange
+	}
+	proof := make(Proof, 0, len(t.levels)-1)
+	for _, level := range t.levels[:len(t.levels)-1] {
+		if index%2 == 1 {
+			proof = append(proof, Step{Hash: level[index-1], Left: true})
+		} else if index+1 < len(level) {
+			proof = append(proof, Step{Hash: level[index+1], Left: false})
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+// Root returns Merkle root of the leaves. Root of the empty tree is hash of empty data.
+func Root(leaves [][]byte) []byte {
+	return NewTree(leaves).Root()
+}
+
+// NewProof returns inclusion proof for the leaf with given index
+func NewProof(leaves [][]byte, index int) (Proof, error) {
+	return NewTree(leaves).Proof(index)
+}
+
+// Verify checks that data is a leaf of the tree with given root
+func Verify(root []byte, data []byte, proof Proof) bool {
+	hash := LeafHash(data)
+	for _, step := range proof {
+		if step.Left {
+			hash = NodeHash(step.Hash, hash)
+		} else {
+			hash = NodeHash(hash, step.Hash)
+		}
+	}
+	return bytes.Equal(hash, root)
+}
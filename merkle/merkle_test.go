@@ -0,0 +1,90 @@
+package merkle
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func leaves(n int) [][]byte {
+	res := make([][]byte, n)
+	for i := range res {
+		res[i] = []byte(fmt.Sprintf("leaf %v", i))
+	}
+	return res
+}
+
+func TestRootSingleLeaf(t *testing.T) {
+	l := leaves(1)
+	if !bytes.Equal(Root(l), LeafHash(l[0])) {
+		t.Errorf("Root of single leaf should be its hash")
+	}
+}
+
+func TestRootTwoLeaves(t *testing.T) {
+	l := leaves(2)
+	if !bytes.Equal(Root(l), NodeHash(LeafHash(l[0]), LeafHash(l[1]))) {
+		t.Errorf("Root of two leaves is wrong")
+	}
+}
+
+func TestRootOddLeaves(t *testing.T) {
+	l := leaves(3)
+	expected := NodeHash(NodeHash(LeafHash(l[0]), LeafHash(l[1])), LeafHash(l[2]))
+	if !bytes.Equal(Root(l), expected) {
+		t.Errorf("last odd node should be promoted")
+	}
+}
+
+func TestRootDependsOnOrder(t *testing.T) {
+	l := leaves(4)
+	root := Root(l)
+	l[0], l[1] = l[1], l[0]
+	if bytes.Equal(root, Root(l)) {
+		t.Errorf("Root should depend on leaves order")
+	}
+}
+
+func TestProofVerify(t *testing.T) {
+	for n := 1; n < 20; n++ {
+		l := leaves(n)
+		root := Root(l)
+		for i := 0; i < n; i++ {
+			proof, err := NewProof(l, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !Verify(root, l[i], proof) {
+				t.Errorf("proof for leaf %v of %v failed", i, n)
+			}
+			if Verify(root, []byte("other"), proof) {
+				t.Errorf("proof for leaf %v of %v accepted wrong data", i, n)
+			}
+		}
+	}
+}
+
+func TestProofOutOfRange(t *testing.T) {
+	if _, err := NewProof(leaves(3), 3); err != errIndexOutOfRange {
+		t.Errorf("NewProof should fail for index out of range")
+	}
+}
+
+func TestTree(t *testing.T) {
+	for n := 0; n < 20; n++ {
+		l := leaves(n)
+		tree := NewTree(l)
+		if !bytes.Equal(tree.Root(), Root(l)) {
+			t.Errorf("tree root of %v leaves is wrong", n)
+		}
+		for i := 0; i < n; i++ {
+			proof, err := tree.Proof(i)
+			if err != nil || !Verify(tree.Root(), l[i], proof) {
+				t.Errorf("tree proof for leaf %v of %v failed", i, n)
+			}
+		}
+		if _, err := tree.Proof(n); err == nil {
+			t.Errorf("tree proof out of range should fail")
+		}
+	}
+}
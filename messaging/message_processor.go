@@ -1,18 +1,22 @@
 package messaging
 
 import (
+	"fmt"
+
 	"github.com/Ansiblock/Ansiblock/books"
+	"github.com/Ansiblock/Ansiblock/log"
 )
 
 // ProcessMessages processes user requests, like check balance,
 // get ValidVDFValue and get Transaction count for testing.
+// Votes of signers are passed to the finality tracker.
 func ProcessMessages(messages []Request, bm *books.Accounts) *Responses {
 	responses := make([]Response, 0, len(messages))
 	for _, message := range messages {
 		switch message.Type {
 		case Balance:
-			balance := bm.Balance(message.PublicKey)
-			response := ResponseBalance{Value: balance, Addr: message.Addr, PublicKey: message.PublicKey}
+			balance := bm.AssetBalance(message.PublicKey, message.Asset)
+			response := ResponseBalance{Value: balance, Addr: message.Addr, PublicKey: message.PublicKey, Asset: message.Asset}
 			responses = append(responses, &response)
 		case ValidVDFValue:
 			validVDFValue := bm.ValidVDFValue()
@@ -22,6 +26,21 @@ func ProcessMessages(messages []Request, bm *books.Accounts) *Responses {
 			transactionsTotal := bm.TransactionsTotal()
 			response := ResponseTransactionsTotal{Addr: message.Addr, Value: transactionsTotal}
 			responses = append(responses, &response)
+		case BalanceProof:
+			proof := bm.AccountProof(message.PublicKey)
+			response := ResponseBalanceProof{AccountProof: *proof, Addr: message.Addr}
+			responses = append(responses, &response)
+		case TransactionStatus:
+			receipt := bm.TransactionReceipt(message.Signature)
+			response := ResponseTransactionStatus{Receipt: receipt, Signature: message.Signature, Addr: message.Addr}
+			responses = append(responses, &response)
+		case Vote:
+			if message.Vote == nil {
+				continue
+			}
+			if _, err := bm.AddVote(message.Vote); err != nil {
+				log.Debug(fmt.Sprintf("vote for block %v rejected: %v", message.Vote.Number, err))
+			}
 		}
 	}
 	return &Responses{Responses: responses}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/Ansiblock/Ansiblock/block"
 	"github.com/Ansiblock/Ansiblock/books"
+	"golang.org/x/crypto/ed25519"
 )
 
 func TestProcessMessagesCheckBalance(t *testing.T) {
@@ -28,6 +29,26 @@ func TestProcessMessagesCheckBalance(t *testing.T) {
 
 }
 
+func TestProcessMessagesCheckAssetBalance(t *testing.T) {
+	accounts := books.NewBookManager()
+	issuer := block.NewKeyPair()
+	accounts.CreateAccount(issuer.Public, 100)
+	vdf := []byte{1, 2, 3}
+	accounts.AddValidVDFValue(vdf)
+	create := block.NewCreateAsset(&issuer, issuer.Public, 500, 1, vdf)
+	accounts.ProcessTransactions(block.Transactions{Ts: []block.Transaction{create}})
+
+	requests := []Request{Request{Type: Balance, PublicKey: issuer.Public, Asset: create.AssetID()},
+		Request{Type: Balance, PublicKey: issuer.Public}}
+	responses := ProcessMessages(requests, accounts)
+	if r := responses.Responses[0].(*ResponseBalance); r.Value != 500 || !bytes.Equal(r.Asset, create.AssetID()) {
+		t.Errorf("response asset balance should be 500 got %d", r.Value)
+	}
+	if r := responses.Responses[1].(*ResponseBalance); r.Value != 99 {
+		t.Errorf("response native balance should be 99 got %d", r.Value)
+	}
+}
+
 func TestProcessMessagesCheckValidVDFValue(t *testing.T) {
 	accounts := books.NewBookManager()
 	accounts.CreateAccount([]byte("acc1"), 100)
@@ -69,3 +90,75 @@ func TestProcessMessagesCheckTransactionsTotal(t *testing.T) {
 		t.Errorf("response transaction count does not match expacted value, should be %d got %d", accounts.TransactionsTotal(), r.Value)
 	}
 }
+
+func TestProcessMessagesBalanceProof(t *testing.T) {
+	accounts := books.NewBookManager()
+	accounts.CreateAccount([]byte("acc1"), 100)
+	accounts.CreateAccount([]byte("acc2"), 100)
+	accounts.CreateAccount([]byte("acc3"), 100)
+
+	requests := []Request{Request{Type: BalanceProof, Addr: nil, PublicKey: []byte("acc2")}}
+	responses := ProcessMessages(requests, accounts)
+	r := responses.Responses[0].(*ResponseBalanceProof)
+	if r.Balance != 100 || !r.Found || !r.Verify() || !bytes.Equal(r.Root, accounts.StateRoot()) {
+		t.Errorf("response balance proof does not verify against state root")
+	}
+}
+
+func TestProcessMessagesTransactionStatus(t *testing.T) {
+	accounts := books.NewBookManager()
+	accounts.CreateAccount([]byte("acc1"), 100)
+	vdf := []byte{1, 2, 3}
+	accounts.AddValidVDFValue(vdf)
+	trans := block.Transactions{}
+	trans.Ts = make([]block.Transaction, 1)
+	trans.Ts[0] = block.Transaction{From: []byte("acc1"), To: []byte("acc2"), Token: 500, ValidVDFValue: vdf, Signature: []byte{1}}
+	accounts.ProcessTransactions(trans)
+
+	requests := Requests{Requests: []Request{Request{Type: TransactionStatus, Signature: make([]byte, 64)}}}
+	requests.Requests[0].Signature[0] = 1
+	var deserialized Requests
+	deserialized.Deserialize(requests.Serialize())
+	responses := ProcessMessages(deserialized.Requests, accounts)
+	r := responses.Responses[0].(*ResponseTransactionStatus)
+	if r.Status != books.StatusUnknown {
+		t.Errorf("status of unknown signature should be unknown, got %v", r.Status)
+	}
+
+	requests.Requests[0].Signature = []byte{1}
+	responses = ProcessMessages(requests.Requests, accounts)
+	r = responses.Responses[0].(*ResponseTransactionStatus)
+	if r.Status != books.StatusRejected || r.Reason != books.ReasonInsufficientFunds {
+		t.Errorf("transaction should be rejected with insufficient funds, got %v", r.Receipt)
+	}
+}
+
+type testVoters map[string]bool
+
+func (v testVoters) Power(voter ed25519.PublicKey) int64 {
+	if v[string(voter)] {
+		return 1
+	}
+	return 0
+}
+
+func (v testVoters) TotalPower() int64 {
+	return int64(len(v))
+}
+
+func TestProcessMessagesVote(t *testing.T) {
+	accounts := books.NewBookManager()
+	voter := block.NewKeyPair()
+	accounts.SetVoters(testVoters{string(voter.Public): true})
+	vote := block.NewVote(&voter, 5, block.VDF([]byte("vote")))
+	requests := Requests{Requests: []Request{Request{Type: Vote, Vote: &vote}}}
+	var deserialized Requests
+	deserialized.Deserialize(requests.Serialize())
+	responses := ProcessMessages(deserialized.Requests, accounts)
+	if len(responses.Responses) != 0 {
+		t.Errorf("vote should have no response")
+	}
+	if accounts.FinalizedHeight() != 5 {
+		t.Errorf("vote of the only voter should make the block final, finalized %v", accounts.FinalizedHeight())
+	}
+}
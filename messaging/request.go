@@ -3,6 +3,7 @@ package messaging
 import (
 	"net"
 
+	"github.com/Ansiblock/Ansiblock/block"
 	"github.com/Ansiblock/Ansiblock/network"
 
 	"golang.org/x/crypto/ed25519"
@@ -20,13 +21,27 @@ const (
 
 	// TransactionsTotal message
 	TransactionsTotal
+
+	// BalanceProof message, balance with Merkle proof of its inclusion into the state root
+	BalanceProof
+
+	// TransactionStatus message, receipt of the transaction with given signature
+	TransactionStatus
+
+	// Vote message, signed vote of a signer for an applied block, it has no response
+	Vote
 )
 
-// Request stores message request and sender address
+// Request stores message request and sender address.
+// Asset is optional user issued asset of Balance request, native token balance
+// is requested when it is empty. Vote is the vote of Vote request.
 type Request struct {
 	Type      Type
 	Addr      net.Addr
 	PublicKey ed25519.PublicKey
+	Signature []byte
+	Asset     []byte
+	Vote      *block.Vote
 }
 
 // Requests is a slice of Request types
@@ -44,12 +59,24 @@ func (r *Requests) Serialize() *network.Packets {
 			packets.Ps[i].Addr = r.Requests[i].Addr
 			packets.Ps[i].Size = 1
 			packets.Ps[i].Data[0] = r.Requests[i].Type
-			if r.Requests[i].Type == Balance {
+			if r.Requests[i].Type == Balance || r.Requests[i].Type == BalanceProof {
 				packets.Ps[i].Size += ed25519.PublicKeySize
 				for j := 0; j < ed25519.PublicKeySize; j++ {
 					packets.Ps[i].Data[j+1] = r.Requests[i].PublicKey[j]
 				}
 			}
+			if r.Requests[i].Type == Balance && len(r.Requests[i].Asset) == block.AssetIDSize {
+				copy(packets.Ps[i].Data[packets.Ps[i].Size:], r.Requests[i].Asset)
+				packets.Ps[i].Size += block.AssetIDSize
+			}
+			if r.Requests[i].Type == TransactionStatus {
+				packets.Ps[i].Size += ed25519.SignatureSize
+				copy(packets.Ps[i].Data[1:1+ed25519.SignatureSize], r.Requests[i].Signature)
+			}
+			if r.Requests[i].Type == Vote && r.Requests[i].Vote != nil {
+				packets.Ps[i].Size += block.VoteSize
+				copy(packets.Ps[i].Data[1:1+block.VoteSize], r.Requests[i].Vote.Serialize())
+			}
 			counter <- true
 		}(i)
 	}
@@ -80,5 +107,19 @@ func (r *Requests) Deserialize(packets *network.Packets) {
 		for j := 0; j < ed25519.PublicKeySize; j++ {
 			r.Requests[i].PublicKey[j] = packets.Ps[i].Data[j+1]
 		}
+		if r.Requests[i].Type == TransactionStatus {
+			r.Requests[i].Signature = make([]byte, ed25519.SignatureSize)
+			copy(r.Requests[i].Signature, packets.Ps[i].Data[1:1+ed25519.SignatureSize])
+		}
+		if r.Requests[i].Type == Vote {
+			if vote, err := block.DeserializeVote(packets.Ps[i].Data[1:packets.Ps[i].Size]); err == nil {
+				r.Requests[i].Vote = &vote
+			}
+		}
+		start := 1 + ed25519.PublicKeySize
+		if r.Requests[i].Type == Balance && int(packets.Ps[i].Size) >= start+block.AssetIDSize {
+			r.Requests[i].Asset = make([]byte, block.AssetIDSize)
+			copy(r.Requests[i].Asset, packets.Ps[i].Data[start:])
+		}
 	}
 }
@@ -56,3 +56,17 @@ func TestRequestWithEmptyRequest(t *testing.T) {
 		Deserialized %v`, requests, deserializedRequests)
 	}
 }
+
+func TestRequestBalanceWithAsset(t *testing.T) {
+	messagingAddr := net.UDPAddr{Port: 12345, IP: net.ParseIP("127.0.0.1")}
+	acc1 := block.NewKeyPair()
+	asset := make([]byte, block.AssetIDSize)
+	asset[0] = 7
+	requests := Requests{Requests: []Request{Request{Type: Balance, Addr: &messagingAddr, PublicKey: acc1.Public, Asset: asset}}}
+
+	var deserializedRequests Requests
+	deserializedRequests.Deserialize(requests.Serialize())
+	if !reflect.DeepEqual(requests, deserializedRequests) {
+		t.Errorf("deserialized requests does not equal original %v != %v", requests, deserializedRequests)
+	}
+}
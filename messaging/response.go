@@ -4,13 +4,23 @@ import (
 	"net"
 
 	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/books"
+	"github.com/Ansiblock/Ansiblock/merkle"
 	"github.com/Ansiblock/Ansiblock/network"
 	"github.com/Ansiblock/Ansiblock/utils"
 	"golang.org/x/crypto/ed25519"
 )
 
+const (
+	// proofStepSize is the size of serialized Merkle proof step: side flag and hash
+	proofStepSize = 1 + merkle.HashSize
+	// maxProofSteps is how many proof steps fit into a packet after balance proof header
+	maxProofSteps = (network.PacketDataSize - 1 - 8 - ed25519.PublicKeySize - 8 - 8 - merkle.HashSize - 2) / proofStepSize
+)
+
 // Response interface is implemented by response concrete types
-// ResponseBalance, ResponseValidVDFValue, ResponseTransactionsTotal
+// ResponseBalance, ResponseValidVDFValue, ResponseTransactionsTotal, ResponseBalanceProof,
+// ResponseTransactionStatus
 type Response interface {
 	Serialize() network.Packet
 	Deserialize(network.Packet)
@@ -21,11 +31,12 @@ type Responses struct {
 	Responses []Response
 }
 
-// ResponseBalance stores balance on requested account
+// ResponseBalance stores balance on requested account, Asset is empty for native token balance
 type ResponseBalance struct {
 	Value     int64
 	Addr      net.Addr
 	PublicKey ed25519.PublicKey
+	Asset     []byte
 }
 
 // ResponseValidVDFValue stores last vdf value on producer node
@@ -40,6 +51,20 @@ type ResponseTransactionsTotal struct {
 	Addr  net.Addr
 }
 
+// ResponseBalanceProof stores balance of requested account with proof of its
+// inclusion into the state root, so the client does not have to trust the node.
+type ResponseBalanceProof struct {
+	books.AccountProof
+	Addr net.Addr
+}
+
+// ResponseTransactionStatus stores receipt of the transaction with requested signature
+type ResponseTransactionStatus struct {
+	books.Receipt
+	Signature []byte
+	Addr      net.Addr
+}
+
 // Serialize method converts ResponseBalance to Packet
 func (rb *ResponseBalance) Serialize() network.Packet {
 	packet := new(network.Packet)
@@ -61,6 +86,10 @@ func (rb *ResponseBalance) Serialize() network.Packet {
 	for j := 0; j < ed25519.PublicKeySize; j++ {
 		packet.Data[j+start] = rb.PublicKey[j]
 	}
+	if len(rb.Asset) == block.AssetIDSize {
+		copy(packet.Data[packet.Size:], rb.Asset)
+		packet.Size += block.AssetIDSize
+	}
 	return *packet
 }
 
@@ -73,6 +102,11 @@ func (rb *ResponseBalance) Deserialize(packet network.Packet) {
 	for j := 0; j < ed25519.PublicKeySize; j++ {
 		rb.PublicKey[j] = packet.Data[j+9]
 	}
+	start := 9 + ed25519.PublicKeySize
+	if int(packet.Size) >= start+block.AssetIDSize {
+		rb.Asset = make([]byte, block.AssetIDSize)
+		copy(rb.Asset, packet.Data[start:])
+	}
 }
 
 // Serialize method converts ResponseValidVDFValue to Packet
@@ -115,6 +149,108 @@ func (rt *ResponseTransactionsTotal) Deserialize(packet network.Packet) {
 	rt.Value = uint64(utils.ByteToInt64(packet.Data[:10], 1))
 }
 
+// Serialize method converts ResponseBalanceProof to Packet.
+// Layout: type, balance, public key, height, nonce, root, found flag, number of
+// proof steps and the steps, each one is a side flag followed by hash.
+// Steps which do not fit into the packet are dropped, so proof of a huge
+// state will not verify.
+func (rp *ResponseBalanceProof) Serialize() network.Packet {
+	packet := new(network.Packet)
+	packet.Addr = rp.Addr
+	packet.Data[0] = BalanceProof
+	start := 1
+	copy(packet.Data[start:], utils.Uint64toByte(uint64(rp.Balance)))
+	start += 8
+	copy(packet.Data[start:start+ed25519.PublicKeySize], rp.PublicKey)
+	start += ed25519.PublicKeySize
+	copy(packet.Data[start:], utils.Uint64toByte(rp.Height))
+	start += 8
+	copy(packet.Data[start:], utils.Uint64toByte(rp.Nonce))
+	start += 8
+	copy(packet.Data[start:start+merkle.HashSize], rp.Root)
+	start += merkle.HashSize
+	if rp.Found {
+		packet.Data[start] = 1
+	}
+	start++
+	steps := len(rp.Proof)
+	if steps > maxProofSteps {
+		steps = maxProofSteps
+	}
+	packet.Data[start] = byte(steps)
+	start++
+	for _, step := range rp.Proof[:steps] {
+		if step.Left {
+			packet.Data[start] = 1
+		}
+		copy(packet.Data[start+1:start+1+merkle.HashSize], step.Hash)
+		start += proofStepSize
+	}
+	packet.Size = uint16(start)
+	return *packet
+}
+
+// Deserialize method converts Packet to ResponseBalanceProof
+func (rp *ResponseBalanceProof) Deserialize(packet network.Packet) {
+	rp.Addr = packet.Addr
+	start := 1
+	rp.Balance = utils.ByteToInt64(packet.Data[:], start)
+	start += 8
+	rp.PublicKey = make([]byte, ed25519.PublicKeySize)
+	copy(rp.PublicKey, packet.Data[start:])
+	start += ed25519.PublicKeySize
+	rp.Height = uint64(utils.ByteToInt64(packet.Data[:], start))
+	start += 8
+	rp.Nonce = uint64(utils.ByteToInt64(packet.Data[:], start))
+	start += 8
+	rp.Root = make([]byte, merkle.HashSize)
+	copy(rp.Root, packet.Data[start:])
+	start += merkle.HashSize
+	rp.Found = packet.Data[start] == 1
+	start++
+	steps := int(packet.Data[start])
+	if steps > maxProofSteps {
+		steps = maxProofSteps
+	}
+	start++
+	rp.Proof = make(merkle.Proof, steps)
+	for i := range rp.Proof {
+		rp.Proof[i].Left = packet.Data[start] == 1
+		rp.Proof[i].Hash = make([]byte, merkle.HashSize)
+		copy(rp.Proof[i].Hash, packet.Data[start+1:])
+		start += proofStepSize
+	}
+}
+
+// Serialize method converts ResponseTransactionStatus to Packet
+func (rs *ResponseTransactionStatus) Serialize() network.Packet {
+	packet := new(network.Packet)
+	packet.Addr = rs.Addr
+	packet.Size = 1 + ed25519.SignatureSize + 1 + 1 + 8
+	packet.Data[0] = TransactionStatus
+	start := 1
+	copy(packet.Data[start:start+ed25519.SignatureSize], rs.Signature)
+	start += ed25519.SignatureSize
+	packet.Data[start] = rs.Status
+	packet.Data[start+1] = rs.Reason
+	start += 2
+	copy(packet.Data[start:], utils.Uint64toByte(rs.Height))
+	return *packet
+}
+
+// Deserialize method converts Packet to ResponseTransactionStatus
+func (rs *ResponseTransactionStatus) Deserialize(packet network.Packet) {
+	rs.Addr = packet.Addr
+	start := 1
+	rs.Signature = make([]byte, ed25519.SignatureSize)
+	copy(rs.Signature, packet.Data[start:])
+	start += ed25519.SignatureSize
+	rs.Status = packet.Data[start]
+	rs.Reason = packet.Data[start+1]
+	start += 2
+	rs.Height = uint64(utils.ByteToInt64(packet.Data[:], start))
+}
+
 // Serialize method converts Responses to Packets
 func (rs *Responses) Serialize() *network.Packets {
 	var result network.Packets
@@ -151,6 +287,14 @@ func (rs *Responses) Deserialize(packets *network.Packets) {
 				var deserializedResponse ResponseTransactionsTotal
 				deserializedResponse.Deserialize(packets.Ps[i])
 				rs.Responses[i] = &deserializedResponse
+			case BalanceProof:
+				var deserializedResponse ResponseBalanceProof
+				deserializedResponse.Deserialize(packets.Ps[i])
+				rs.Responses[i] = &deserializedResponse
+			case TransactionStatus:
+				var deserializedResponse ResponseTransactionStatus
+				deserializedResponse.Deserialize(packets.Ps[i])
+				rs.Responses[i] = &deserializedResponse
 			}
 			counter <- true
 		}(i)
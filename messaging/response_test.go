@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/books"
+	"github.com/Ansiblock/Ansiblock/network"
 )
 
 func TestResponseBalanceSerialize(t *testing.T) {
@@ -44,6 +46,20 @@ func TestResponseBalanceDeserialize(t *testing.T) {
 	}
 }
 
+func TestResponseBalanceWithAsset(t *testing.T) {
+	responseAddr := net.UDPAddr{Port: 12345, IP: net.ParseIP("127.0.0.1")}
+	keyPair := block.NewKeyPair()
+	asset := make([]byte, block.AssetIDSize)
+	asset[1] = 3
+	response := ResponseBalance{Value: 77, Addr: &responseAddr, PublicKey: keyPair.Public, Asset: asset}
+
+	var deserializedResponse ResponseBalance
+	deserializedResponse.Deserialize(response.Serialize())
+	if !reflect.DeepEqual(response, deserializedResponse) {
+		t.Errorf("deserialized response does not equal original %v != %v", response, deserializedResponse)
+	}
+}
+
 func TestResponsResponseValidVDFValueSerialize(t *testing.T) {
 	responseAddr := net.UDPAddr{Port: 12345, IP: net.ParseIP("127.0.0.1")}
 	testValidVDFValue := block.VDF([]byte("TestResponsResponse"))
@@ -179,3 +195,42 @@ func TestResponsesDeserialize(t *testing.T) {
 	}
 
 }
+
+func TestResponseBalanceProofSerializeDeserialize(t *testing.T) {
+	responseAddr := net.UDPAddr{Port: 12345, IP: net.ParseIP("127.0.0.1")}
+	accounts, keyPairs := books.RandomAccounts(100)
+	proof := accounts.AccountProof(keyPairs[7].Public)
+	response := ResponseBalanceProof{AccountProof: *proof, Addr: &responseAddr}
+
+	serializedResponse := response.Serialize()
+	if serializedResponse.Data[0] != BalanceProof || int(serializedResponse.Size) != 91+len(proof.Proof)*proofStepSize {
+		t.Errorf("serialization of ResponseBalanceProof failed, size %v", serializedResponse.Size)
+	}
+
+	var responses Responses
+	responses.Deserialize(&network.Packets{Ps: []network.Packet{serializedResponse}})
+	deserializedResponse := responses.Responses[0].(*ResponseBalanceProof)
+	if !reflect.DeepEqual(&response, deserializedResponse) || !deserializedResponse.Verify() {
+		t.Errorf(`deserialized response does not equal original. \n
+		Original: %v
+		Deserialized %v`, response, deserializedResponse)
+	}
+}
+
+func TestResponseTransactionStatusSerializeDeserialize(t *testing.T) {
+	responseAddr := net.UDPAddr{Port: 12345, IP: net.ParseIP("127.0.0.1")}
+	kp := block.NewKeyPair()
+	tran := block.NewTransaction(&kp, kp.Public, 10, 0, block.VDF([]byte{1}))
+	response := ResponseTransactionStatus{Receipt: books.Receipt{Status: books.StatusRejected, Reason: books.ReasonInsufficientFunds, Height: 1234},
+		Signature: tran.Signature, Addr: &responseAddr}
+
+	serializedResponse := response.Serialize()
+	var responses Responses
+	responses.Deserialize(&network.Packets{Ps: []network.Packet{serializedResponse}})
+	deserializedResponse := responses.Responses[0].(*ResponseTransactionStatus)
+	if !reflect.DeepEqual(&response, deserializedResponse) {
+		t.Errorf(`deserialized response does not equal original. \n
+		Original: %v
+		Deserialized %v`, response, deserializedResponse)
+	}
+}
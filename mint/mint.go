@@ -7,12 +7,16 @@ import (
 
 const privateKeySize = 1024
 
-// Mint structure stores initial amount.
+// Mint structure stores initial amount and VDF scheme of the chain.
+// VDF is the name of the scheme, iterated SHA-256 if empty, VDFModulus is
+// RSA modulus used by Wesolowski scheme.
 type Mint struct {
 	KeyPair    block.KeyPair
 	PrivateKey ed25519.PrivateKey
 	PublicKey  ed25519.PublicKey
 	Tokens     int64
+	VDF        string
+	VDFModulus []byte
 
 	// publicKey  string
 	// publicKey  rsa.PublicKey
@@ -24,7 +28,12 @@ func NewMint(tokens int64) Mint {
 	privateKey := keyPair.Private
 	publicKey := keyPair.Public
 
-	return Mint{keyPair, privateKey, publicKey, tokens}
+	return Mint{KeyPair: keyPair, PrivateKey: privateKey, PublicKey: publicKey, Tokens: tokens}
+}
+
+// VDFScheme returns VDF scheme of the chain configured by the mint
+func (m *Mint) VDFScheme() (block.VDFScheme, error) {
+	return block.NewVDFScheme(m.VDF, m.VDFModulus)
 }
 
 // CreateTransactions returns transaction from mint account to mint account
@@ -52,3 +52,18 @@ func TestValidVDFValue(t *testing.T) {
 		t.Errorf("Error in ValidVDFValue")
 	}
 }
+
+func TestVDFScheme(t *testing.T) {
+	mint := NewMint(1000)
+	if scheme, err := mint.VDFScheme(); err != nil || scheme.Name() != block.SHA256VDFName {
+		t.Errorf("mint without vdf should use SHA256, got %v %v", scheme, err)
+	}
+	mint.VDF = block.WesolowskiVDFName
+	if _, err := mint.VDFScheme(); err == nil {
+		t.Errorf("wesolowski vdf needs modulus")
+	}
+	mint.VDFModulus, _ = block.GenerateVDFModulus(1024)
+	if scheme, err := mint.VDFScheme(); err != nil || scheme.Name() != block.WesolowskiVDFName {
+		t.Errorf("mint should select wesolowski vdf, got %v %v", scheme, err)
+	}
+}
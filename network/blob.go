@@ -16,8 +16,8 @@ const (
 	BlobDataSize = 1024 * 64
 	// BlobRealDataSize represents real data in blob
 	BlobRealDataSize = BlobDataSize - ed25519.PublicKeySize - 8 - 4
-	// NumBlobs represents number of packets in Packets struct
-	NumBlobs = NumPackets * packetDataSize / BlobDataSize
+	// NumBlobs represents number of blobs in Blobs struct, blobs read at once hold 2MB of data
+	NumBlobs = 2 * 1024 * 1024 / BlobDataSize
 
 	fromOffset   = 8
 	flagsOffset  = fromOffset + ed25519.PublicKeySize
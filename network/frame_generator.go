@@ -2,6 +2,7 @@ package network
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 
@@ -14,6 +15,35 @@ const FrameSize = 2 * 1024
 // Frame struct saves blobs. We use it to encode and decode blobs
 type Frame struct {
 	Blobs []*Blob
+	start uint64 // index of the first blob which is not recovered yet
+	reset int32  // set when recovery restarts from the next received blob
+}
+
+// Start returns index of the first blob which is not recovered from the frame
+// yet, a producer taking over from a silent one continues indexing from it
+func (f *Frame) Start() uint64 {
+	return atomic.LoadUint64(&f.start)
+}
+
+// Reset forgets blobs of the frame, recovery restarts from the group of the
+// next received blob. Replaced producer resets its frame before it follows
+// blobs of the new producer, their indexes may overlap its own ones.
+func (f *Frame) Reset() {
+	for i := range f.Blobs {
+		f.Blobs[i] = new(Blob)
+	}
+	atomic.StoreInt32(&f.reset, 1)
+}
+
+// groupStart returns index of the first blob in the coding group of the earliest blob
+func groupStart(blobs *Blobs) uint64 {
+	start := blobs.Bs[0].Index()
+	for i := range blobs.Bs {
+		if index := blobs.Bs[i].Index(); index < start {
+			start = index
+		}
+	}
+	return start - start%NumCoded
 }
 
 // NewFrame returns brand new frame with empty blobs
@@ -91,7 +121,7 @@ func recoverBlobs(frame *Frame, start, end uint64, doneBlobs chan *Blobs) uint64
 func processBlobs(frame *Frame, start, end uint64, blobs *Blobs, doneBlobs chan *Blobs, missingIndexes chan []uint64) (uint64, uint64) {
 	end = fillFrame(frame, start, end, blobs)
 	outputFrame(frame, start, end, "before")
-	for start+NumCoded < end {
+	for start+NumCoded <= end+1 {
 		err := DecodeRS(frame.Blobs, start, end)
 		if err == nil {
 			start = recoverBlobs(frame, start, end, doneBlobs)
@@ -125,7 +155,12 @@ func FrameGenerator(frame *Frame, input <-chan *Blobs) (chan *Blobs, chan []uint
 				log.Error("Frame request failed!")
 				return
 			}
+			if len(blobs.Bs) > 0 && atomic.CompareAndSwapInt32(&frame.reset, 1, 0) {
+				start = groupStart(blobs)
+				end = start
+			}
 			start, end = processBlobs(frame, start, end, blobs, doneBlobs, missingIndexes)
+			atomic.StoreUint64(&frame.start, start)
 		}
 	}()
 	return doneBlobs, missingIndexes
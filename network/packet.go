@@ -10,16 +10,21 @@ import (
 )
 
 const (
-	packetDataSize = 256
+	// PacketDataSize represents maximal size of packet data, it fits into a single UDP datagram.
+	// It is large enough for account proofs with a long Merkle path and for
+	// transactions with memo, cosignatures or up to 255 batch transfer outputs.
+	PacketDataSize = 1024 * 12
+	packetDataSize = PacketDataSize
 	// NumPackets represents number of packets in Packets struct
-	NumPackets  = 1024 * 8
+	NumPackets  = 1024
 	readTimeout = 120 * time.Millisecond //TODO to be determined
 )
 
 // Packet stores transaction data
 type Packet struct {
 	Data [packetDataSize]byte
-	Size uint8
+	// Size is uint16 as packet data is larger than 255 bytes
+	Size uint16
 	Addr net.Addr //UDP Address
 	_    [16]byte //padding
 }
@@ -91,7 +96,7 @@ func NewPackets() *Packets {
 func (ps *Packets) ReadFrom(reader net.PacketConn) int {
 	for i := range ps.Ps {
 		n, addr, err := reader.ReadFrom(ps.Ps[i].Data[:])
-		ps.Ps[i].Size = uint8(n)
+		ps.Ps[i].Size = uint16(n)
 		ps.Ps[i].Addr = addr
 		if err != nil || n == 0 {
 			ps.Ps = ps.Ps[:i]
@@ -15,35 +15,73 @@ const (
 // PacketGenerator thread is responsible for reading packets from socket
 // and sending to the output channel
 func PacketGenerator(reader net.PacketConn, capacity int) <-chan *Packets {
+	return StoppablePacketGenerator(reader, capacity, nil)
+}
+
+// StoppablePacketGenerator works as PacketGenerator until done is closed,
+// pending read is interrupted and the output channel is closed then
+func StoppablePacketGenerator(reader net.PacketConn, capacity int, done <-chan struct{}) <-chan *Packets {
 	// packetCount := 0
 	out := make(chan *Packets, capacity)
+	if done != nil {
+		go func() {
+			<-done
+			reader.SetReadDeadline(time.Now())
+		}()
+	}
 	go func(reader net.PacketConn, p chan<- *Packets) {
 		for {
 			packets := NewPackets()
 			n := packets.ReadFrom(reader)
+			if stopped(done) {
+				close(out)
+				return
+			}
 
 			if n > 0 {
 				// packetCount += len(packets.Ps)
 				log.Info("PacketGenerator: ", zap.Int("Total Packets", n))
 				// fmt.Println(n)
-				out <- packets
+				select {
+				case out <- packets:
+				case <-done:
+					close(out)
+					return
+				}
 			}
 		}
 	}(reader, out)
 	return out
 }
 
+func stopped(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
 // PacketBatch is responsible for reading packets from
 // channel and sending batches of packets to the output
 // maximum batch size should be maxBatchSize packets
 // PacketBatch waits for 1 second for the incoming packets
+// nil is returned when the input is closed and there are no more packets
 func PacketBatch(input <-chan *Packets) []*Packets {
 	batch := make([]*Packets, 0, 200)
 	size := 0
 	ok := true
 	for ok {
 		select {
-		case packets := <-input:
+		case packets, open := <-input:
+			if !open {
+				if len(batch) == 0 {
+					return nil
+				}
+				ok = false
+				break
+			}
 			batch = append(batch, packets)
 			size += len(packets.Ps)
 			if size > maxBatchSize {
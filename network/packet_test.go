@@ -1,6 +1,7 @@
 package network_test
 
 import (
+	"net"
 	"testing"
 
 	"github.com/Ansiblock/Ansiblock/block"
@@ -27,8 +28,8 @@ func TestReadFrom(t *testing.T) {
 	var pc PCon
 	pc.B = make([]byte, 10)
 	n := p.ReadFrom(&pc)
-	if n != 1*8*1024 {
-		t.Errorf("ReadFrom: expected to read %v byte but read %v", 1*8*1024, n)
+	if n != NumPackets {
+		t.Errorf("ReadFrom: expected to read %v byte but read %v", NumPackets, n)
 	}
 	for _, pa := range p.Ps {
 		if pa.Size != 1 {
@@ -37,7 +38,7 @@ func TestReadFrom(t *testing.T) {
 		if pa.Addr == nil {
 			t.Errorf("ReadFrom: expected addr %v but was %v", "127.0.0.1:8000", pa.Addr)
 		}
-		for j := uint8(0); j < pa.Size; j++ {
+		for j := uint16(0); j < pa.Size; j++ {
 			if pa.Data[j] != 1 {
 				t.Fatalf("ReadFrom: unexpected data %v", pa.Data)
 			}
@@ -112,3 +113,22 @@ func TestWriteToError(t *testing.T) {
 		t.Errorf("ReadFrom: expected to write %v byte but wrote %v", 0, n)
 	}
 }
+
+func TestReadFromFullPacket(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	out := NewNumPackets(1)
+	out.Ps[0].Size = PacketDataSize
+	out.Ps[0].Data[PacketDataSize-1] = 7
+	out.Ps[0].Addr = conn.LocalAddr()
+	if out.WriteTo(conn) != PacketDataSize {
+		t.Fatalf("WriteTo: packet of %v bytes was not sent", PacketDataSize)
+	}
+	in := NewNumPackets(1)
+	if in.ReadFrom(conn) != 1 || in.Ps[0].Size != PacketDataSize || in.Ps[0].Data[PacketDataSize-1] != 7 {
+		t.Errorf("ReadFrom: packet of %v bytes should be read whole", PacketDataSize)
+	}
+}
@@ -65,6 +65,19 @@ func DecodeRSBlock(coding [][]byte, data [][]byte) error {
 	return err
 }
 
+// GroupPadding returns empty data blobs which complete the coding group of the
+// blob index, there are none when the index starts a group
+func GroupPadding(index uint64) *Blobs {
+	res := new(Blobs)
+	if offset := index % NumCoded; offset != 0 && offset < NumData {
+		res.Bs = make([]Blob, NumData-offset)
+		for i := range res.Bs {
+			res.Bs[i].Size = DataOffset
+		}
+	}
+	return res
+}
+
 func AddCodingBlobs2(blobs *Blobs, start int) {
 	added := 0
 	blobsLen := len(blobs.Bs)
@@ -163,11 +176,12 @@ func EncodeRS(frame []*Blob, start, numBlobs uint64) error {
 // 	}
 // }
 
+// getMaxDataSize returns the largest size of blobs with indexes from blobStart to blobEnd
 func getMaxDataSize(frame []*Blob, blobStart, blobEnd uint64) uint32 {
 	maxDataSize := uint32(0)
 	for i := blobStart; i < blobEnd; i++ {
 		index := i % FrameSize
-		if frame[index].Size > maxDataSize {
+		if frame[index].Index() == i && frame[index].Size > maxDataSize {
 			maxDataSize = frame[index].Size
 		}
 	}
@@ -207,18 +221,20 @@ func prepairForDecoding(frame []*Blob, data [][]byte, maxDataSize uint32, start,
 	}
 }
 
-func recoverIndex(frame []*Blob, start uint64) {
+// recoverIndex sets index and size of recovered blobs, their data is padded to size
+func recoverIndex(frame []*Blob, start uint64, size uint32) {
 	end := start + NumCoded
 	for i := start; i < end; i++ {
-		if frame[i%FrameSize].Index() != uint64(i) {
+		if frame[i%FrameSize].Size == 0 || frame[i%FrameSize].Index() != uint64(i) {
 			log.Info(fmt.Sprintf("Recovered index %v\n", i))
 			frame[i%FrameSize].SetIndex(uint64(i))
+			frame[i%FrameSize].Size = size
 		}
 	}
 }
 
-// DecodeRS receives frame and recovers damaged data
-// for efficiency it recovers only data,
+// DecodeRS receives frame and recovers damaged data, end is the index of the
+// last received blob. For efficiency it recovers only data,
 // if coded blobs are demaged they will not be recovered
 func DecodeRS(frame []*Blob, start, end uint64) error {
 	if end <= start {
@@ -226,7 +242,7 @@ func DecodeRS(frame []*Blob, start, end uint64) error {
 		return errors.New("Wrong indexes, end <= start")
 	}
 	blockStart := start - (start % NumCoded)
-	if end-blockStart < NumCoded {
+	if end-blockStart+1 < NumCoded {
 		// return if not enough blobs
 		return errors.New("not enough number of blobs")
 	}
@@ -245,8 +261,9 @@ func DecodeRS(frame []*Blob, start, end uint64) error {
 		return errors.New("can not decode, too many blobs are missing")
 	}
 	log.Debug(fmt.Sprintf("DecodeRS: dataMissing: %d codedMissing: %d", dataMissing, codedMissing))
-	// Find out maximum data length from blobs. Every blob will be padded to maximum size
-	maxDataSize := getMaxDataSize(frame, blockStart, codingStart)
+	// Find out maximum data length from blobs. Every blob will be padded to maximum size,
+	// coding blobs have the size when the largest data blob is missing
+	maxDataSize := getMaxDataSize(frame, blockStart, codingEnd)
 	// allocate NumCoded slots, preallocated memory will accelerate DecodeRSBlock
 	var data = make([][]byte, NumData, NumCoded)
 	var coding = make([][]byte, MaxMissing)
@@ -256,7 +273,7 @@ func DecodeRS(frame []*Blob, start, end uint64) error {
 	if err == nil {
 		log.Info(fmt.Sprintf("DecodeRS successful for blobs from: %d to: %d", blockStart, codingEnd))
 		// recover demaged indexes
-		recoverIndex(frame, blockStart)
+		recoverIndex(frame, blockStart, maxDataSize)
 	}
 	return err
 }
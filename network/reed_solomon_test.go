@@ -1,6 +1,7 @@
 package network
 
 import (
+	"bytes"
 	"fmt"
 	"math/rand"
 	"reflect"
@@ -221,3 +222,40 @@ func TestFrameRecovery(t *testing.T) {
 		t.Error("result of Reed-Solomon verify FAILED")
 	}
 }
+
+func TestFrameRecoveryData(t *testing.T) {
+	dataLen := uint64(150)
+	frame, blobLen := generateFrame(dataLen, 0, 16)
+	EncodeRS(frame, 0, uint64(blobLen))
+	want := make([]byte, dataLen)
+	copy(want, frame[3].Data[:dataLen])
+
+	frame[3] = new(Blob)
+	if err := DecodeRS(frame, 0, uint64(blobLen)); err != nil {
+		t.Fatal(err)
+	}
+	if frame[3].Index() != 3 || frame[3].Size == 0 {
+		t.Errorf("recovered blob should get index and size, got %v and %v", frame[3].Index(), frame[3].Size)
+	}
+	if !bytes.Equal(frame[3].Data[DataOffset:dataLen], want[DataOffset:]) {
+		t.Errorf("recovered blob should carry the lost data")
+	}
+}
+
+func TestFrameRecoveryLargestData(t *testing.T) {
+	dataLen := uint64(100)
+	frame, blobLen := generateFrame(dataLen, 0, 16)
+	frame[5].Size = 150
+	fillRandom(frame[5].Data[dataLen:150])
+	EncodeRS(frame, 0, uint64(blobLen))
+	want := make([]byte, 150)
+	copy(want, frame[5].Data[:150])
+
+	frame[5] = new(Blob)
+	if err := DecodeRS(frame, 0, uint64(blobLen)); err != nil {
+		t.Fatal(err)
+	}
+	if frame[5].Size != 150 || !bytes.Equal(frame[5].Data[DataOffset:150], want[DataOffset:]) {
+		t.Errorf("the largest data blob should be recovered with its size, got size %v", frame[5].Size)
+	}
+}
@@ -0,0 +1,148 @@
+package pipelines
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Ansiblock/Ansiblock/api"
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/books"
+	"github.com/Ansiblock/Ansiblock/log"
+	"github.com/Ansiblock/Ansiblock/network"
+	"github.com/Ansiblock/Ansiblock/replication"
+	"golang.org/x/crypto/ed25519"
+)
+
+// producerTimeout is how long signers wait for a block before the producer is
+// considered silent. Blocks are recovered in groups of network.NumData blobs and
+// an idle producer broadcasts about one blob per second.
+const producerTimeout = 30 * time.Second
+
+func lastBlockNumber(bm *books.Accounts) uint64 {
+	if last := bm.LastBlock(); last != nil {
+		return last.Number
+	}
+	return 0
+}
+
+// followProducer makes producer the producer of this node and credits it with
+// fees. When the producer changes, blocks above the final block are rolled
+// back: every node following the new producer, the new producer included,
+// continues the chain from the final block they all agree on.
+func followProducer(bm *books.Accounts, sync *replication.Sync, producer *replication.NodeData) {
+	if !bytes.Equal(sync.MyNodeData().Producer, producer.Self) {
+		sync.ChangeProducer(producer.Self)
+		rollbackToFinal(bm)
+	}
+	bm.SetFeeCollector(producer.Self)
+}
+
+// rollbackToFinal drops processed blocks above the final block
+func rollbackToFinal(bm *books.Accounts) {
+	final := bm.FinalizedHeight()
+	if final == 0 || final >= lastBlockNumber(bm) {
+		return
+	}
+	if err := bm.RollbackTo(final); err != nil {
+		log.Info(fmt.Sprintf("Failover: blocks after final block %v are kept: %v", final, err))
+		return
+	}
+	log.Info(fmt.Sprintf("Failover: rolled back to final block %v", final))
+}
+
+// replacedBy returns the node which replaced the producer, nil if the producer
+// was not replaced. It is the case when more than half of the voting power
+// follows another node which announced itself as producer. Blocks generated by
+// a replaced producer must not be broadcast, they would fork the chain of its
+// successor.
+func replacedBy(sync *replication.Sync, producer ed25519.PublicKey) *replication.NodeData {
+	next := sync.MajorityProducer()
+	if next == nil || bytes.Equal(next.Self, producer) {
+		return nil
+	}
+	log.Info(fmt.Sprintf("Failover: %v replaced this producer", next.NodeName))
+	return next
+}
+
+// Failover watches blocks processed by BlockSigner. When no block arrives for
+// timeout, the successor of the silent producer is announced through sync as
+// the new producer. If the successor is this node, takeover is called to
+// produce blocks after the final block. When the successor stays silent
+// too, the next candidate is chosen. Nodes which did not time out themselves
+// follow the successor as soon as it signs itself as producer.
+func Failover(bm *books.Accounts, sync *replication.Sync, timeout time.Duration, takeover func()) {
+	me := sync.MyNodeData().Self
+	producer := sync.MyNodeData().Producer
+	number := lastBlockNumber(bm)
+	seen := time.Now()
+	attempt := 0
+	for {
+		time.Sleep(timeout / 10)
+		if next := sync.Successor(producer, attempt+1); next != nil && bytes.Equal(next.Producer, next.Self) && next.SignedProducer() {
+			log.Info(fmt.Sprintf("Failover: %v announced itself as producer", next.NodeName))
+			followProducer(bm, sync, next)
+			producer = next.Self
+			seen = time.Now()
+			attempt = 0
+			continue
+		}
+		if n := lastBlockNumber(bm); n != number {
+			number = n
+			seen = time.Now()
+			producer = sync.MyNodeData().Producer
+			attempt = 0
+			continue
+		}
+		if time.Since(seen) < timeout {
+			continue
+		}
+		attempt++
+		seen = time.Now()
+		successor := sync.Successor(producer, attempt)
+		if successor == nil {
+			log.Error(fmt.Sprintf("Failover: no successor for silent producer after block %v", number))
+			continue
+		}
+		log.Info(fmt.Sprintf("Failover: no blocks after %v for %v, new producer is %v", number, timeout, successor.NodeName))
+		followProducer(bm, sync, successor)
+		if bytes.Equal(successor.Self, me) {
+			takeover()
+			return
+		}
+	}
+}
+
+// FailoverSigner runs BlockSigner on the node and Failover with takeover which
+// produces blocks on the node. Blob indexes of the new producer continue from
+// the last blob recovered by the node, so other signers keep their frames.
+func FailoverSigner(bm *books.Accounts, sync *replication.Sync, node replication.Node, timeout time.Duration, applied chan<- block.Block) {
+	signWithFailover(bm, sync, node, network.NewFrame(), node.Sockets.Repair, timeout, nil, applied)
+}
+
+// FailoverProducer runs BlockGenerationFaster on the node. When signers
+// replace the producer, the node signs blocks of its successor and may take
+// over production again, see FailoverSigner. Checkpoints of bm should be
+// enabled, so that blocks above the final block can be rolled back.
+func FailoverProducer(bm *books.Accounts, sync *replication.Sync, node replication.Node, mempool *books.Mempool, startingBlocksTotal uint64, timeout time.Duration, db api.DataBase, applied chan<- block.Block) {
+	frame := network.NewFrame()
+	generateBlocks(bm, sync, node.KeyPair, mempool, frame, node.Sockets.Transaction, node.Sockets.Replicate, node.Sockets.Repair, startingBlocksTotal, db, applied)
+	frame.Reset()
+	bm.SetStrictVerification(true)
+	signWithFailover(bm, sync, node, frame, nil, timeout, db, applied)
+}
+
+// signWithFailover runs signBlocks on the node with blobs recovered in frame.
+// Blocks are produced on the node whenever it succeeds a silent producer,
+// until signers replace it in turn.
+func signWithFailover(bm *books.Accounts, sync *replication.Sync, node replication.Node, frame *network.Frame, reconstructionConn net.PacketConn, timeout time.Duration, db api.DataBase, applied chan<- block.Block) {
+	go signBlocks(bm, sync, frame, node.Sockets.Replicate, reconstructionConn, node.Sockets.Transport, db, applied)
+	for {
+		Failover(bm, sync, timeout, func() {
+			mempool := books.NewMempool(bm, mempoolCapacity)
+			generateBlocks(bm, sync, node.KeyPair, mempool, frame, node.Sockets.Transaction, node.Sockets.Broadcast, nil, lastBlockNumber(bm), db, applied)
+			frame.Reset()
+		})
+	}
+}
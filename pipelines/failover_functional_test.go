@@ -0,0 +1,215 @@
+package pipelines_test
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/books"
+	"github.com/Ansiblock/Ansiblock/mint"
+	"github.com/Ansiblock/Ansiblock/pipelines"
+	"github.com/Ansiblock/Ansiblock/replication"
+)
+
+// waits until every sync knows about numNodes nodes
+func waitForTableSize(syncs []*replication.Sync, numNodes int) bool {
+	for i := 0; i < 60; i++ {
+		ready := true
+		for _, sync := range syncs {
+			if len(sync.TableCopy()) != numNodes {
+				ready = false
+			}
+		}
+		if ready {
+			return true
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	return false
+}
+
+// waits until all nodes in every table agree on the producer
+func waitForProducer(syncs []*replication.Sync, nodes []replication.Node, producer []byte) bool {
+	for i := 0; i < 60; i++ {
+		agreed := true
+		for _, sync := range syncs {
+			table := sync.TableCopy()
+			for _, node := range nodes {
+				if !bytes.Equal(table[string(node.Data.Self)].Producer, producer) {
+					agreed = false
+				}
+			}
+		}
+		if agreed {
+			return true
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	return false
+}
+
+// silentConn drops written packets while silent is set
+type silentConn struct {
+	net.PacketConn
+	silent int32
+}
+
+func (c *silentConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	if atomic.LoadInt32(&c.silent) == 1 {
+		return len(b), nil
+	}
+	return c.PacketConn.WriteTo(b, addr)
+}
+
+// waits until last block of bm is produced by producer after number
+func waitForBlock(bm *books.Accounts, producer []byte, number uint64) *block.Block {
+	for i := 0; i < 240; i++ {
+		if last := bm.LastBlock(); last != nil && last.Number > number && bytes.Equal(last.Producer, producer) {
+			return last
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	return nil
+}
+
+// waits until the node follows producer
+func waitForFollow(sync *replication.Sync, producer []byte) bool {
+	for i := 0; i < 240; i++ {
+		if bytes.Equal(sync.MyNodeData().Producer, producer) {
+			return true
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	return false
+}
+
+// runs Messaging and Voting of the node, blocks passed to the returned channel are voted for
+func startVoting(bm *books.Accounts, sync *replication.Sync, node replication.Node) chan block.Block {
+	applied := make(chan block.Block, 10)
+	bm.SetVoters(sync)
+	go pipelines.Messaging(bm, node.Sockets.Messages, node.Sockets.Respond)
+	go pipelines.Voting(bm, sync, node.KeyPair, applied, node.Sockets.Respond)
+	return applied
+}
+
+// waits until both accounts have the same last block
+func waitForSameBlock(bm1 *books.Accounts, bm2 *books.Accounts) bool {
+	for i := 0; i < 240; i++ {
+		last1, last2 := bm1.LastBlock(), bm2.LastBlock()
+		if last1.Number == last2.Number && bytes.Equal(last1.Hash(), last2.Hash()) {
+			return true
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	return false
+}
+
+// stakes the same token on every node in every accounts
+func stakeNodes(bms []*books.Accounts, m mint.Mint, nodes []replication.Node) {
+	trans := block.Transactions{}
+	for _, node := range nodes {
+		trans.Ts = append(trans.Ts, block.NewStake(&m.KeyPair, node.Data.Self, 100, 0, bms[0].ValidVDFValue()))
+	}
+	for _, bm := range bms {
+		bm.ProcessTransactions(trans)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	producer := replication.NewNode("producer", "failover-producer")
+	producer.Data.Producer = producer.Data.Self
+	bmP, m := processMintAndCreateAccounts()
+	bmP.SetCheckpoints(10, 10)
+	nodes := make([]replication.Node, 2)
+	bms := make([]*books.Accounts, len(nodes))
+	for i := range nodes {
+		nodes[i] = replication.NewNode("signer", "failover-signer"+strconv.Itoa(i))
+		nodes[i].Data.Producer = producer.Data.Self
+		bms[i], _ = processMintAndCreateAccounts()
+	}
+	stakeNodes(append(bms, bmP), m, append(nodes, producer))
+
+	syncP, _ := replication.NewSync(producer.Data)
+	syncP.SetKeyPair(producer.KeyPair)
+	syncP.SetStakes(bmP)
+	broadcast := &silentConn{PacketConn: producer.Sockets.Replicate}
+	silenced := producer
+	silenced.Sockets.Replicate = broadcast
+	appliedP := startVoting(bmP, syncP, producer)
+	go pipelines.Synchronization(syncP, producer.Sockets.Sync, producer.Sockets.SyncSend)
+	go pipelines.FailoverProducer(bmP, syncP, silenced, books.NewMempool(bmP, 1000), bmP.LastBlock().Number, 25*time.Second, nil, appliedP)
+
+	syncs := make([]*replication.Sync, len(nodes))
+	for i := range nodes {
+		bms[i].SetStrictVerification(true)
+		bms[i].SetCheckpoints(10, 10)
+		syncs[i], _ = replication.NewSync(nodes[i].Data)
+		syncs[i].SetKeyPair(nodes[i].KeyPair)
+		syncs[i].SetStakes(bms[i])
+		syncs[i].Insert(syncP.MyCopy())
+		applied := startVoting(bms[i], syncs[i], nodes[i])
+		go pipelines.Synchronization(syncs[i], nodes[i].Sockets.Sync, nodes[i].Sockets.SyncSend)
+		go pipelines.FailoverSigner(bms[i], syncs[i], nodes[i], 25*time.Second, applied)
+	}
+	if !waitForTableSize(append(syncs, syncP), len(nodes)+1) {
+		t.Fatalf("nodes failed to synchronize")
+	}
+	for i := range bms {
+		if waitForBlock(bms[i], producer.Data.Self, 2) == nil {
+			t.Fatalf("%v should sign blocks of the producer", nodes[i].Data.NodeName)
+		}
+	}
+
+	successor := syncs[0].Successor(producer.Data.Self, 1)
+	follower := 0
+	if bytes.Equal(nodes[0].Data.Self, successor.Self) {
+		follower = 1
+	}
+	atomic.StoreInt32(&broadcast.silent, 1)
+
+	// the silent producer seals a transfer which never reaches signers
+	to := block.NewKeyPair()
+	trans := block.Transactions{Ts: []block.Transaction{block.NewTransaction(&m.KeyPair, to.Public, 10, 0, bmP.ValidVDFValue())}}
+	trans.ToPackets(&producer.Data.Addresses.Transaction).WriteTo(nodes[0].Sockets.Respond)
+	for i := 0; i < 40 && bmP.Balance(to.Public) == 0; i++ {
+		time.Sleep(250 * time.Millisecond)
+	}
+	if bmP.Balance(to.Public) != 10 {
+		t.Fatalf("silent producer should process the transfer")
+	}
+
+	for i := range syncs {
+		if !waitForFollow(syncs[i], successor.Self) {
+			t.Fatalf("%v should follow the successor %v", nodes[i].Data.NodeName, successor.NodeName)
+		}
+	}
+	last := waitForBlock(bms[follower], successor.Self, 0)
+	if last == nil {
+		t.Fatalf("%v should sign blocks of the new producer", nodes[follower].Data.NodeName)
+	}
+	if !bytes.Equal(bms[follower].FeeCollector(), successor.Self) {
+		t.Errorf("fees should be credited to the new producer")
+	}
+
+	// the old producer is heard again, it must not fork the chain of its successor
+	atomic.StoreInt32(&broadcast.silent, 0)
+	if !waitForFollow(syncP, successor.Self) {
+		t.Fatalf("old producer should follow its successor")
+	}
+	if waitForBlock(bmP, successor.Self, last.Number) == nil {
+		t.Fatalf("old producer should sign blocks of its successor")
+	}
+	if !waitForSameBlock(bmP, bms[follower]) {
+		t.Fatalf("old producer should follow the chain of its successor")
+	}
+	if bmP.Balance(to.Public) != 0 || bmP.Balance(m.KeyPair.Public) != bms[follower].Balance(m.KeyPair.Public) {
+		t.Errorf("transfer sealed by the replaced producer should be rolled back")
+	}
+	if !waitForProducer(syncs, nodes, successor.Self) {
+		t.Errorf("production should stay with the successor while its blocks arrive")
+	}
+}
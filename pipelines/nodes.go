@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/Ansiblock/Ansiblock/api"
+	"github.com/Ansiblock/Ansiblock/block"
 	"github.com/Ansiblock/Ansiblock/books"
 	"github.com/Ansiblock/Ansiblock/log"
 	"github.com/Ansiblock/Ansiblock/mint"
@@ -25,10 +26,20 @@ func parseMint() mint.Mint {
 	return m
 }
 
+// vdfScheme returns VDF scheme of the chain configured by the mint
+func vdfScheme(m mint.Mint) block.VDFScheme {
+	scheme, err := m.VDFScheme()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	return scheme
+}
+
 // processMintAndCreateAccounts will process genesis blocks and create mint account
 func processMintAndCreateAccounts() (*books.Accounts, mint.Mint, uint64) {
 	bm := books.NewBookManager()
 	m := parseMint()
+	bm.SetVDFScheme(vdfScheme(m))
 	blocks := m.CreateBlocks()
 
 	log.Info("Create genesis blocks")
@@ -47,13 +58,70 @@ func processMintAndCreateAccounts() (*books.Accounts, mint.Mint, uint64) {
 	return bm, m, 2
 }
 
-func producerNodeHelper(producer replication.Node, db api.DataBase) (*books.Accounts, *replication.Sync, mint.Mint) {
-	bm, m, startingBlocksTotal := processMintAndCreateAccounts()
+const (
+	// stateFileSuffix is appended to the node name to get the file where its account state is committed
+	stateFileSuffix = ".state"
+	// snapshotFileSuffix is appended to the node name to get the snapshot file used for bootstrapping
+	snapshotFileSuffix = ".snapshot"
+)
+
+// loadOrCreateAccounts restores accounts from the node's state file and returns
+// the height of the last committed block. When nothing was committed yet the node
+// bootstraps from its snapshot file, or processes genesis blocks if there is no
+// snapshot either. The resulting state is committed. Unreadable state file is fatal.
+func loadOrCreateAccounts(name string) (*books.Accounts, mint.Mint, uint64) {
+	store, err := books.NewFileStore(name + stateFileSuffix)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	bm, err := books.LoadBookManager(store)
+	if err != nil && err != books.ErrNoState {
+		// corrupted state must not be overwritten by genesis or snapshot
+		log.Fatal(fmt.Sprintf("failed to load committed state of %v: %v", name, err))
+	}
+	if err == books.ErrNoState || bm.LastBlock() == nil {
+		if snapshot, err := books.ImportSnapshot(name + snapshotFileSuffix); err == nil {
+			log.Info(fmt.Sprintf("Bootstrap %v from snapshot at height %v", name, snapshot.Height))
+			m := parseMint()
+			bm, _ = books.NewBookManagerFromSnapshot(snapshot)
+			bm.SetVDFScheme(vdfScheme(m))
+			bm.SetStore(store)
+			if err = bm.Commit(); err != nil {
+				log.Fatal(err.Error())
+			}
+			return bm, m, snapshot.Height
+		}
+		log.Info(fmt.Sprintf("No committed state for %v, starting from genesis", name))
+		bm, m, height := processMintAndCreateAccounts()
+		bm.SetStore(store)
+		if err = bm.Commit(); err != nil {
+			log.Fatal(err.Error())
+		}
+		return bm, m, height
+	}
+	height := bm.LastBlock().Number
+	log.Info(fmt.Sprintf("Loaded committed state for %v at height %v", name, height))
+	m := parseMint()
+	bm.SetVDFScheme(vdfScheme(m))
+	return bm, m, height
+}
+
+func producerNodeHelper(producer replication.Node, db api.DataBase) (*books.Accounts, *replication.Sync, *books.Mempool, mint.Mint) {
+	bm, m, startingBlocksTotal := loadOrCreateAccounts(producer.Data.NodeName)
 	producer.Data.Producer = producer.Data.Self
+	bm.SetFeeCollector(producer.Data.Self)
 	sync, _ := replication.NewSync(producer.Data)
+	sync.SetKeyPair(producer.KeyPair)
 	go Messaging(bm, producer.Sockets.Messages, producer.Sockets.Respond)
 	go Synchronization(sync, producer.Sockets.Sync, producer.Sockets.SyncSend)
-	go BlockGenerationFaster(bm, sync, producer.Sockets.Transaction, producer.Sockets.Replicate, producer.Sockets.Repair, startingBlocksTotal, db)
+	mempool := books.NewMempool(bm, mempoolCapacity)
+	applied := make(chan block.Block, votingChannelCapacity)
+	sync.SetStakes(bm)
+	bm.SetVoters(sync)
+	go Voting(bm, sync, producer.KeyPair, applied, producer.Sockets.Respond)
+	go Slashing(bm, sync, producer.KeyPair, mempool, producer.Sockets.Respond)
+	bm.SetCheckpoints(checkpointInterval, checkpointCount)
+	go FailoverProducer(bm, sync, producer, mempool, startingBlocksTotal, producerTimeout, db, applied)
 	// log.Debug(fmt.Sprintf("Producer Node: %v", producer.Data.Addresses))
 	log.Debug(fmt.Sprintf("Producer Node:\n blocks: %v\n messages: %v\n Replicate: %v\n Transport: %v\n",
 		producer.Sockets.Transaction.LocalAddr().String(),
@@ -61,14 +129,15 @@ func producerNodeHelper(producer replication.Node, db api.DataBase) (*books.Acco
 		producer.Sockets.Replicate.LocalAddr().String(),
 		producer.Sockets.Transport.LocalAddr().String()))
 
-	return bm, sync, m
+	return bm, sync, mempool, m
 }
 
 // ProducerNodeWithServer is responsible createing producer node and run server on it
 func ProducerNodeWithServer(producer replication.Node) {
 	db := api.NewDBConnection(api.DBFilename)
-	bm, sync, mint := producerNodeHelper(producer, db)
+	bm, sync, mempool, mint := producerNodeHelper(producer, db)
 	blockchainAPI := api.New(bm, db, sync, &mint)
+	blockchainAPI.SetMempool(mempool)
 	api.RunRestAPI(blockchainAPI)
 	ch := make(chan bool)
 	<-ch
@@ -83,15 +152,24 @@ func ProducerNode(producer replication.Node) {
 
 // SignerNode is responsible creating signer
 func SignerNode(producer replication.Node, name string) {
-	bm, _, _ := processMintAndCreateAccounts()
+	bm, _, _ := loadOrCreateAccounts(name)
 	node := replication.NewNode("signer", name)
 	log.Info(fmt.Sprintf(" ==== Signer %v: %v ==== \n", name, node.Sockets.Messages.LocalAddr().String()))
 	node.Data.Producer = producer.Data.Self
+	bm.SetFeeCollector(producer.Data.Self)
+	bm.SetStrictVerification(true)
+	bm.SetCheckpoints(checkpointInterval, checkpointCount)
 	sync, _ := replication.NewSync(node.Data)
+	sync.SetKeyPair(node.KeyPair)
 	sync.Insert(producer.Data)
 	go Messaging(bm, node.Sockets.Messages, node.Sockets.Respond)
 	go Synchronization(sync, node.Sockets.Sync, node.Sockets.SyncSend)
-	go BlockSigner(bm, sync, node.Sockets.Replicate, node.Sockets.Repair, node.Sockets.Transport, nil)
+	applied := make(chan block.Block, votingChannelCapacity)
+	sync.SetStakes(bm)
+	bm.SetVoters(sync)
+	go Voting(bm, sync, node.KeyPair, applied, node.Sockets.Respond)
+	go Slashing(bm, sync, node.KeyPair, nil, node.Sockets.Respond)
+	go FailoverSigner(bm, sync, node, producerTimeout, applied)
 	// log.Debug(fmt.Sprintf("Signer Node: %v", node.Data.Addresses))
 	log.Debug(fmt.Sprintf("Signer Node:\n blocks: %v\n messages: %v\n Replicate: %v\n Transport: %v\n",
 		node.Sockets.Transaction.LocalAddr().String(),
@@ -104,16 +182,31 @@ func SignerNode(producer replication.Node, name string) {
 
 // ServerNode is responsible creating server node
 func ServerNode(producer replication.Node, name string) {
-	bm, mint, _ := processMintAndCreateAccounts()
+	serverNode(producer, name, nil)
+}
+
+// serverNode runs server node, blocks are signed by the leaders of schedule
+// when it is not nil
+func serverNode(producer replication.Node, name string, schedule *books.LeaderSchedule) {
+	bm, mint, _ := loadOrCreateAccounts(name)
 	node := replication.NewNode("server", name)
 	node.Data.Producer = producer.Data.Self
+	if schedule != nil {
+		bm.SetLeaderSchedule(schedule)
+	} else {
+		bm.SetFeeCollector(producer.Data.Self)
+	}
 	sync, _ := replication.NewSync(node.Data)
+	sync.SetKeyPair(node.KeyPair)
 	sync.Insert(producer.Data)
 
 	db := api.NewDBConnection(api.DBFilename)
 
+	sync.SetStakes(bm)
+	bm.SetVoters(sync)
+	go Messaging(bm, node.Sockets.Messages, node.Sockets.Respond)
 	go Synchronization(sync, node.Sockets.Sync, node.Sockets.SyncSend)
-	go BlockSigner(bm, sync, node.Sockets.Replicate, node.Sockets.Repair, node.Sockets.Transport, db)
+	go BlockSigner(bm, sync, node.Sockets.Replicate, node.Sockets.Repair, node.Sockets.Transport, db, nil)
 
 	blockchainAPI := api.New(bm, db, sync, &mint)
 	api.RunRestAPI(blockchainAPI)
@@ -20,16 +20,22 @@ const (
 	messageChannelCapacity         = 10
 	synchronizationChannelCapacity = 10
 	signerChannelCapacity          = 10
+	mempoolCapacity                = 1024 * 64
+	checkpointInterval             = 100
+	checkpointCount                = 10
 	synchronizationTimeoutDuration = 1000 * time.Millisecond
 )
 
-// BlockGeneration is run on the producer node and is responsible for transaction processing and generating blocks
-func BlockGeneration(bm *books.Accounts, sync *replication.Sync, inputConn net.PacketConn, outputConn net.PacketConn, reconstructionConn net.PacketConn, startingBlocksTotal uint64, db api.DataBase) {
+// BlockGeneration is run on the producer node and is responsible for transaction processing and generating blocks.
+// Blocks are linked to the last block, anchored to the state after them, see books.Settle,
+// and signed with keyPair before they are saved and broadcast.
+func BlockGeneration(bm *books.Accounts, sync *replication.Sync, keyPair block.KeyPair, inputConn net.PacketConn, outputConn net.PacketConn, reconstructionConn net.PacketConn, startingBlocksTotal uint64, db api.DataBase) {
+	settled := bm.Clone()
 	packets := network.PacketGenerator(inputConn, blockGenerationChannelCapacity)
 	filteredPackets := books.SignatureVerification(packets)
 	transactions := books.TransactionGenerator(bm, filteredPackets)
 	blocks := block.Generator(transactions, bm.ValidVDFValue(), startingBlocksTotal)
-	batch := block.Saver(blocks, db)
+	batch := block.Saver(blocks, nil)
 	blobs := make(chan *network.Blobs, cap(batch))
 	index := int32(0)
 	frame := network.NewFrame()
@@ -39,36 +45,85 @@ func BlockGeneration(bm *books.Accounts, sync *replication.Sync, inputConn net.P
 	}
 	// go func() {
 	for b := range batch {
+		if next := replacedBy(sync, keyPair.Public); next != nil {
+			followProducer(bm, sync, next)
+			return
+		}
 		num := int32(0)
-		for _, block := range b {
-			fmt.Printf("block %v\n", block.Number)
-			bm.UpdateLastBlock(&block)
-			num += block.Transactions.Count()
-			index += block.Transactions.Count()
+		for i := range b {
+			fmt.Printf("block %v\n", b[i].Number)
+			settled.Settle(&b[i], bm)
+			b[i].Seal(&keyPair, bm.LastBlock())
+			bm.UpdateLastBlock(&b[i])
+			bm.ConfirmTransactions(b[i].Transactions, b[i].Number)
+			bm.AdvanceStakes(b[i].Transactions, b[i].Number)
+			bm.CollectFees(b[i].Transactions, b[i].Number)
+			num += b[i].Transactions.Count()
+			index += b[i].Transactions.Count()
+		}
+		if err := bm.Commit(); err != nil {
+			log.Error(fmt.Sprintf("failed to commit account state: %v", err))
 		}
+		go block.BatchSaver(b, db)
 		fmt.Printf("broadcasting %v transactions. sum = %v\n", num, index)
 		blobs <- block.BlocksToBlobs(b)
 	}
 	// }()
 }
 
-// BlockGeneration is run on the producer node and is responsible for transaction processing and generating blocks
-func BlockGenerationFaster(bm *books.Accounts, sync *replication.Sync, inputConn net.PacketConn, outputConn net.PacketConn, reconstructionConn net.PacketConn, startingBlocksTotal uint64, db api.DataBase) {
-	packets := network.PacketGenerator(inputConn, blockGenerationChannelCapacity)
+// BlockGenerationFaster is run on the producer node and is responsible for transaction processing and generating blocks.
+// Verified transactions wait in the mempool and are processed in order of decreasing fee.
+// Blocks are linked to the last block, anchored and signed with keyPair, committed blocks
+// are passed to applied for voting, applied may be nil. Generation stops when
+// signers replaced the producer, see FailoverProducer.
+func BlockGenerationFaster(bm *books.Accounts, sync *replication.Sync, keyPair block.KeyPair, mempool *books.Mempool, inputConn net.PacketConn, outputConn net.PacketConn, reconstructionConn net.PacketConn, startingBlocksTotal uint64, db api.DataBase, applied chan<- block.Block) {
+	generateBlocks(bm, sync, keyPair, mempool, network.NewFrame(), inputConn, outputConn, reconstructionConn, startingBlocksTotal, db, applied)
+}
+
+// generateBlocks runs BlockGenerationFaster, blobs are broadcast through frame.
+// When the producer is replaced, the whole pipeline is stopped and accounts
+// return to the last sealed block before the producer follows its successor.
+func generateBlocks(bm *books.Accounts, sync *replication.Sync, keyPair block.KeyPair, mempool *books.Mempool, frame *network.Frame, inputConn net.PacketConn, outputConn net.PacketConn, reconstructionConn net.PacketConn, startingBlocksTotal uint64, db api.DataBase, applied chan<- block.Block) {
+	settled := bm.Clone()
+	done := make(chan struct{})
+	packets := network.StoppablePacketGenerator(inputConn, blockGenerationChannelCapacity, done)
 	filteredPackets := books.SignatureVerification(packets)
-	transactions := books.TransactionGenerator(bm, filteredPackets)
+	transactions := books.MempoolTransactionGenerator(bm, mempool, filteredPackets)
 	blocks := block.Generator(transactions, bm.ValidVDFValue(), startingBlocksTotal)
 	batch := block.Batcher(blocks)
 	blobs := make(chan *network.Blobs, cap(batch))
-	frame := network.NewFrame()
 	go replication.Broadcaster(sync, frame, outputConn, blobs)
-	go RequestBlobs(sync, frame, reconstructionConn, outputConn)
+	if reconstructionConn != nil {
+		go RequestBlobs(sync, frame, reconstructionConn, outputConn)
+	}
 	// temp := 0
 	// go func() {
 	for b := range batch {
-		for _, block := range b {
-			go bm.UpdateLastBlock(&block)
+		if next := replacedBy(sync, keyPair.Public); next != nil {
+			close(done)
+			mempool.Stop()
+			unsealed := blockTransactions(b)
+			for rest := range batch {
+				unsealed = append(unsealed, blockTransactions(rest)...)
+			}
+			close(blobs)
+			settled.Unsettle(bm, unsealed)
+			followProducer(bm, sync, next)
+			return
 		}
+		for i := range b {
+			settled.Settle(&b[i], bm)
+			b[i].Seal(&keyPair, bm.LastBlock())
+			settled.Sealed(&b[i], bm)
+			bm.UpdateLastBlock(&b[i])
+			bm.ConfirmTransactions(b[i].Transactions, b[i].Number)
+			bm.AdvanceStakes(b[i].Transactions, b[i].Number)
+			bm.CollectFees(b[i].Transactions, b[i].Number)
+		}
+		if err := bm.Commit(); err != nil {
+			log.Error(fmt.Sprintf("failed to commit account state: %v", err))
+		}
+		announceBlock(applied, b[len(b)-1])
 		go block.BatchSaver(b, db)
 		b1 := block.BlocksToBlobs(b)
 		// for _, b2 := range b1.Bs {
@@ -80,6 +135,17 @@ func BlockGenerationFaster(bm *books.Accounts, sync *replication.Sync, inputConn
 	// }()
 }
 
+// blockTransactions returns transactions of the blocks
+func blockTransactions(blocks []block.Block) []block.Transaction {
+	res := make([]block.Transaction, 0)
+	for i := range blocks {
+		if blocks[i].Transactions != nil {
+			res = append(res, blocks[i].Transactions.Ts...)
+		}
+	}
+	return res
+}
+
 // Messaging is run on the producer or signer node and is responsible for Message processing
 func Messaging(bm *books.Accounts, inputConn net.PacketConn, outputConn net.PacketConn) {
 	packets := network.PacketGenerator(inputConn, messageChannelCapacity)
@@ -100,25 +166,37 @@ func Synchronization(sync *replication.Sync, inputConn net.PacketConn, outputCon
 			mixedBlobs <- &network.Blobs{Bs: []network.Blob{*syncBlob}}
 		}
 	}()
+	go func() {
+		for evidenceBlobs := range replication.EvidenceGossip(sync) {
+			mixedBlobs <- evidenceBlobs
+		}
+	}()
 	for listenBlob := range listenBlobs {
 		mixedBlobs <- listenBlob
 
 	}
 }
 
-// BlockSigner is run on every node and is responsible for block signer
-func BlockSigner(bm *books.Accounts, sync *replication.Sync, replicationConn net.PacketConn, reconstructionConn net.PacketConn, outputConn net.PacketConn, db api.DataBase) {
+// BlockSigner is run on every node and is responsible for block signer.
+// Applied blocks are passed to applied for voting, applied may be nil.
+func BlockSigner(bm *books.Accounts, sync *replication.Sync, replicationConn net.PacketConn, reconstructionConn net.PacketConn, outputConn net.PacketConn, db api.DataBase, applied chan<- block.Block) {
+	signBlocks(bm, sync, network.NewFrame(), replicationConn, reconstructionConn, outputConn, db, applied)
+}
+
+// signBlocks runs BlockSigner, received blobs are recovered in frame.
+// Repair requests are not served when reconstructionConn is nil.
+func signBlocks(bm *books.Accounts, sync *replication.Sync, frame *network.Frame, replicationConn net.PacketConn, reconstructionConn net.PacketConn, outputConn net.PacketConn, db api.DataBase, applied chan<- block.Block) {
 	blobsReceiver := network.BlobGenerator(replicationConn, signerChannelCapacity)
-	frame := network.NewFrame()
-	go RequestBlobs(sync, frame, reconstructionConn, outputConn)
+	if reconstructionConn != nil {
+		go RequestBlobs(sync, frame, reconstructionConn, outputConn)
+	}
 	replicationBlobs := make(chan *network.Blobs, cap(blobsReceiver))
 	transportBlobs := make(chan *network.Blobs, cap(blobsReceiver))
 	go func() {
 		for blobs := range blobsReceiver {
 			log.Debug(fmt.Sprintf("Got blobs on replication socket %v", replicationConn.LocalAddr().String()))
 			fmt.Printf("Got %v blobs on replicate socket %v\n", len(blobs.Bs), replicationConn.LocalAddr().String())
-			replicationBlobs <- blobs
-			transportBlobs <- blobs
+			// blocks are saved before replication, so that divergent ones are deleted
 			if db != nil {
 				blocks := block.BlobsToBlocks(blobs)
 				for _, bl := range blocks {
@@ -126,11 +204,13 @@ func BlockSigner(bm *books.Accounts, sync *replication.Sync, replicationConn net
 					db.SaveBlock(bl)
 				}
 			}
+			replicationBlobs <- blobs
+			transportBlobs <- blobs
 		}
 	}()
 	reconBlobs := reconstruction.Reconstruct(frame, replicationBlobs, sync, outputConn)
 
-	replication.New(bm, reconBlobs)
+	replication.New(bm, sync, reconBlobs, db, applied)
 	replication.Transporter(sync, transportBlobs, outputConn)
 
 }
@@ -0,0 +1,155 @@
+package pipelines
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/Ansiblock/Ansiblock/api"
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/books"
+	"github.com/Ansiblock/Ansiblock/log"
+	"github.com/Ansiblock/Ansiblock/network"
+	"github.com/Ansiblock/Ansiblock/replication"
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	// slotTickDuration is how long the producer waits for transactions before it produces an empty block
+	slotTickDuration = 200 * time.Millisecond
+	// rotationPollInterval is how often a node checks whether its slot has come
+	rotationPollInterval = 50 * time.Millisecond
+	// RotationSlotLength is the number of blocks in a slot of the node entry points
+	RotationSlotLength = 16
+	// RotationEpochLength is the number of slots in an epoch of the node entry points
+	RotationEpochLength = 8
+)
+
+// SlotProduction produces and broadcasts blocks of the producer's slot signed
+// with keyPair, it returns after the last block of the slot is broadcast.
+// Committed blocks are passed to applied for voting.
+func SlotProduction(bm *books.Accounts, keyPair block.KeyPair, mempool *books.Mempool, end uint64, blobs chan<- *network.Blobs, db api.DataBase, applied chan<- block.Block) {
+	settled := bm.Clone()
+	for bl := range books.SlotGenerator(bm, mempool, end, slotTickDuration) {
+		settled.Settle(&bl, bm)
+		bl.Seal(&keyPair, bm.LastBlock())
+		bm.UpdateLastBlock(&bl)
+		bm.ConfirmTransactions(bl.Transactions, bl.Number)
+		bm.AdvanceStakes(bl.Transactions, bl.Number)
+		bm.CollectFees(bl.Transactions, bl.Number)
+		if err := bm.Commit(); err != nil {
+			log.Error(fmt.Sprintf("failed to commit account state: %v", err))
+		}
+		announceBlock(applied, bl)
+		batch := []block.Block{bl}
+		go block.BatchSaver(batch, db)
+		blobs <- block.BlocksToBlobs(batch)
+	}
+}
+
+// Rotation switches the node between producer and signer roles at slot
+// boundaries of the leader schedule. Producer of the next block is announced
+// through sync, in its own slots the node produces blocks from its mempool,
+// in other slots blocks of the leader are processed by BlockSigner. Blob
+// indexes of consecutive slots of the node continue from the last blob
+// recovered by the node. The last coding group of the slots is completed, so
+// that the next leader recovers the last block without waiting for more blobs.
+// Rotation starts when nodes of the schedule are known through sync, blocks
+// broadcast earlier would be lost for them.
+func Rotation(bm *books.Accounts, sync *replication.Sync, node replication.Node, mempool *books.Mempool, db api.DataBase, applied chan<- block.Block) {
+	me := sync.MyNodeData().Self
+	frame := network.NewFrame()
+	go signBlocks(bm, sync, frame, node.Sockets.Replicate, node.Sockets.Repair, node.Sockets.Transport, db, applied)
+	waitForScheduleNodes(bm, sync)
+	var blobs chan *network.Blobs
+	var broadcast <-chan struct{}
+	for {
+		last := bm.LastBlock()
+		if last == nil {
+			time.Sleep(rotationPollInterval)
+			continue
+		}
+		next := last.Number + 1
+		leader := bm.Leader(next)
+		if leader == nil {
+			time.Sleep(rotationPollInterval)
+			continue
+		}
+		if !bytes.Equal(sync.MyNodeData().Producer, leader) {
+			sync.ChangeProducer(leader)
+		}
+		if !bytes.Equal(leader, me) {
+			if blobs != nil {
+				blobs <- new(network.Blobs)
+				close(blobs)
+				<-broadcast
+				blobs = nil
+				// blobs of the next leader continue after the slots of the node
+				frame.Reset()
+			}
+			time.Sleep(rotationPollInterval)
+			continue
+		}
+		if blobs == nil {
+			blobs = make(chan *network.Blobs, signerChannelCapacity)
+			broadcast = replication.Broadcaster(sync, frame, node.Sockets.Broadcast, blobs)
+		}
+		end := bm.LeaderSchedule().SlotEnd(next)
+		log.Info(fmt.Sprintf("Rotation: producing slot from %v to %v", next, end))
+		SlotProduction(bm, node.KeyPair, mempool, end, blobs, db, applied)
+	}
+}
+
+// waitForScheduleNodes returns when all nodes of the leader schedule are in the sync table
+func waitForScheduleNodes(bm *books.Accounts, sync *replication.Sync) {
+	for {
+		schedule := bm.LeaderSchedule()
+		if schedule == nil {
+			return
+		}
+		table := sync.TableCopy()
+		known := true
+		for _, node := range schedule.Nodes() {
+			if _, ok := table[string(node)]; !ok {
+				known = false
+			}
+		}
+		if known {
+			return
+		}
+		time.Sleep(rotationPollInterval)
+	}
+}
+
+// RotatingNode runs node which takes producer role in its slots of the leader
+// schedule over nodes and signs blocks of other producers otherwise. The node
+// joins the network through entry. Transactions received by the node wait in
+// its mempool until its slot.
+func RotatingNode(entry replication.Node, node replication.Node, nodes []ed25519.PublicKey, slotLength uint64, epochLength uint64) {
+	bm, _, _ := loadOrCreateAccounts(node.Data.NodeName)
+	log.Info(fmt.Sprintf(" ==== Rotating node %v: %v ==== \n", node.Data.NodeName, node.Sockets.Transaction.LocalAddr().String()))
+	node.Data.Producer = entry.Data.Self
+	bm.SetLeaderSchedule(books.NewLeaderSchedule(nodes, slotLength, epochLength))
+	bm.SetStrictVerification(true)
+	bm.SetCheckpoints(checkpointInterval, checkpointCount)
+	sync, _ := replication.NewSync(node.Data)
+	sync.SetKeyPair(node.KeyPair)
+	sync.Insert(entry.Data)
+	mempool := books.NewMempool(bm, mempoolCapacity)
+	go Messaging(bm, node.Sockets.Messages, node.Sockets.Respond)
+	go Synchronization(sync, node.Sockets.Sync, node.Sockets.SyncSend)
+	applied := make(chan block.Block, votingChannelCapacity)
+	sync.SetStakes(bm)
+	bm.SetVoters(sync)
+	go Voting(bm, sync, node.KeyPair, applied, node.Sockets.Respond)
+	go Slashing(bm, sync, node.KeyPair, mempool, node.Sockets.Respond)
+	packets := network.PacketGenerator(node.Sockets.Transaction, blockGenerationChannelCapacity)
+	go books.MempoolIntake(mempool, books.SignatureVerification(packets))
+	Rotation(bm, sync, node, mempool, nil, applied)
+}
+
+// RotatingServerNode runs server node which follows producers of the leader
+// schedule over nodes, see RotatingNode
+func RotatingServerNode(entry replication.Node, name string, nodes []ed25519.PublicKey, slotLength uint64, epochLength uint64) {
+	serverNode(entry, name, books.NewLeaderSchedule(nodes, slotLength, epochLength))
+}
@@ -0,0 +1,61 @@
+package pipelines_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Ansiblock/Ansiblock/books"
+	"github.com/Ansiblock/Ansiblock/pipelines"
+	"github.com/Ansiblock/Ansiblock/replication"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestRotation(t *testing.T) {
+	nodes := make([]replication.Node, 3)
+	keys := make([]ed25519.PublicKey, len(nodes))
+	for i := range nodes {
+		nodes[i] = replication.NewNode("signer", "rotation-node"+strconv.Itoa(i))
+		keys[i] = nodes[i].Data.Self
+	}
+	syncs := make([]*replication.Sync, len(nodes))
+	bms := make([]*books.Accounts, len(nodes))
+	for i := range nodes {
+		nodes[i].Data.Producer = keys[0]
+		bms[i], _ = processMintAndCreateAccounts()
+		bms[i].SetLeaderSchedule(books.NewLeaderSchedule(keys, 4, 4))
+		bms[i].SetStrictVerification(true)
+		bms[i].SetCheckpoints(10, 10)
+		syncs[i], _ = replication.NewSync(nodes[i].Data)
+		syncs[i].SetKeyPair(nodes[i].KeyPair)
+		syncs[i].SetStakes(bms[i])
+		if i > 0 {
+			syncs[i].Insert(syncs[0].MyCopy())
+		}
+		go pipelines.Synchronization(syncs[i], nodes[i].Sockets.Sync, nodes[i].Sockets.SyncSend)
+	}
+	if !waitForTableSize(syncs, len(nodes)) {
+		t.Fatalf("nodes failed to synchronize")
+	}
+	for i := range nodes {
+		applied := startVoting(bms[i], syncs[i], nodes[i])
+		go pipelines.Rotation(bms[i], syncs[i], nodes[i], books.NewMempool(bms[i], 1000), nil, applied)
+	}
+
+	// every node takes the producer role in its slots and the others sign its blocks
+	for i := range nodes {
+		for j := range bms {
+			last := waitForBlock(bms[j], keys[i], 2)
+			if last == nil {
+				t.Fatalf("%v should sign blocks of %v", nodes[j].Data.NodeName, nodes[i].Data.NodeName)
+			}
+			if last.Count == 0 {
+				t.Errorf("VDF should be iterated between blocks of the slot")
+			}
+		}
+	}
+	for i := 1; i < len(bms); i++ {
+		if !waitForSameBlock(bms[0], bms[i]) {
+			t.Errorf("%v should follow the chain of %v", nodes[i].Data.NodeName, nodes[0].Data.NodeName)
+		}
+	}
+}
@@ -110,7 +110,7 @@ func ProducerNodeForTest(producer replication.Node, bm *books.Accounts) {
 	sync, _ := replication.NewSync(producer.Data)
 	go pipelines.Messaging(bm, producer.Sockets.Messages, producer.Sockets.Respond)
 	go pipelines.Synchronization(sync, producer.Sockets.Sync, producer.Sockets.SyncSend)
-	go pipelines.BlockGeneration(bm, sync, producer.Sockets.Transaction, producer.Sockets.Replicate, nil, 2, nil)
+	go pipelines.BlockGeneration(bm, sync, producer.KeyPair, producer.Sockets.Transaction, producer.Sockets.Replicate, nil, 2, nil)
 	fmt.Printf("Producer Node: %v", producer.Data.Addresses)
 	fmt.Printf("Producer Node:\n Transaction: %v\n Messages: %v\n Replicate: %v\n Transport: %v\n",
 		producer.Sockets.Transaction.LocalAddr().String(),
@@ -129,7 +129,7 @@ func SignerNodeForTest(producer replication.Node, bm *books.Accounts) {
 	sync.Insert(producer.Data)
 	go pipelines.Messaging(bm, node.Sockets.Messages, node.Sockets.Respond)
 	go pipelines.Synchronization(sync, node.Sockets.Sync, node.Sockets.SyncSend)
-	go pipelines.BlockSigner(bm, sync, node.Sockets.Replicate, node.Sockets.Repair, node.Sockets.Transport, nil)
+	go pipelines.BlockSigner(bm, sync, node.Sockets.Replicate, node.Sockets.Repair, node.Sockets.Transport, nil, nil)
 	fmt.Printf("Signer Node: %v", node.Data.Addresses)
 	fmt.Printf("Producer Node:\n Transaction: %v\n Messages: %v\n Replicate: %v\n Transport: %v\n",
 		node.Sockets.Transaction.LocalAddr().String(),
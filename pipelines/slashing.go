@@ -0,0 +1,56 @@
+package pipelines
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/books"
+	"github.com/Ansiblock/Ansiblock/log"
+	"github.com/Ansiblock/Ansiblock/replication"
+	"golang.org/x/crypto/ed25519"
+)
+
+// evidencePollInterval is how often a node looks for new evidence of equivocation
+const evidencePollInterval = 100 * time.Millisecond
+
+// Slashing is run on the producer and signer nodes and is responsible for
+// punishment of equivocating signers. Conflicting votes found by finality are
+// recorded in sync, which gossips them. Every known evidence which is not
+// slashed yet is reported by slash transaction signed with the node's key.
+// Node with mempool keeps the transaction for its own blocks, other nodes
+// send it to the producer, again whenever the producer changes, so that an
+// offending producer can not censor it.
+func Slashing(bm *books.Accounts, sync *replication.Sync, keyPair block.KeyPair, mempool *books.Mempool, outputConn net.PacketConn) {
+	reported := make(map[string]ed25519.PublicKey)
+	for {
+		for _, evidence := range bm.TakeEvidence() {
+			sync.AddEvidence(evidence)
+		}
+		producer := sync.ProducerNodeData()
+		for _, evidence := range sync.Evidence() {
+			id := string(evidence.ID())
+			if bm.Slashed(&evidence) {
+				delete(reported, id)
+				continue
+			}
+			if to, ok := reported[id]; ok && (mempool != nil || producer == nil || bytes.Equal(to, producer.Self)) {
+				continue
+			}
+			tran := block.NewSlash(&keyPair, evidence, 0, bm.ValidVDFValue())
+			if mempool != nil {
+				mempool.Add([]block.Transaction{tran})
+				reported[id] = keyPair.Public
+			} else if producer != nil {
+				trans := block.Transactions{Ts: []block.Transaction{tran}}
+				packets := trans.ToPackets(&producer.Addresses.Transaction)
+				packets.WriteTo(outputConn)
+				reported[id] = producer.Self
+			}
+			log.Info(fmt.Sprintf("Slashing: reported equivocation of block %v", evidence.First.Number))
+		}
+		time.Sleep(evidencePollInterval)
+	}
+}
@@ -48,7 +48,7 @@ func TestTransactions(t *testing.T) {
 	producer := replication.NewNode("producer", "test1")
 	producer.Data.Producer = producer.Data.Self
 	syncL, _ := replication.NewSync(producer.Data)
-	go pipelines.BlockGeneration(am, syncL, transactionCon, nil, nil, 2, nil)
+	go pipelines.BlockGeneration(am, syncL, producer.KeyPair, transactionCon, nil, nil, 2, nil)
 	fmt.Println("Transaction run")
 
 	messagingCons := strings.Split(messagingCon.LocalAddr().String(), ":")
@@ -0,0 +1,49 @@
+package pipelines
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/books"
+	"github.com/Ansiblock/Ansiblock/log"
+	"github.com/Ansiblock/Ansiblock/messaging"
+	"github.com/Ansiblock/Ansiblock/replication"
+)
+
+// votingChannelCapacity is the number of applied blocks waiting for a vote
+const votingChannelCapacity = 10
+
+// announceBlock passes applied block to voting, the block is skipped when
+// voting is behind, since the vote for a later block covers it
+func announceBlock(applied chan<- block.Block, bl block.Block) {
+	if applied == nil {
+		return
+	}
+	select {
+	case applied <- bl:
+	default:
+	}
+}
+
+// Voting is run on the producer and signer nodes and is responsible for votes.
+// Hash of every applied block is signed with the node's key and the vote is sent to
+// messaging sockets of all other nodes, it is recorded by the node itself too.
+func Voting(bm *books.Accounts, sync *replication.Sync, keyPair block.KeyPair, applied <-chan block.Block, outputConn net.PacketConn) {
+	for bl := range applied {
+		vote := block.NewVote(&keyPair, bl.Number, bl.Hash())
+		if _, err := bm.AddVote(&vote); err != nil {
+			log.Error(fmt.Sprintf("own vote for block %v rejected: %v", bl.Number, err))
+		}
+		nodes := sync.AllNodesExceptMe(keyPair.Public)
+		if len(nodes) == 0 {
+			continue
+		}
+		requests := messaging.Requests{Requests: make([]messaging.Request, len(nodes))}
+		for i, node := range nodes {
+			addr := node.Addresses.Message
+			requests.Requests[i] = messaging.Request{Type: messaging.Vote, Addr: &addr, Vote: &vote}
+		}
+		requests.Serialize().WriteTo(outputConn)
+	}
+}
@@ -26,12 +26,24 @@ func chunkUpBlobs(blobs *network.Blobs, size int) [][]*network.Blob {
 	return res
 }
 
-// Broadcaster thread is responsible for broadcasting blocks from producer to signers
-func Broadcaster(sync *Sync, frame *network.Frame, outputConn net.PacketConn, input chan *network.Blobs) {
+// Broadcaster thread is responsible for broadcasting blocks from producer to signers.
+// Blob indexes continue from the start of the frame. Empty batch completes the
+// coding group of the broadcast blobs with empty blobs, so that signers recover
+// them without waiting for more blobs. The returned channel is closed after
+// the input is closed and all its blobs are broadcast.
+func Broadcaster(sync *Sync, frame *network.Frame, outputConn net.PacketConn, input chan *network.Blobs) <-chan struct{} {
+	done := make(chan struct{})
 	go func() {
-		index := uint64(0) // global blob index
+		defer close(done)
+		index := frame.Start() // global blob index
 		for {
-			blobs := <-input
+			blobs, ok := <-input
+			if !ok {
+				return
+			}
+			if len(blobs.Bs) == 0 {
+				blobs = network.GroupPadding(index)
+			}
 			nodes := sync.transitNodes()
 			if len(nodes) < 1 {
 				log.Info("No nodes to broadcast")
@@ -76,4 +88,5 @@ func Broadcaster(sync *Sync, frame *network.Frame, outputConn net.PacketConn, in
 			}
 		}
 	}()
+	return done
 }
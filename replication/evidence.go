@@ -0,0 +1,104 @@
+package replication
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/log"
+	"github.com/Ansiblock/Ansiblock/network"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ed25519"
+)
+
+// evidenceChannelCapacity is the number of new evidences waiting for gossip
+const evidenceChannelCapacity = 16
+
+// EvidenceMessage is a struct for gossiping evidence of equivocation
+type EvidenceMessage struct {
+	From     ed25519.PublicKey
+	Evidence []block.Evidence
+}
+
+// Serialize method is for turning EvidenceMessage into blobs
+func (msg *EvidenceMessage) Serialize() *network.Blob {
+	var b bytes.Buffer
+	enc := gob.NewEncoder(&b)
+	err := enc.Encode(msg)
+	if err != nil {
+		log.Error("encode error:", zap.Error(err))
+	}
+	blob := new(network.Blob)
+	blob.Size = uint32(b.Len() + 1)
+	copy(blob.Data[1:blob.Size], b.Bytes())
+	blob.Data[0] = evidenceType
+	return blob
+}
+
+// Deserialize method is for turning Blobs into EvidenceMessage
+func (msg *EvidenceMessage) Deserialize(blob *network.Blob) {
+	b := bytes.NewBuffer(blob.Data[1:blob.Size])
+	dec := gob.NewDecoder(b)
+	err := dec.Decode(msg)
+	if err != nil {
+		log.Error("decode error:", zap.Error(err))
+	}
+}
+
+// AddEvidence verifies and records evidence of equivocation, it returns true
+// if the offence was not known. New evidence is gossiped, see EvidenceGossip.
+func (c *Sync) AddEvidence(evidence block.Evidence) bool {
+	if !evidence.Verify() {
+		return false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	id := string(evidence.ID())
+	if _, ok := c.evidence[id]; ok {
+		return false
+	}
+	c.evidence[id] = evidence
+	log.Warn(fmt.Sprintf("Evidence of equivocation at block %v", evidence.First.Number))
+	select {
+	case c.newEvidence <- evidence:
+	default:
+		log.Error("Evidence gossip is full, evidence is not gossiped")
+	}
+	return true
+}
+
+// Evidence returns all known evidence of equivocation
+func (c *Sync) Evidence() []block.Evidence {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	res := make([]block.Evidence, 0, len(c.evidence))
+	for _, evidence := range c.evidence {
+		res = append(res, evidence)
+	}
+	return res
+}
+
+// EvidenceGossip thread sends every new evidence to sync sockets of all other
+// nodes, nodes which learn the evidence from the message gossip it further
+func EvidenceGossip(sync *Sync) <-chan *network.Blobs {
+	out := make(chan *network.Blobs, evidenceChannelCapacity)
+	go func(out chan<- *network.Blobs) {
+		for evidence := range sync.newEvidence {
+			nodes := sync.AllNodesExceptMe(sync.me)
+			if len(nodes) == 0 {
+				continue
+			}
+			msg := EvidenceMessage{From: sync.me, Evidence: []block.Evidence{evidence}}
+			blob := msg.Serialize()
+			blobs := &network.Blobs{Bs: make([]network.Blob, len(nodes))}
+			for i, node := range nodes {
+				addr := node.Addresses.Sync
+				blobs.Bs[i] = *blob
+				blobs.Bs[i].Addr = &addr
+			}
+			out <- blobs
+		}
+	}(out)
+	return out
+}
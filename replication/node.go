@@ -19,10 +19,11 @@ type Sockets struct {
 	Transport   net.PacketConn
 }
 
-// Node saves the data of a user node
+// Node saves the data of a user node, KeyPair signs votes of the node
 type Node struct {
 	Data    *NodeData
 	Sockets Sockets
+	KeyPair block.KeyPair
 }
 
 // NewNode creates new Node
@@ -37,11 +38,11 @@ func NewNode(nodeType string, name string) Node {
 	repair, _ := net.ListenPacket("udp", "127.0.0.1:0")
 	transport, _ := net.ListenPacket("udp", "127.0.0.1:0")
 
-	pubKey := block.NewKeyPair().Public
+	keyPair := block.NewKeyPair()
 
-	data := NewNodeData(pubKey, nodeType, name, *sync.LocalAddr().(*net.UDPAddr), *replicate.LocalAddr().(*net.UDPAddr), *messages.LocalAddr().(*net.UDPAddr), *transaction.LocalAddr().(*net.UDPAddr), *repair.LocalAddr().(*net.UDPAddr))
+	data := NewNodeData(keyPair.Public, nodeType, name, *sync.LocalAddr().(*net.UDPAddr), *replicate.LocalAddr().(*net.UDPAddr), *messages.LocalAddr().(*net.UDPAddr), *transaction.LocalAddr().(*net.UDPAddr), *repair.LocalAddr().(*net.UDPAddr))
 
-	return Node{Data: data, Sockets: Sockets{sync, syncSend, messages, replicate, transaction, respond, broadcast, repair, transport}}
+	return Node{Data: data, Sockets: Sockets{sync, syncSend, messages, replicate, transaction, respond, broadcast, repair, transport}, KeyPair: keyPair}
 }
 
 func NewProducerNode(nodeType string, name string) Node {
@@ -55,11 +56,11 @@ func NewProducerNode(nodeType string, name string) Node {
 	repair, _ := net.ListenPacket("udp", "127.0.0.1:0")
 	transport, _ := net.ListenPacket("udp", "127.0.0.1:0")
 
-	pubKey := block.NewKeyPair().Public
+	keyPair := block.NewKeyPair()
 
-	data := NewNodeData(pubKey, nodeType, name, *sync.LocalAddr().(*net.UDPAddr), *replicate.LocalAddr().(*net.UDPAddr), *messages.LocalAddr().(*net.UDPAddr), *transaction.LocalAddr().(*net.UDPAddr), *repair.LocalAddr().(*net.UDPAddr))
+	data := NewNodeData(keyPair.Public, nodeType, name, *sync.LocalAddr().(*net.UDPAddr), *replicate.LocalAddr().(*net.UDPAddr), *messages.LocalAddr().(*net.UDPAddr), *transaction.LocalAddr().(*net.UDPAddr), *repair.LocalAddr().(*net.UDPAddr))
 
-	return Node{Data: data, Sockets: Sockets{sync, syncSend, messages, replicate, transaction, respond, broadcast, repair, transport}}
+	return Node{Data: data, Sockets: Sockets{sync, syncSend, messages, replicate, transaction, respond, broadcast, repair, transport}, KeyPair: keyPair}
 }
 
 // DestroyNode closes all packet connections of the node
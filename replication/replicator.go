@@ -10,6 +10,7 @@ import (
 	"github.com/Ansiblock/Ansiblock/log"
 	"github.com/Ansiblock/Ansiblock/network"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/ed25519"
 )
 
 // ReplicateRequests replicates blocks for clients
@@ -31,8 +32,49 @@ import (
 
 // }
 
-// New runs goroutine which in infinite loop replicates blocks
-func New(bm *books.Accounts, blobsReceiver <-chan *network.Blobs) {
+// blockProducer returns leader of the block height when accounts have a leader
+// schedule, otherwise the producer of sync
+func blockProducer(bm *books.Accounts, sync *Sync, height uint64) ed25519.PublicKey {
+	if leader := bm.Leader(height); leader != nil {
+		return leader
+	}
+	if producer := sync.ProducerNodeData(); producer != nil {
+		return producer.Self
+	}
+	return nil
+}
+
+// signedBlocks returns blocks preceding the first block which is not signed by
+// its producer, see blockProducer. Signed block which conflicts with the last
+// applied block of the same producer is recorded in sync as evidence of equivocation.
+func signedBlocks(bm *books.Accounts, sync *Sync, blocks []block.Block) []block.Block {
+	for i := range blocks {
+		if producer := blockProducer(bm, sync, blocks[i].Number); producer == nil || !blocks[i].VerifyHeader(producer) {
+			log.Error("Replicated block is not signed by the producer, blobs dropped", zap.Uint64("Height", blocks[i].Number))
+			return blocks[:i]
+		}
+		last := bm.LastBlock()
+		if last != nil && last.Number == blocks[i].Number && bytes.Equal(last.Producer, blocks[i].Producer) &&
+			!bytes.Equal(last.Hash(), blocks[i].Hash()) {
+			sync.AddEvidence(block.NewEvidence(last.Vote(), blocks[i].Vote()))
+		}
+	}
+	return blocks
+}
+
+// Store keeps replicated blocks
+type Store interface {
+	// DeleteBlocksAfterHeight deletes stored blocks above the height
+	DeleteBlocksAfterHeight(height uint64) error
+}
+
+// New runs goroutine which in infinite loop replicates blocks. Blocks which
+// are not signed by the producer of sync are dropped. When a block diverges
+// accounts are restored to the state before the batch and stored blocks above
+// the last processed block are deleted from store, if it is not nil. The last
+// block of every successfully processed batch is passed to applied, if it is
+// not nil and not full, so that the node can vote for it.
+func New(bm *books.Accounts, sync *Sync, blobsReceiver <-chan *network.Blobs, store Store, applied chan<- block.Block) {
 	go func(bm *books.Accounts, blobsReceiver <-chan *network.Blobs) {
 		for {
 			blobs, ok := <-blobsReceiver
@@ -45,17 +87,40 @@ func New(bm *books.Accounts, blobsReceiver <-chan *network.Blobs) {
 				fmt.Printf("%v ", blobs.Bs[i].Index())
 			}
 			fmt.Println("]")
-			blocks := block.BlobsToBlocks(blobs)
+			blocks := signedBlocks(bm, sync, block.BlobsToBlocks(blobs))
 			fmt.Printf("Replicate block books : [")
 			for _, bl := range blocks {
 				fmt.Printf("%v ", bl.Number)
 			}
 			fmt.Println("]")
 			err := bm.ProcessBlocks(blocks)
+			if blockErr, ok := err.(*books.BlockError); ok {
+				log.Error("Replicated block diverges from the replayed chain, blobs dropped",
+					zap.Uint64("Height", blockErr.Number), zap.Int("Transaction", blockErr.Transaction), zap.Error(err))
+				if store == nil {
+					continue
+				}
+				// blocks of the batch may already be stored
+				height := uint64(0)
+				if last := bm.LastBlock(); last != nil {
+					height = last.Number
+				}
+				if err = store.DeleteBlocksAfterHeight(height); err != nil {
+					log.Error("Rollback of stored blocks failed, replication stopped", zap.Error(err))
+					break
+				}
+				continue
+			}
 			if err != nil {
 				log.Error("Process blocks failed! ", zap.Int("blobs num", len(blobs.Bs)), zap.Error(err))
 				break
 			}
+			if applied != nil && len(blocks) > 0 {
+				select {
+				case applied <- blocks[len(blocks)-1]:
+				default:
+				}
+			}
 		}
 	}(bm, blobsReceiver)
 }
@@ -67,8 +132,10 @@ func Transporter(sync *Sync, input <-chan *network.Blobs, outputConn net.PacketC
 			blobs := <-input
 			log.Debug(fmt.Sprintf("Transport %v blobs", len(blobs.Bs)))
 			fmt.Printf("Transport %v blobs\n", len(blobs.Bs))
+			producer := sync.ProducerNodeData()
 			for _, blob := range blobs.Bs {
-				if bytes.Equal(blob.From(), sync.ProducerNodeData().Self) {
+				// producer may be announced before its node data is synchronized
+				if producer != nil && bytes.Equal(blob.From(), producer.Self) {
 					// log.Debug(fmt.Sprintf("Blob %v sent from producer to all nodes", blob.Index()))
 					// fmt.Printf("Blob %v sent from producer to all nodes\n", blob.Index())
 					transport(sync, &blob, outputConn)
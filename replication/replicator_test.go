@@ -28,19 +28,36 @@ import (
 // 	}
 // }
 
+// producerSync returns sync of a signer which follows the producer node
+func producerSync(producer *Node) *Sync {
+	producer.Data.Producer = producer.Data.Self
+	syncL, _ := NewSync(producer.Data)
+	return syncL
+}
+
+// sealBlocks links blocks to the last block and signs them by the producer
+func sealBlocks(producer *Node, last *block.Block, blocks []block.Block) {
+	for i := range blocks {
+		blocks[i].Seal(&producer.KeyPair, last)
+		last = &blocks[i]
+	}
+}
+
 func TestReplicatorNew(t *testing.T) {
 	rand.Seed(0)
 	bm, keyPairs := books.RandomAccounts(100)
 
 	blocks := books.RandomTransactionsBlocks(bm, 100, 10, keyPairs)
 	bmClone := bm.Clone()
+	producer := NewNode("producer", "test")
+	sealBlocks(&producer, bm.LastBlock(), blocks)
 	blobs := block.BlocksToBlobs(blocks)
 	blobsReceiver := make(chan *network.Blobs, 1)
 	blobsReceiver <- blobs
 
 	bm.ProcessBlocks(blocks)
 	// var exit uint64
-	New(bmClone, blobsReceiver)
+	New(bmClone, producerSync(&producer), blobsReceiver, nil, nil)
 
 	//wait while replicator thread is finished
 	time.Sleep(2 * time.Second)
@@ -51,6 +68,62 @@ func TestReplicatorNew(t *testing.T) {
 	}
 }
 
+// storeMock records heights of deleted blocks
+type storeMock chan uint64
+
+func (s storeMock) DeleteBlocksAfterHeight(height uint64) error {
+	s <- height
+	return nil
+}
+
+func TestReplicatorDivergence(t *testing.T) {
+	bm, keyPairs := books.RandomAccounts(10)
+	blocks := books.RandomTransactionsBlocks(bm, 10, 3, keyPairs)
+	bm.SetStrictVerification(true)
+	expected := bm.Clone()
+	producer := NewNode("producer", "test")
+	sealBlocks(&producer, bm.LastBlock(), blocks)
+	blocks[2].Seal(&producer.KeyPair, nil)
+	blobsReceiver := make(chan *network.Blobs, 1)
+	blobsReceiver <- block.BlocksToBlobs(blocks)
+	store := make(storeMock, 1)
+
+	New(bm, producerSync(&producer), blobsReceiver, store, nil)
+	select {
+	case height := <-store:
+		if height != 0 || !bm.Equals(expected) {
+			t.Errorf("blocks above the last processed block should be deleted, got %v", height)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("stored blocks of divergent batch should be deleted")
+	}
+}
+
+func TestSignedBlocks(t *testing.T) {
+	bm, keyPairs := books.RandomAccounts(10)
+	blocks := books.RandomTransactionsBlocks(bm, 10, 3, keyPairs)
+	producer := NewNode("producer", "test")
+	other := NewNode("producer", "other")
+	syncL := producerSync(&producer)
+	sealBlocks(&producer, bm.LastBlock(), blocks)
+	blocks[1].Seal(&other.KeyPair, &blocks[0])
+	if res := signedBlocks(bm, syncL, blocks); len(res) != 1 {
+		t.Errorf("blocks from the first block not signed by the producer should be dropped, got %v", len(res))
+	}
+
+	bm.ProcessBlocks(blocks[:1])
+	conflict := blocks[0]
+	conflict.Transactions = &block.Transactions{}
+	conflict.Seal(&producer.KeyPair, nil)
+	if res := signedBlocks(bm, syncL, []block.Block{conflict}); len(res) != 1 {
+		t.Errorf("signed block should be accepted")
+	}
+	evidence := syncL.Evidence()
+	if len(evidence) != 1 || !bytes.Equal(evidence[0].Offender(), producer.KeyPair.Public) {
+		t.Errorf("conflicting signed blocks should be evidence of equivocation, got %v", evidence)
+	}
+}
+
 func TestTransport(t *testing.T) {
 	blob := network.NewBlobs().Bs[0]
 	blob.Data[0] = 1
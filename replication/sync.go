@@ -2,10 +2,12 @@ package replication
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/rand"
 	"net"
+	"sort"
 	synchro "sync"
 
 	"github.com/Ansiblock/Ansiblock/block"
@@ -24,15 +26,46 @@ type Addresses struct {
 	Repair      net.UDPAddr
 }
 
-// NodeData represents structure for replication through sync
+// NodeData represents structure for replication through sync.
+// ProducerSignature is signature of Self over Version and Producer, see SignedProducer.
 type NodeData struct {
-	Self          ed25519.PublicKey
-	Version       uint64
-	Addresses     Addresses
-	Producer      ed25519.PublicKey
-	ValidVDFValue block.VDFValue
-	NodeType      string
-	NodeName      string
+	Self              ed25519.PublicKey
+	Version           uint64
+	Addresses         Addresses
+	Producer          ed25519.PublicKey
+	ProducerSignature []byte
+	ValidVDFValue     block.VDFValue
+	NodeType          string
+	NodeName          string
+}
+
+// producerDomain separates producer announcements from other data signed by nodes
+const producerDomain = "producer"
+
+// producerData returns data signed by the node which announces its producer
+func producerData(version uint64, producer ed25519.PublicKey) []byte {
+	data := make([]byte, len(producerDomain)+8, len(producerDomain)+8+len(producer))
+	copy(data, producerDomain)
+	binary.BigEndian.PutUint64(data[len(producerDomain):], version)
+	return append(data, producer...)
+}
+
+// SignedProducer returns true if the producer was announced by the node itself.
+// Gossip is not authenticated, so producers of other nodes are trusted only
+// when they are signed together with the version of the node data.
+func (n *NodeData) SignedProducer() bool {
+	return len(n.Self) == ed25519.PublicKeySize &&
+		ed25519.Verify(n.Self, producerData(n.Version, n.Producer), n.ProducerSignature)
+}
+
+// Stakes provides stakes of validators and nodes they stand for, see books.Accounts.
+// Validators bind their nodes by signed transactions, so nodes can not claim stake.
+type Stakes interface {
+	ValidatorStake(validator ed25519.PublicKey) int64
+	TotalStake() int64
+	ValidatorOf(node ed25519.PublicKey) ed25519.PublicKey
+	NodeOf(validator ed25519.PublicKey) ed25519.PublicKey
+	StakedNodes() []ed25519.PublicKey
 }
 
 // Sync struct is responsible for replication
@@ -43,6 +76,10 @@ type Sync struct {
 	index          uint64
 	me             ed25519.PublicKey
 	mutex          *synchro.RWMutex
+	stakes         Stakes
+	keyPair        *block.KeyPair
+	evidence       map[string]block.Evidence
+	newEvidence    chan block.Evidence
 }
 
 // NewNodeData returns new NodeIno struct
@@ -77,7 +114,9 @@ func NewSync(me *NodeData) (*Sync, error) {
 		localVersions:  make(map[string]uint64),
 		remoteVersions: make(map[string]uint64),
 		index:          1,
-		me:             me.Self}
+		me:             me.Self,
+		evidence:       make(map[string]block.Evidence),
+		newEvidence:    make(chan block.Evidence, evidenceChannelCapacity)}
 	sync.localVersions[string(me.Self)] = sync.index
 	sync.table[string(me.Self)] = me
 	sync.mutex = &synchro.RWMutex{}
@@ -158,15 +197,32 @@ func (c *Sync) ProducerNodeData() *NodeData {
 	return c.table[string(c.MyNodeData().Producer)]
 }
 
-// ChangeProducer updates producer with new one
+// ChangeProducer updates producer with new one, the producer is signed when
+// key pair of the node is set
 func (c *Sync) ChangeProducer(key ed25519.PublicKey) {
 	my := c.MyNodeData().Copy()
 	log.Info(fmt.Sprintf("Update Producer: {%v} -> {%v}", bytesToString(my.Producer), bytesToString(key)))
 	my.Producer = key
 	my.Version++
+	my.ProducerSignature = nil
+	c.mutex.RLock()
+	keyPair := c.keyPair
+	c.mutex.RUnlock()
+	if keyPair != nil {
+		my.ProducerSignature = ed25519.Sign(keyPair.Private, producerData(my.Version, key))
+	}
 	c.Insert(my)
 }
 
+// SetKeyPair sets key pair of the node, producer of the node is signed with it
+// from now on, see SignedProducer
+func (c *Sync) SetKeyPair(keyPair block.KeyPair) {
+	c.mutex.Lock()
+	c.keyPair = &keyPair
+	c.mutex.Unlock()
+	c.ChangeProducer(c.MyNodeData().Producer)
+}
+
 // Insert method will insert new NodeData into the table
 func (c *Sync) Insert(info *NodeData) {
 	c.mutex.Lock()
@@ -280,15 +336,161 @@ func (c *Sync) AllNodesExceptMe(me ed25519.PublicKey) []*NodeData {
 	return res
 }
 
+// transitNodes returns nodes which pass blobs of the producer on. Producer
+// nodes sign blocks only after they were replaced and follow another node.
 func (c *Sync) transitNodes() []*NodeData {
 	me := c.MyCopy()
 	table := c.TableCopy()
 	nodes := make([]*NodeData, 0)
 	for _, v := range table {
-		if !bytes.Equal(me.Self, v.Self) && !bytes.Equal(me.Producer, v.Self) &&
+		producing := v.NodeType == "producer" && bytes.Equal(v.Producer, v.Self)
+		if !bytes.Equal(me.Self, v.Self) && !bytes.Equal(me.Producer, v.Self) && !producing &&
 			v.Addresses.Replication.String() != "0.0.0.0:0" {
 			nodes = append(nodes, v)
 		}
 	}
 	return nodes
 }
+
+// Successor returns node which takes over block production after producer went
+// silent, attempt counts consecutive failovers starting from 1. The successor
+// is the attempt-th candidate after the silent producer in order of public
+// keys. Candidates are nodes of staked validators recorded on chain, so every
+// signer picks the same successor, or producer and signer nodes of the table
+// when nobody has stake. Nil is returned when there are no candidates.
+func (c *Sync) Successor(producer ed25519.PublicKey, attempt int) *NodeData {
+	candidates := c.candidates(producer)
+	if len(candidates) == 0 || attempt < 1 {
+		return nil
+	}
+	next := sort.Search(len(candidates), func(i int) bool {
+		return bytes.Compare(candidates[i], producer) > 0
+	})
+	key := candidates[(next+attempt-1)%len(candidates)]
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if v, ok := c.table[string(key)]; ok {
+		return v.Copy()
+	}
+	return &NodeData{Self: key}
+}
+
+// candidates returns sorted keys of nodes which may succeed the producer
+func (c *Sync) candidates(producer ed25519.PublicKey) []ed25519.PublicKey {
+	res := make([]ed25519.PublicKey, 0)
+	if stakes := c.staked(); stakes != nil {
+		for _, node := range stakes.StakedNodes() {
+			if !bytes.Equal(node, producer) {
+				res = append(res, node)
+			}
+		}
+		return res
+	}
+	for _, v := range c.TableCopy() {
+		if (v.NodeType == "producer" || v.NodeType == "signer") && !bytes.Equal(v.Self, producer) {
+			res = append(res, v.Self)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return bytes.Compare(res[i], res[j]) < 0
+	})
+	return res
+}
+
+// MajorityProducer returns node which announced itself as producer and is
+// followed by more than half of the stake, nil if there is no such node. Only
+// signed producers are counted, see SignedProducer. When nobody has stake
+// there is no majority producer, as anybody can add nodes to the table.
+func (c *Sync) MajorityProducer() *NodeData {
+	if c.staked() == nil {
+		return nil
+	}
+	total := c.TotalPower()
+	if total <= 0 {
+		return nil
+	}
+	table := c.TableCopy()
+	power := make(map[string]int64)
+	for _, v := range table {
+		if (v.NodeType == "producer" || v.NodeType == "signer") && v.SignedProducer() {
+			power[string(v.Producer)] += c.Power(v.Self)
+		}
+	}
+	for key, p := range power {
+		if v, ok := table[key]; ok && bytes.Equal(v.Producer, v.Self) && v.SignedProducer() && 2*p > total {
+			return v
+		}
+	}
+	return nil
+}
+
+// SetStakes weights voting power of nodes by stake of their staking identities
+func (c *Sync) SetStakes(stakes Stakes) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.stakes = stakes
+}
+
+// staked returns stakes when some validator has stake, nil otherwise
+func (c *Sync) staked() Stakes {
+	c.mutex.RLock()
+	stakes := c.stakes
+	c.mutex.RUnlock()
+	if stakes == nil || stakes.TotalStake() <= 0 {
+		return nil
+	}
+	return stakes
+}
+
+// NodeOfValidator returns producer or signer node the validator stands for,
+// it is the node bound by the validator or the validator itself
+func (c *Sync) NodeOfValidator(validator ed25519.PublicKey) *NodeData {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	node := validator
+	if c.stakes != nil {
+		node = c.stakes.NodeOf(validator)
+	}
+	if v, ok := c.table[string(node)]; ok && (v.NodeType == "producer" || v.NodeType == "signer") {
+		return v.Copy()
+	}
+	return nil
+}
+
+// Power returns voting power of the voter. It is the stake of the validator
+// the voter stands for, when nobody has stake every producer and signer node
+// of the table has equal power.
+func (c *Sync) Power(voter ed25519.PublicKey) int64 {
+	stakes := c.staked()
+	c.mutex.RLock()
+	v, ok := c.table[string(voter)]
+	c.mutex.RUnlock()
+	if !ok || (v.NodeType != "producer" && v.NodeType != "signer") {
+		return 0
+	}
+	if stakes != nil {
+		validator := stakes.ValidatorOf(voter)
+		if validator == nil {
+			return 0
+		}
+		return stakes.ValidatorStake(validator)
+	}
+	return 1
+}
+
+// TotalPower returns voting power of all validators, it is the number of
+// producer and signer nodes of the table when nobody has stake
+func (c *Sync) TotalPower() int64 {
+	if stakes := c.staked(); stakes != nil {
+		return stakes.TotalStake()
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	total := int64(0)
+	for _, v := range c.table {
+		if v.NodeType == "producer" || v.NodeType == "signer" {
+			total++
+		}
+	}
+	return total
+}
@@ -53,6 +53,12 @@ func handleBlob(sync *Sync, blob *network.Blob) *network.Blob {
 		rec.Deserialize(blob)
 		// fmt.Printf("updates: %v\n", string(rec.From[:8]))
 		sync.update(rec)
+	} else if blob.Data[0] == evidenceType {
+		msg := new(EvidenceMessage)
+		msg.Deserialize(blob)
+		for _, evidence := range msg.Evidence {
+			sync.AddEvidence(evidence)
+		}
 	}
 	return nil
 }
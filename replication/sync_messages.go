@@ -14,6 +14,7 @@ import (
 const (
 	getUpdatesType byte = iota
 	updatesType
+	evidenceType
 )
 
 // GetUpdates is struct for requesting updates for Sync
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/Ansiblock/Ansiblock/block"
 	"github.com/Ansiblock/Ansiblock/network"
+	"golang.org/x/crypto/ed25519"
 )
 
 func TestNewNodeData(t *testing.T) {
@@ -187,3 +189,217 @@ func TestAllNodesExceptMe(t *testing.T) {
 func TestRemoteTableCopy(t *testing.T) {
 
 }
+
+func TestSuccessor(t *testing.T) {
+	keys := [][]byte{[]byte("key-1"), []byte("key-2"), []byte("key-3"), []byte("key-4")}
+	node := NewNodeData(keys[2], "signer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP)
+	sync, _ := NewSync(node)
+	for _, i := range []int{3, 0, 1} {
+		nodeType := "signer"
+		if i == 1 {
+			nodeType = "producer"
+		}
+		sync.Insert(NewNodeData(keys[i], nodeType, "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP))
+	}
+	sync.Insert(NewNodeData([]byte("key-0"), "server", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP))
+
+	expected := [][]byte{keys[2], keys[3], keys[0], keys[2]}
+	for attempt, key := range expected {
+		if successor := sync.Successor(keys[1], attempt+1); !bytes.Equal(successor.Self, key) {
+			t.Errorf("attempt %v: expected successor %s, got %s", attempt+1, key, successor.Self)
+		}
+	}
+	if successor := sync.Successor(keys[3], 1); !bytes.Equal(successor.Self, keys[0]) {
+		t.Errorf("successor of the last node should wrap around, got %s", successor.Self)
+	}
+	if sync.Successor(keys[1], 0) != nil {
+		t.Errorf("attempt should start from 1")
+	}
+}
+
+func TestPower(t *testing.T) {
+	node := NewNodeData([]byte("key-1"), "producer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP)
+	sync, _ := NewSync(node)
+	sync.Insert(NewNodeData([]byte("key-2"), "signer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP))
+	sync.Insert(NewNodeData([]byte("key-3"), "server", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP))
+	if sync.TotalPower() != 2 || sync.Power([]byte("key-2")) != 1 || sync.Power([]byte("key-3")) != 0 || sync.Power([]byte("key-4")) != 0 {
+		t.Errorf("producer and signer nodes should have equal voting power")
+	}
+}
+
+// testStakes holds stakes of validators and nodes bound by validators
+type testStakes struct {
+	stakes   map[string]int64
+	bindings map[string]string
+}
+
+func (s testStakes) ValidatorStake(validator ed25519.PublicKey) int64 {
+	return s.stakes[string(validator)]
+}
+
+func (s testStakes) TotalStake() int64 {
+	total := int64(0)
+	for _, stake := range s.stakes {
+		total += stake
+	}
+	return total
+}
+
+func (s testStakes) ValidatorOf(node ed25519.PublicKey) ed25519.PublicKey {
+	for validator, bound := range s.bindings {
+		if bound == string(node) {
+			return ed25519.PublicKey(validator)
+		}
+	}
+	if _, ok := s.bindings[string(node)]; ok {
+		return nil
+	}
+	return node
+}
+
+func (s testStakes) NodeOf(validator ed25519.PublicKey) ed25519.PublicKey {
+	if node, ok := s.bindings[string(validator)]; ok {
+		return ed25519.PublicKey(node)
+	}
+	return validator
+}
+
+func (s testStakes) StakedNodes() []ed25519.PublicKey {
+	res := make([]ed25519.PublicKey, 0, len(s.stakes))
+	for validator, stake := range s.stakes {
+		if stake > 0 {
+			res = append(res, s.NodeOf(ed25519.PublicKey(validator)))
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return bytes.Compare(res[i], res[j]) < 0
+	})
+	return res
+}
+
+func TestPowerStake(t *testing.T) {
+	node := NewNodeData([]byte("key-1"), "producer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP)
+	sync, _ := NewSync(node)
+	sync.Insert(NewNodeData([]byte("key-2"), "signer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP))
+	stakes := testStakes{stakes: map[string]int64{}, bindings: map[string]string{}}
+	sync.SetStakes(stakes)
+	if sync.TotalPower() != 2 || sync.Power([]byte("key-1")) != 1 {
+		t.Errorf("nodes should have equal voting power when nobody has stake")
+	}
+	stakes.stakes["key-1"] = 30
+	stakes.stakes["validator"] = 60
+	stakes.stakes["offline"] = 10
+	stakes.bindings["validator"] = "key-2"
+	if sync.TotalPower() != 100 || sync.Power([]byte("key-1")) != 30 || sync.Power([]byte("key-2")) != 60 {
+		t.Errorf("voting power should be the stake of the validator, got %v of %v", sync.Power([]byte("key-2")), sync.TotalPower())
+	}
+	if n := sync.NodeOfValidator([]byte("validator")); n == nil || !bytes.Equal(n.Self, []byte("key-2")) {
+		t.Errorf("node should be found by its validator")
+	}
+	if sync.NodeOfValidator([]byte("offline")) != nil || !bytes.Equal(sync.NodeOfValidator([]byte("key-1")).Self, []byte("key-1")) {
+		t.Errorf("node without binding should stand for itself")
+	}
+
+	// validator node which bound another node has no power of its own
+	sync.Insert(NewNodeData([]byte("validator"), "signer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP))
+	if sync.Power([]byte("validator")) != 0 || sync.Power([]byte("key-2")) != 60 {
+		t.Errorf("validator should stand for a single node")
+	}
+}
+
+func TestSuccessorStake(t *testing.T) {
+	node := NewNodeData([]byte("key-1"), "producer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP)
+	sync, _ := NewSync(node)
+	sync.Insert(NewNodeData([]byte("key-2"), "signer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP))
+	sync.Insert(NewNodeData([]byte("key-3"), "signer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP))
+	sync.SetStakes(testStakes{
+		stakes:   map[string]int64{"key-1": 10, "validator": 10, "key-5": 10},
+		bindings: map[string]string{"validator": "key-3"},
+	})
+
+	// key-2 has no stake and key-5 is not known yet, still it is a candidate
+	expected := [][]byte{[]byte("key-3"), []byte("key-5"), []byte("key-3")}
+	for attempt, key := range expected {
+		if successor := sync.Successor([]byte("key-1"), attempt+1); !bytes.Equal(successor.Self, key) {
+			t.Errorf("attempt %v: expected successor %s, got %s", attempt+1, key, successor.Self)
+		}
+	}
+	if successor := sync.Successor([]byte("key-1"), 1); successor.NodeName != "test" {
+		t.Errorf("known successor should be returned with its node data")
+	}
+}
+
+func TestMajorityProducer(t *testing.T) {
+	pairs := []block.KeyPair{block.NewKeyPair(), block.NewKeyPair(), block.NewKeyPair()}
+	keys := make([][]byte, len(pairs))
+	stakes := testStakes{stakes: map[string]int64{}, bindings: map[string]string{}}
+	for i, kp := range pairs {
+		keys[i] = kp.Public
+		stakes.stakes[string(kp.Public)] = 10
+	}
+	sync, _ := NewSync(NewNodeData(keys[0], "producer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP))
+	syncs := make([]*Sync, len(keys))
+	for i, key := range keys {
+		node := NewNodeData(key, "signer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP)
+		node.Producer = keys[0]
+		syncs[i], _ = NewSync(node)
+		syncs[i].SetKeyPair(pairs[i])
+		if i > 0 {
+			sync.Insert(syncs[i].MyCopy())
+		}
+	}
+	sync.SetKeyPair(pairs[0])
+	sync.ChangeProducer(keys[0])
+	if p := sync.MajorityProducer(); p != nil {
+		t.Fatalf("there should be no majority producer without stake")
+	}
+	sync.SetStakes(stakes)
+	if p := sync.MajorityProducer(); p == nil || !bytes.Equal(p.Self, keys[0]) {
+		t.Fatalf("followed producer should be the majority producer")
+	}
+
+	syncs[1].ChangeProducer(keys[1])
+	sync.Insert(syncs[1].MyCopy())
+	if p := sync.MajorityProducer(); p == nil || !bytes.Equal(p.Self, keys[0]) {
+		t.Errorf("producer followed by one node should not be the majority producer")
+	}
+	forged := syncs[2].MyCopy()
+	forged.Producer = keys[1]
+	forged.Version++
+	sync.Insert(forged)
+	if p := sync.MajorityProducer(); p != nil {
+		t.Errorf("unsigned producer should not be counted, got %v", p.Self)
+	}
+	// the forged entry took the next version, the node announces past it
+	syncs[2].ChangeProducer(keys[1])
+	syncs[2].ChangeProducer(keys[1])
+	sync.Insert(syncs[2].MyCopy())
+	if p := sync.MajorityProducer(); p == nil || !bytes.Equal(p.Self, keys[1]) {
+		t.Errorf("successor followed by the majority should be the majority producer")
+	}
+}
+
+func TestEvidenceGossip(t *testing.T) {
+	node := NewNodeData([]byte("key-1"), "signer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP)
+	sync, _ := NewSync(node)
+	sync.Insert(NewNodeData([]byte("key-2"), "signer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP))
+	kp := block.NewKeyPair()
+	evidence := block.NewEvidence(block.NewVote(&kp, 5, block.VDF([]byte{1})), block.NewVote(&kp, 5, block.VDF([]byte{2})))
+	if sync.AddEvidence(block.NewEvidence(evidence.First, evidence.First)) {
+		t.Errorf("invalid evidence should not be added")
+	}
+	gossip := EvidenceGossip(sync)
+	if !sync.AddEvidence(evidence) || sync.AddEvidence(evidence) || len(sync.Evidence()) != 1 {
+		t.Errorf("evidence should be added once")
+	}
+	blobs := <-gossip
+	if len(blobs.Bs) != 1 || blobs.Bs[0].Data[0] != evidenceType {
+		t.Fatalf("new evidence should be gossiped to other nodes")
+	}
+
+	other, _ := NewSync(NewNodeData([]byte("key-2"), "signer", "test", network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP, network.BlockAddrUserUDP))
+	handleBlob(other, &blobs.Bs[0])
+	if res := other.Evidence(); len(res) != 1 || string(res[0].ID()) != string(evidence.ID()) {
+		t.Errorf("gossiped evidence should be received, got %v", res)
+	}
+}
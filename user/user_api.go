@@ -8,8 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/books"
 	"github.com/Ansiblock/Ansiblock/log"
 	"github.com/Ansiblock/Ansiblock/messaging"
 	"github.com/Ansiblock/Ansiblock/network"
@@ -18,6 +20,9 @@ import (
 	"golang.org/x/crypto/ed25519"
 )
 
+// statusPollInterval is the interval between transaction status requests
+const statusPollInterval = 100 * time.Millisecond
+
 // API object is for querying and sending transactions to the network.
 type API struct {
 	messagingAddr      net.Addr
@@ -40,11 +45,21 @@ func NewUserAPI(ra net.Addr, ta net.Addr, rs net.PacketConn, ts net.PacketConn)
 // until the server sends a response. If the response packet is dropped
 // by the network, this method will hang indefinitely.
 func (tc *API) Balance(publicKey ed25519.PublicKey) (int64, error) {
+	return tc.AssetBalance(publicKey, nil)
+}
+
+// AssetBalance method requests balance of user holding 'publicKey' in the user
+// issued asset, empty asset means native token. It blocks the same way as Balance.
+func (tc *API) AssetBalance(publicKey ed25519.PublicKey, asset []byte) (int64, error) {
 	log.Info("Balance")
 	if len(publicKey) != ed25519.PublicKeySize {
 		return 0, errors.New("public key not found")
 	}
-	requestsArr := []messaging.Request{messaging.Request{Type: messaging.Balance, Addr: tc.messagingAddr, PublicKey: publicKey}}
+	if len(asset) != 0 && len(asset) != block.AssetIDSize {
+		return 0, errors.New("invalid asset")
+	}
+	key := string(publicKey) + string(asset)
+	requestsArr := []messaging.Request{messaging.Request{Type: messaging.Balance, Addr: tc.messagingAddr, PublicKey: publicKey, Asset: asset}}
 	requests := messaging.Requests{Requests: requestsArr}
 	// fmt.Printf("requests %v\n", requests)
 
@@ -63,8 +78,8 @@ func (tc *API) Balance(publicKey ed25519.PublicKey) (int64, error) {
 
 			response := messaging.ResponseBalance{}
 			response.Deserialize(responsePackets.Ps[0])
-			if bytes.Equal(response.PublicKey, publicKey) {
-				tc.balances[string(publicKey)] = response.Value
+			if bytes.Equal(response.PublicKey, publicKey) && bytes.Equal(response.Asset, asset) {
+				tc.balances[key] = response.Value
 				break
 			}
 			log.Warn("user.API's response PublicKey is different from initial one, continue reading!")
@@ -73,13 +88,106 @@ func (tc *API) Balance(publicKey ed25519.PublicKey) (int64, error) {
 		}
 	}
 
-	if val, ok := tc.balances[string(publicKey)]; ok {
+	if val, ok := tc.balances[key]; ok {
 		return val, nil
 	}
 	return 0, errors.New("public key not found")
 
 }
 
+// BalanceProof method requests balance of user holding 'publicKey' together with
+// Merkle proof of its inclusion into the node's state root and verifies it.
+// The caller should compare returned root with the one it trusts (e.g. root of
+// the snapshot at the same height). If the response packet is dropped by the
+// network, this method will hang indefinitely.
+func (tc *API) BalanceProof(publicKey ed25519.PublicKey) (*messaging.ResponseBalanceProof, error) {
+	log.Info("BalanceProof")
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("public key not found")
+	}
+	requestsArr := []messaging.Request{messaging.Request{Type: messaging.BalanceProof, Addr: tc.messagingAddr, PublicKey: publicKey}}
+	requests := messaging.Requests{Requests: requestsArr}
+	requests.Serialize().WriteTo(tc.messagingSocket)
+	for {
+		responsePackets := network.NewNumPackets(1)
+		n := responsePackets.ReadFrom(tc.messagingSocket)
+		if n == 0 || responsePackets.Ps[0].Data[0] != messaging.BalanceProof {
+			continue
+		}
+		log.Info("user.API read balance proof response: ", zap.Int("bytes", n))
+		response := new(messaging.ResponseBalanceProof)
+		response.Deserialize(responsePackets.Ps[0])
+		if !bytes.Equal(response.PublicKey, publicKey) {
+			log.Warn("user.API's response PublicKey is different from initial one, continue reading!")
+			continue
+		}
+		if !response.Found {
+			return nil, errors.New("public key not found")
+		}
+		if !response.Verify() {
+			return nil, errors.New("balance proof verification failed")
+		}
+		tc.balances[string(publicKey)] = response.Balance
+		return response, nil
+	}
+}
+
+// VerifyTransactionProof checks proof of inclusion of the transaction with
+// given signature into a block, e.g. the one returned by the REST API. The
+// block header should be signed by the producer the caller trusts, so the
+// transaction is proven without downloading the whole block.
+func VerifyTransactionProof(proof *block.TransactionProof, signature []byte, producer ed25519.PublicKey) error {
+	if proof == nil || !bytes.Equal(proof.Transaction.Signature, signature) {
+		return errors.New("proof is not for the transaction")
+	}
+	if !bytes.Equal(proof.Block.Producer, producer) {
+		return errors.New("block is not produced by the producer")
+	}
+	if !proof.Transaction.VerifySignature() {
+		return errors.New("invalid transaction signature")
+	}
+	if !proof.Verify() {
+		return errors.New("transaction proof verification failed")
+	}
+	return nil
+}
+
+// TransactionStatus requests receipt of the transaction with given signature.
+// The request is repeated every statusPollInterval while the transaction is
+// pending or unknown, until it is confirmed or rejected. If timeout expires
+// the last received receipt is returned together with an error.
+func (tc *API) TransactionStatus(signature []byte, timeout time.Duration) (books.Receipt, error) {
+	log.Info("TransactionStatus")
+	var receipt books.Receipt
+	if len(signature) != ed25519.SignatureSize {
+		return receipt, errors.New("invalid signature")
+	}
+	requestsArr := []messaging.Request{messaging.Request{Type: messaging.TransactionStatus, Addr: tc.messagingAddr, Signature: signature}}
+	requests := messaging.Requests{Requests: requestsArr}
+	deadline := time.Now().Add(timeout)
+	for {
+		start := time.Now()
+		requests.Serialize().WriteTo(tc.messagingSocket)
+		tc.messagingSocket.SetReadDeadline(start.Add(statusPollInterval))
+		responsePackets := network.NewNumPackets(1)
+		n := responsePackets.ReadFrom(tc.messagingSocket)
+		if n > 0 && responsePackets.Ps[0].Data[0] == messaging.TransactionStatus {
+			response := messaging.ResponseTransactionStatus{}
+			response.Deserialize(responsePackets.Ps[0])
+			if bytes.Equal(response.Signature, signature) {
+				receipt = response.Receipt
+				if receipt.Status == books.StatusConfirmed || receipt.Status == books.StatusRejected {
+					return receipt, nil
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			return receipt, errors.New("transaction status timeout")
+		}
+		time.Sleep(statusPollInterval - time.Since(start))
+	}
+}
+
 // TransactionsTotal requests the transaction count from server.
 // If the response packet is dropped by the network, this method will hang.
 func (tc *API) TransactionsTotal() uint64 {
@@ -119,6 +227,97 @@ func (tc *API) Transfer(from *block.KeyPair, to ed25519.PublicKey, token int64,
 	tc.TransferTransaction(tran)
 }
 
+// TransferWithNonce will create Transaction with sender's next nonce, sign and transfer
+// to the transactionSocket. Such transaction is not bound to the vdf window of the ledger.
+func (tc *API) TransferWithNonce(from *block.KeyPair, to ed25519.PublicKey, token int64, vdf block.VDFValue, nonce uint64) {
+	tran := block.NewTransactionWithNonce(from, to, token, 0, vdf, nonce)
+	tc.TransferTransaction(tran)
+}
+
+// TransferWithMemo will create Transaction with memo, sign and transfer to the transactionSocket
+func (tc *API) TransferWithMemo(from *block.KeyPair, to ed25519.PublicKey, token int64, vdf block.VDFValue, memo []byte) {
+	tran := block.NewTransactionWithMemo(from, to, token, 0, vdf, memo)
+	tc.TransferTransaction(tran)
+}
+
+// TransferBatch will create batch transfer Transaction, sign and transfer to the transactionSocket.
+// Number of outputs is limited by block.MaxOutputs.
+func (tc *API) TransferBatch(from *block.KeyPair, outputs []block.Output, fee int64, vdf block.VDFValue) {
+	tran := block.NewBatchTransaction(from, outputs, fee, vdf)
+	tc.TransferTransaction(tran)
+}
+
+// RegisterMultisig will create multisig registration Transaction, sign and transfer to the transactionSocket.
+// Afterwards transactions of the sender must be cosigned by at least threshold of signers.
+func (tc *API) RegisterMultisig(from *block.KeyPair, threshold byte, signers []ed25519.PublicKey, fee int64, vdf block.VDFValue) {
+	tran := block.NewMultisigRegistration(from, threshold, signers, fee, vdf)
+	tc.TransferTransaction(tran)
+}
+
+// TransferEscrow will create escrow Transaction, sign and transfer to the transactionSocket.
+// Tokens are paid to the recipient once the condition holds. Signature of the
+// returned transaction identifies the escrow.
+func (tc *API) TransferEscrow(from *block.KeyPair, to ed25519.PublicKey, token int64, fee int64, condition block.EscrowCondition, vdf block.VDFValue) block.Transaction {
+	tran := block.NewEscrow(from, to, token, fee, condition, vdf)
+	tc.TransferTransaction(tran)
+	return tran
+}
+
+// ReleaseEscrow will create escrow release Transaction, sign and transfer to the transactionSocket.
+func (tc *API) ReleaseEscrow(from *block.KeyPair, escrow []byte, fee int64, vdf block.VDFValue) {
+	tran := block.NewEscrowRelease(from, escrow, fee, vdf)
+	tc.TransferTransaction(tran)
+}
+
+// CancelEscrow will create escrow cancel Transaction, sign and transfer to the transactionSocket.
+func (tc *API) CancelEscrow(from *block.KeyPair, escrow []byte, fee int64, vdf block.VDFValue) {
+	tran := block.NewEscrowCancel(from, escrow, fee, vdf)
+	tc.TransferTransaction(tran)
+}
+
+// CreateAsset will create asset issuing Transaction, sign and transfer to the transactionSocket.
+// Supply is credited to the mint authority. AssetID of the returned transaction identifies the asset.
+func (tc *API) CreateAsset(from *block.KeyPair, authority ed25519.PublicKey, supply int64, fee int64, vdf block.VDFValue) block.Transaction {
+	tran := block.NewCreateAsset(from, authority, supply, fee, vdf)
+	tc.TransferTransaction(tran)
+	return tran
+}
+
+// MintAsset will create asset minting Transaction, sign and transfer to the transactionSocket.
+func (tc *API) MintAsset(from *block.KeyPair, asset []byte, to ed25519.PublicKey, token int64, fee int64, vdf block.VDFValue) {
+	tran := block.NewMintAsset(from, asset, to, token, fee, vdf)
+	tc.TransferTransaction(tran)
+}
+
+// TransferAsset will create asset transfer Transaction, sign and transfer to the transactionSocket.
+// Fee is paid in native tokens.
+func (tc *API) TransferAsset(from *block.KeyPair, asset []byte, to ed25519.PublicKey, token int64, fee int64, vdf block.VDFValue) {
+	tran := block.NewAssetTransfer(from, asset, to, token, fee, vdf)
+	tc.TransferTransaction(tran)
+}
+
+// Stake will create stake delegation Transaction, sign and transfer to the transactionSocket.
+// Fee is included in token.
+func (tc *API) Stake(from *block.KeyPair, validator ed25519.PublicKey, token int64, fee int64, vdf block.VDFValue) {
+	tran := block.NewStake(from, validator, token, fee, vdf)
+	tc.TransferTransaction(tran)
+}
+
+// Unstake will create unstake Transaction, sign and transfer to the transactionSocket.
+// Unstaked token is returned after cool-down. Returned transaction identifies the unbond.
+func (tc *API) Unstake(from *block.KeyPair, validator ed25519.PublicKey, token int64, fee int64, vdf block.VDFValue) block.Transaction {
+	tran := block.NewUnstake(from, validator, token, fee, vdf)
+	tc.TransferTransaction(tran)
+	return tran
+}
+
+// BindNode will create Transaction which binds the node to the validator, sign and
+// transfer to the transactionSocket. The node votes with stake of the validator.
+func (tc *API) BindNode(validator *block.KeyPair, node ed25519.PublicKey, fee int64, vdf block.VDFValue) {
+	tran := block.NewBindNode(validator, node, fee, vdf)
+	tc.TransferTransaction(tran)
+}
+
 // ValidVDFValue method queries producer for the valid vdf saved in the ledger
 // and returns the result.
 // If producer will not respond or the request will be lost this method will hang.
@@ -4,8 +4,10 @@ import (
 	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/Ansiblock/Ansiblock/block"
+	"github.com/Ansiblock/Ansiblock/books"
 	"github.com/Ansiblock/Ansiblock/messaging"
 	"github.com/Ansiblock/Ansiblock/network"
 )
@@ -103,3 +105,72 @@ func TestTransfer(t *testing.T) {
 		t.Errorf("Incorrect transfer")
 	}
 }
+
+func TestBalanceProof(t *testing.T) {
+	accounts, keyPairs := books.RandomAccounts(20)
+	publicKey := keyPairs[3].Public
+	response := messaging.ResponseBalanceProof{AccountProof: *accounts.AccountProof(publicKey), Addr: &MessagingAddrServerUDP}
+	packet := response.Serialize()
+
+	messagingCon := network.NewSocketMock(nil, nil, &MessagingAddrServerUDP)
+	messagingCon.AddToReadBuff(packet.Data[:packet.Size])
+	us := NewUserAPI(&MessagingAddrServerUDP, nil, messagingCon, nil)
+	proof, err := us.BalanceProof(publicKey)
+	if err != nil || proof.Balance != accounts.Balance(publicKey) || !reflect.DeepEqual(proof.Root, accounts.StateRoot()) {
+		t.Errorf(`balance proof request error! error: %v`, err)
+	}
+
+	response.Balance++
+	packet = response.Serialize()
+	messagingCon.AddToReadBuff(packet.Data[:packet.Size])
+	if _, err = us.BalanceProof(publicKey); err == nil {
+		t.Errorf("forged balance should not be accepted")
+	}
+}
+
+func TestTransactionStatus(t *testing.T) {
+	kp := block.NewKeyPair()
+	tran := block.NewTransaction(&kp, kp.Public, 10, 0, block.VDF([]byte{1}))
+	pending := messaging.ResponseTransactionStatus{Receipt: books.Receipt{Status: books.StatusPending}, Signature: tran.Signature, Addr: &MessagingAddrServerUDP}
+	confirmed := messaging.ResponseTransactionStatus{Receipt: books.Receipt{Status: books.StatusConfirmed, Height: 42}, Signature: tran.Signature, Addr: &MessagingAddrServerUDP}
+	packet := pending.Serialize()
+	packet2 := confirmed.Serialize()
+
+	messagingCon := network.NewSocketMock(nil, nil, &MessagingAddrServerUDP)
+	messagingCon.AddToReadBuff(packet.Data[:packet.Size])
+	messagingCon.AddToReadBuff(packet2.Data[:packet2.Size])
+	us := NewUserAPI(&MessagingAddrServerUDP, nil, messagingCon, nil)
+	receipt, err := us.TransactionStatus(tran.Signature, time.Second)
+	if err != nil || receipt.Status != books.StatusConfirmed || receipt.Height != 42 {
+		t.Errorf("transaction status error: %v, receipt %v", err, receipt)
+	}
+
+	messagingCon.AddToReadBuff(packet.Data[:packet.Size])
+	receipt, err = us.TransactionStatus(tran.Signature, 300*time.Millisecond)
+	if err == nil || receipt.Status != books.StatusPending {
+		t.Errorf("pending transaction should time out, receipt %v", receipt)
+	}
+}
+
+func TestVerifyTransactionProof(t *testing.T) {
+	producer := block.NewKeyPair()
+	prev := block.NewEmpty(block.VDF([]byte("user")), 0, 0)
+	trans := block.CreateRealTransactions(5)
+	bl := block.New(prev.Val, prev.Number, 0, &trans)
+	bl.Seal(&producer, &prev)
+	signature := trans.Ts[2].Signature
+	proof := bl.TransactionProof(signature)
+	if err := VerifyTransactionProof(proof, signature, producer.Public); err != nil {
+		t.Errorf("valid proof should be verified, got %v", err)
+	}
+	if err := VerifyTransactionProof(proof, trans.Ts[1].Signature, producer.Public); err == nil {
+		t.Errorf("proof should not be accepted for other transaction")
+	}
+	if err := VerifyTransactionProof(proof, signature, block.NewKeyPair().Public); err == nil {
+		t.Errorf("proof should not be accepted from other producer")
+	}
+	proof.Proof = proof.Proof[1:]
+	if err := VerifyTransactionProof(proof, signature, producer.Public); err == nil {
+		t.Errorf("proof with missing step should not be accepted")
+	}
+}